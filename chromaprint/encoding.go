@@ -34,6 +34,7 @@ func EncodeFingerprintToString(data []byte) string {
 }
 
 // ParseFingerprint reads binary fingerprint data and returns a parsed Fingerprint structure.
+// Use a Decoder to parse many fingerprints without allocating memory for each of them.
 func ParseFingerprint(data []byte) (*Fingerprint, error) {
 	var fp Fingerprint
 	err := unpackFingerprint(data, &fp)
@@ -66,6 +67,42 @@ func ValidateFingerprintString(str string) bool {
 	return ValidateFingerprint(data)
 }
 
+// Decoder decodes fingerprints into buffers which are reused between calls, so that decoding
+// many fingerprints does not allocate memory. The zero value is ready to use. A Decoder is not safe
+// for concurrent use.
+type Decoder struct {
+	str  []byte
+	data []byte
+	fp   Fingerprint
+}
+
+// Decode reads binary fingerprint data. The returned fingerprint is only valid until the next call.
+func (d *Decoder) Decode(data []byte) (*Fingerprint, error) {
+	err := unpackFingerprint(data, &d.fp)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid fingerprint")
+	}
+	return &d.fp, nil
+}
+
+// DecodeString reads base64-encoded fingerprint string. The returned fingerprint is only valid until the next call.
+func (d *Decoder) DecodeString(str string) (*Fingerprint, error) {
+	if len(str) == 0 {
+		return nil, errors.New("empty")
+	}
+	d.str = append(d.str[:0], str...)
+	n := base64.RawURLEncoding.DecodedLen(len(d.str))
+	if cap(d.data) < n {
+		d.data = make([]byte, n)
+	}
+	n, err := base64.RawURLEncoding.Decode(d.data[:n], d.str)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base64 encoding")
+	}
+	return d.Decode(d.data[:n])
+}
+
+// unpackFingerprint decodes the data into fp, reusing the memory of fp.Hashes. If fp is nil, it only validates the data.
 func unpackFingerprint(data []byte, fp *Fingerprint) error {
 	if len(data) < 4 {
 		return errors.New("data is less than 4 bytes")
@@ -81,55 +118,55 @@ func unpackFingerprint(data []byte, fp *Fingerprint) error {
 		return errors.New("empty")
 	}
 
-	bits := util.UnpackUint3Slice(data[offset:])
+	var normalBits util.BitReader
+	normalBits.Reset(data[offset:])
+	numNormalBits := 0
 	numValues := 0
 	numExceptionalBits := 0
-	for bi, bit := range bits {
+	for numValues < totalValues {
+		bit, ok := normalBits.Read(3)
+		if !ok {
+			return errors.New("not enough data to decode normal bits")
+		}
+		numNormalBits++
 		if bit == 0 {
 			numValues++
-			if numValues == totalValues {
-				bits = bits[:bi+1]
-				offset += (len(bits)*3 + 7) / 8
-				break
-			}
 		} else if bit == 7 {
 			numExceptionalBits++
 		}
 	}
 
-	if numValues != totalValues {
-		return errors.New("not enough data to decode normal bits")
-	}
-
-	if numExceptionalBits > 0 {
-		exceptionalBits := util.UnpackUint5Slice(data[offset:])
-		if len(exceptionalBits) < numExceptionalBits {
-			return errors.New("not enough data to decode exceptional bits")
-		}
-		ei := 0
-		for bi, bit := range bits {
-			if bit == 7 {
-				bits[bi] += exceptionalBits[ei]
-				ei++
-			}
-		}
+	exceptionalOffset := offset + (numNormalBits*3+7)/8
+	if numExceptionalBits*5 > (len(data)-exceptionalOffset)*8 {
+		return errors.New("not enough data to decode exceptional bits")
 	}
 
 	if fp != nil {
-		hashes := make([]uint32, totalValues)
-		hi := 0
+		if cap(fp.Hashes) < totalValues {
+			fp.Hashes = make([]uint32, 0, totalValues)
+		}
+		hashes := fp.Hashes[:0]
+		var exceptionalBits util.BitReader
+		normalBits.Reset(data[offset:])
+		exceptionalBits.Reset(data[exceptionalOffset:])
+		var hash, lastHash uint32
 		var lastBit uint8
-		for _, bit := range bits {
+		for len(hashes) < totalValues {
+			bit, _ := normalBits.Read(3)
 			if bit == 0 {
-				if hi > 0 {
-					hashes[hi] ^= hashes[hi-1]
-				}
+				hash ^= lastHash
+				hashes = append(hashes, hash)
+				lastHash = hash
+				hash = 0
 				lastBit = 0
-				hi++
-			} else {
-				lastBit += bit
-				hashes[hi] |= 1 << (lastBit - 1)
+				continue
+			}
+			if bit == 7 {
+				exceptionalBit, _ := exceptionalBits.Read(5)
+				bit += exceptionalBit
 			}
+			lastBit += uint8(bit)
+			hash |= 1 << (lastBit - 1)
 		}
 		fp.Version = version
 		fp.Hashes = hashes
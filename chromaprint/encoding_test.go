@@ -106,6 +106,51 @@ func TestParseFingerprintString2(t *testing.T) {
 	}
 }
 
+func TestDecoder(t *testing.T) {
+	var d Decoder
+	for i := 0; i < 2; i++ {
+		fp, err := d.DecodeString(TestFingerprint2String)
+		if assert.NoError(t, err) {
+			assert.Equal(t, TestFingerprint2Version, fp.Version)
+			assert.Equal(t, TestFingerprint2Hashes, fp.Hashes)
+		}
+		fp, err = d.DecodeString(TestFingerprintString)
+		if assert.NoError(t, err) {
+			assert.Equal(t, TestFingerprintVersion, fp.Version)
+			assert.Equal(t, TestFingerprintHashes, fp.Hashes)
+		}
+		fp, err = d.Decode([]byte{0, 0, 0, 2, 65, 0})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 0, fp.Version)
+			assert.Equal(t, []uint32{1, 0}, fp.Hashes)
+		}
+	}
+
+	_, err := d.DecodeString("")
+	assert.Error(t, err)
+	_, err = d.DecodeString("@#$")
+	assert.Error(t, err)
+	_, err = d.DecodeString("AQAAEwkjrUmSJQpUHflR9mjSJMdZpcO")
+	assert.Error(t, err)
+	_, err = d.Decode([]byte{0, 0, 0, 1, 7})
+	assert.Error(t, err)
+}
+
+func BenchmarkParseFingerprintString(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseFingerprintString(TestFingerprint2String)
+	}
+}
+
+func BenchmarkDecoder_DecodeString(b *testing.B) {
+	var d Decoder
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.DecodeString(TestFingerprint2String)
+	}
+}
+
 func TestValidateFingerprintString(t *testing.T) {
 	assert.False(t, ValidateFingerprintString(""))
 	assert.False(t, ValidateFingerprintString("@#$"))
@@ -0,0 +1,216 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package client
+
+import (
+	"bufio"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/proto"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/pkg/errors"
+	"net"
+)
+
+// maxPendingRequests limits how many add/delete requests can be sent before waiting for their responses.
+const maxPendingRequests = 1024
+
+// BinaryClient talks to the index server using the binary protocol from the proto package.
+// It holds one connection, which has its own transaction on the server. Adds and deletes
+// are pipelined, so their errors might only be reported by a later call.
+//
+// BinaryClient is not safe for concurrent use.
+type BinaryClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	req     proto.Encoder
+	buf     []byte
+	pending int
+	err     error
+}
+
+var _ index.Batch = (*BinaryClient)(nil)
+
+// DialBinary connects to the binary protocol listener of the index server at addr.
+func DialBinary(addr string) (*BinaryClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewBinaryClient(conn), nil
+}
+
+// NewBinaryClient creates a new BinaryClient using an existing connection.
+func NewBinaryClient(conn net.Conn) *BinaryClient {
+	return &BinaryClient{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+}
+
+// Add adds a document to the connection's transaction.
+func (c *BinaryClient) Add(docID uint32, terms []uint32) error {
+	c.req.Reset(proto.OpAdd)
+	c.req.PutUint32(docID)
+	c.req.PutUint32s(terms)
+	return c.send()
+}
+
+// Delete deletes a document in the connection's transaction.
+func (c *BinaryClient) Delete(docID uint32) error {
+	c.req.Reset(proto.OpDelete)
+	c.req.PutUint32(docID)
+	return c.send()
+}
+
+// DeleteMany deletes multiple documents in the connection's transaction.
+func (c *BinaryClient) DeleteMany(docs *intset.SparseBitSet) error {
+	c.req.Reset(proto.OpDeleteMany)
+	c.req.PutUint32(uint32(docs.Len()))
+	docs.ForEach(func(docID uint32) { c.req.PutUint32(docID) })
+	return c.send()
+}
+
+// DeleteRange deletes documents with docIDs between min and max, inclusive, in the connection's transaction.
+func (c *BinaryClient) DeleteRange(min, max uint32) error {
+	c.req.Reset(proto.OpDeleteRange)
+	c.req.PutUint32(min)
+	c.req.PutUint32(max)
+	return c.send()
+}
+
+// Import groups the items by docID and adds the documents to the connection's transaction.
+// The whole input is buffered in memory.
+func (c *BinaryClient) Import(input index.ItemReader) error {
+	return importDocs(input, c.Add)
+}
+
+// Search finds documents matching the terms on the remote index.
+func (c *BinaryClient) Search(terms []uint32) (map[uint32]int, error) {
+	c.req.Reset(proto.OpSearch)
+	c.req.PutUint32s(terms)
+	resp, err := c.call()
+	if err != nil {
+		return nil, err
+	}
+	n := resp.Uint32()
+	hits := make(map[uint32]int, n)
+	for i := uint32(0); i < n; i++ {
+		docID := resp.Uint32()
+		hits[docID] = int(resp.Uint32())
+	}
+	err = resp.Err()
+	if err != nil {
+		return nil, c.fail(errors.Wrap(err, "invalid response"))
+	}
+	return hits, nil
+}
+
+// Commit atomically applies all previous adds and deletes to the remote index.
+// If any of them failed, nothing is committed and the error is returned.
+func (c *BinaryClient) Commit() error {
+	c.req.Reset(proto.OpCommit)
+	_, err := c.call()
+	return err
+}
+
+// Close closes the connection, aborting any uncommitted changes.
+func (c *BinaryClient) Close() error {
+	return c.conn.Close()
+}
+
+// send sends a pipelined request, without waiting for the response.
+func (c *BinaryClient) send() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.pending >= maxPendingRequests {
+		err := c.drain()
+		if err != nil {
+			return err
+		}
+	}
+	err := proto.WriteFrame(c.writer, c.req.Bytes())
+	if err != nil {
+		return c.fail(errors.Wrap(err, "write failed"))
+	}
+	c.pending++
+	return nil
+}
+
+// call sends a request and waits for its response.
+func (c *BinaryClient) call() (*proto.Decoder, error) {
+	err := c.drain()
+	if err != nil {
+		return nil, err
+	}
+	err = proto.WriteFrame(c.writer, c.req.Bytes())
+	if err == nil {
+		err = c.writer.Flush()
+	}
+	if err != nil {
+		return nil, c.fail(errors.Wrap(err, "write failed"))
+	}
+	return c.receive()
+}
+
+// drain waits for the responses to all pipelined requests.
+func (c *BinaryClient) drain() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.pending == 0 {
+		return nil
+	}
+	err := c.writer.Flush()
+	if err != nil {
+		return c.fail(errors.Wrap(err, "write failed"))
+	}
+	var firstErr error
+	for c.pending > 0 {
+		c.pending--
+		_, err := c.receive()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		// The transaction is incomplete, do not allow committing it.
+		return c.fail(firstErr)
+	}
+	return nil
+}
+
+func (c *BinaryClient) receive() (*proto.Decoder, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	payload, err := proto.ReadFrame(c.reader, c.buf)
+	if err != nil {
+		return nil, c.fail(errors.Wrap(err, "read failed"))
+	}
+	c.buf = payload[:0]
+	resp := proto.NewDecoder(payload)
+	switch resp.Code() {
+	case proto.StatusOK:
+		return resp, nil
+	case proto.StatusError:
+		message := resp.String()
+		if resp.Err() != nil {
+			return nil, c.fail(errors.Wrap(resp.Err(), "invalid response"))
+		}
+		return nil, errors.Errorf("server error: %v", message)
+	default:
+		return nil, c.fail(errors.New("invalid response status"))
+	}
+}
+
+// fail marks the client as broken. All following calls will return err.
+func (c *BinaryClient) fail(err error) error {
+	if c.err == nil {
+		c.err = err
+	}
+	return c.err
+}
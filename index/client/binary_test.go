@@ -0,0 +1,114 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package client
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/server"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func newTestBinaryServer(t *testing.T) (*index.DB, net.Listener) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to listen")
+	go server.ServeBinary(l, db)
+	return db, l
+}
+
+func TestBinaryClient(t *testing.T) {
+	db, l := newTestBinaryServer(t)
+	defer db.Close()
+	defer l.Close()
+
+	c, err := DialBinary(l.Addr().String())
+	require.NoError(t, err, "failed to connect")
+	defer c.Close()
+
+	for docID := uint32(1); docID <= 3000; docID++ {
+		require.NoError(t, c.Add(docID, []uint32{docID % 10, 1000 + docID}), "add failed")
+	}
+	require.Equal(t, 0, db.NumDocs(), "changes should not be visible before commit")
+	require.NoError(t, c.Commit(), "commit failed")
+	require.Equal(t, 3000, db.NumDocs())
+
+	hits, err := c.Search([]uint32{1001, 1002, 1003})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 1, 2: 1, 3: 1}, hits)
+
+	require.NoError(t, c.Delete(1), "delete failed")
+	require.NoError(t, c.Add(2, []uint32{1001}), "add failed")
+	require.NoError(t, c.Commit(), "commit failed")
+
+	hits, err = c.Search([]uint32{1001, 1002, 1003})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{2: 1, 3: 1}, hits)
+
+	docs := intset.NewSparseBitSet(0)
+	docs.Add(2)
+	docs.Add(4)
+	require.NoError(t, c.DeleteMany(docs), "delete failed")
+	require.NoError(t, c.DeleteRange(1000, 2999), "delete failed")
+	require.NoError(t, c.Commit(), "commit failed")
+	require.Equal(t, 997, db.NumDocs()-db.NumDeletedDocs())
+
+	hits, err = c.Search([]uint32{1001, 1002, 1003, 4000})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{3: 1, 3000: 1}, hits)
+
+	hits, err = c.Search(nil)
+	require.NoError(t, err, "search failed")
+	require.Empty(t, hits)
+}
+
+func TestBinaryClient_Abort(t *testing.T) {
+	db, l := newTestBinaryServer(t)
+	defer db.Close()
+	defer l.Close()
+
+	c, err := DialBinary(l.Addr().String())
+	require.NoError(t, err, "failed to connect")
+	require.NoError(t, c.Add(1, []uint32{1, 2, 3}), "add failed")
+	_, err = c.Search([]uint32{1})
+	require.NoError(t, err, "search failed")
+	require.NoError(t, c.Close())
+
+	c, err = DialBinary(l.Addr().String())
+	require.NoError(t, err, "failed to connect")
+	defer c.Close()
+	require.NoError(t, c.Commit(), "commit failed")
+	require.Equal(t, 0, db.NumDocs(), "uncommitted changes from a closed connection should be discarded")
+}
+
+func TestBinaryClient_Error(t *testing.T) {
+	opts := *index.DefaultOptions
+	opts.ReadOnly = true
+	fs := vfs.CreateMemDir()
+	db, err := index.Open(fs, true, nil)
+	require.NoError(t, err, "failed to create test db")
+	db.Close()
+	db, err = index.Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open test db")
+	defer db.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to listen")
+	defer l.Close()
+	go server.ServeBinary(l, db)
+
+	c, err := DialBinary(l.Addr().String())
+	require.NoError(t, err, "failed to connect")
+	defer c.Close()
+
+	require.NoError(t, c.Add(1, []uint32{1}), "pipelined add should not wait for the response")
+	err = c.Commit()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "read-only")
+	require.Equal(t, err, c.Add(2, []uint32{1}), "client should stay failed")
+}
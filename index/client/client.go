@@ -0,0 +1,432 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package client provides access to a remote index served by the index/server package.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrBatchClosed = errors.New("batch is closed")
+
+const readerBlockSize = 1024
+
+// Client talks to a remote index server. It implements index.Searcher and starts remote transactions
+// implementing index.Batch, so it can be used in place of a local index.DB. It is safe for concurrent use.
+type Client struct {
+	// URL is the base URL of the index server.
+	URL string
+
+	// HTTPClient is the HTTP client used for talking to the index server.
+	HTTPClient *http.Client
+
+	// APIKey is sent in the Authorization header, if not empty.
+	APIKey string
+}
+
+var _ index.Searcher = (*Client)(nil)
+
+// New creates a new Client for the index server at url.
+func New(url string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Stats contains basic information about the remote index.
+type Stats struct {
+	NumDocs        int `json:"num_docs"`
+	NumDeletedDocs int `json:"num_deleted_docs"`
+	NumSegments    int `json:"num_segments"`
+}
+
+// Stats returns basic information about the remote index.
+func (c *Client) Stats() (*Stats, error) {
+	resp, err := c.get("/stats")
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	var stats Stats
+	err = readResponse(resp, &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Attributes returns all attributes of the remote index.
+func (c *Client) Attributes() (map[string]string, error) {
+	resp, err := c.get("/attributes")
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	var output struct {
+		Attributes map[string]string `json:"attributes"`
+	}
+	err = readResponse(resp, &output)
+	if err != nil {
+		return nil, err
+	}
+	return output.Attributes, nil
+}
+
+// Search finds documents matching the terms on the remote index.
+func (c *Client) Search(terms []uint32) (map[uint32]int, error) {
+	body, err := json.Marshal(struct {
+		Terms []uint32 `json:"terms"`
+	}{Terms: terms})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the request")
+	}
+
+	resp, err := c.post("/search", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	var output struct {
+		Hits map[uint32]int `json:"hits"`
+	}
+	err = readResponse(resp, &output)
+	if err != nil {
+		return nil, err
+	}
+	return output.Hits, nil
+}
+
+// Reader returns an ItemReader that streams all items from the remote index.
+// The reader must be read until it returns an error, otherwise the connection is leaked.
+func (c *Client) Reader() index.ItemReader {
+	return &itemReader{client: c}
+}
+
+// Transaction starts a new remote write transaction.
+func (c *Client) Transaction() (index.Batch, error) {
+	return newBatch(c), nil
+}
+
+// RunInTransaction calls fn in a new remote transaction and commits it, unless fn returns an error.
+func (c *Client) RunInTransaction(fn func(txn index.Batch) error) error {
+	txn, err := c.Transaction()
+	if err != nil {
+		return err
+	}
+	defer txn.Close()
+
+	err = fn(txn)
+	if err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
+
+// Close does nothing, the client does not hold any resources between requests.
+func (c *Client) Close() error {
+	return nil
+}
+
+// Batch is a remote write transaction. The operations are streamed to the server as they are added,
+// in the body of a single request, and the server only applies them when the request is finished.
+type Batch struct {
+	pw      *io.PipeWriter
+	buf     *bufio.Writer
+	encoder *json.Encoder
+	result  chan error
+	closed  bool
+}
+
+var _ index.Batch = (*Batch)(nil)
+
+func newBatch(c *Client) *Batch {
+	pr, pw := io.Pipe()
+	b := &Batch{pw: pw, result: make(chan error, 1)}
+	b.buf = bufio.NewWriter(pw)
+	b.encoder = json.NewEncoder(b.buf)
+	go func() {
+		resp, err := c.post("/index", "application/x-ndjson", pr)
+		if err != nil {
+			err = errors.Wrap(err, "request failed")
+		} else {
+			err = readResponse(resp, nil)
+		}
+		pr.CloseWithError(err)
+		b.result <- err
+	}()
+	return b
+}
+
+func (b *Batch) write(v interface{}) error {
+	if b.closed {
+		return ErrBatchClosed
+	}
+	err := b.encoder.Encode(v)
+	if err != nil {
+		// The request has most likely failed, so report the reason instead of the pipe error.
+		b.closed = true
+		b.pw.CloseWithError(err)
+		if reqErr := <-b.result; reqErr != nil {
+			return reqErr
+		}
+		return errors.Wrap(err, "write failed")
+	}
+	return nil
+}
+
+func (b *Batch) Add(docID uint32, terms []uint32) error {
+	return b.write(struct {
+		DocID uint32   `json:"id"`
+		Terms []uint32 `json:"terms"`
+	}{DocID: docID, Terms: terms})
+}
+
+func (b *Batch) Delete(docID uint32) error {
+	return b.write(struct {
+		DocID  uint32 `json:"id"`
+		Delete bool   `json:"delete"`
+	}{DocID: docID, Delete: true})
+}
+
+func (b *Batch) DeleteMany(docs *intset.SparseBitSet) error {
+	ids := make([]uint32, 0, docs.Len())
+	docs.ForEach(func(docID uint32) { ids = append(ids, docID) })
+	return b.write(struct {
+		DocIDs []uint32 `json:"delete_ids"`
+	}{DocIDs: ids})
+}
+
+func (b *Batch) DeleteRange(min, max uint32) error {
+	type docIDRange struct {
+		Min uint32 `json:"min"`
+		Max uint32 `json:"max"`
+	}
+	return b.write(struct {
+		Ranges []docIDRange `json:"delete_ranges"`
+	}{Ranges: []docIDRange{{Min: min, Max: max}}})
+}
+
+// SetAttribute sets an index attribute. Setting an empty value removes the attribute.
+func (b *Batch) SetAttribute(name, value string) error {
+	return b.write(struct {
+		Attributes map[string]string `json:"attributes"`
+	}{Attributes: map[string]string{name: value}})
+}
+
+// Import adds a stream of items, sorted by term, to the batch. The items are sent in blocks
+// as they are read from the input and the server imports them into a single new segment.
+func (b *Batch) Import(input index.ItemReader) error {
+	type itemsOp struct {
+		Items [][2]uint32 `json:"items"`
+	}
+	items := make([][2]uint32, 0, readerBlockSize)
+	for {
+		block, err := input.ReadBlock()
+		for len(block) > 0 {
+			items = items[:0]
+			for _, item := range block {
+				if len(items) == readerBlockSize {
+					break
+				}
+				items = append(items, [2]uint32{item.Term, item.DocID})
+			}
+			block = block[len(items):]
+			err := b.write(itemsOp{Items: items})
+			if err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrap(err, "read failed")
+		}
+	}
+	// An empty block marks the end of the import.
+	return b.write(itemsOp{Items: [][2]uint32{}})
+}
+
+// Commit finishes the request and waits for the server to apply the batch.
+func (b *Batch) Commit() error {
+	if b.closed {
+		return ErrBatchClosed
+	}
+	b.closed = true
+	err := b.buf.Flush()
+	if err != nil {
+		b.pw.CloseWithError(err)
+		if reqErr := <-b.result; reqErr != nil {
+			return reqErr
+		}
+		return errors.Wrap(err, "write failed")
+	}
+	b.pw.Close()
+	return <-b.result
+}
+
+// Close aborts the batch if it was not committed yet.
+func (b *Batch) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.pw.CloseWithError(errors.New("batch aborted"))
+	<-b.result
+	return nil
+}
+
+type itemReader struct {
+	client  *Client
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	err     error
+}
+
+func (r *itemReader) ReadBlock() ([]index.Item, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if r.scanner == nil {
+		resp, err := r.client.get("/items")
+		if err != nil {
+			r.err = errors.Wrap(err, "request failed")
+			return nil, r.err
+		}
+		if resp.StatusCode != http.StatusOK {
+			r.err = readResponse(resp, nil)
+			return nil, r.err
+		}
+		r.body = resp.Body
+		r.scanner = bufio.NewScanner(resp.Body)
+	}
+
+	items := make([]index.Item, 0, readerBlockSize)
+	for len(items) < readerBlockSize && r.scanner.Scan() {
+		item, err := parseItem(r.scanner.Text())
+		if err != nil {
+			r.fail(err)
+			return items, r.err
+		}
+		items = append(items, item)
+	}
+	if len(items) < readerBlockSize {
+		err := r.scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		r.fail(err)
+		return items, r.err
+	}
+	return items, nil
+}
+
+func (r *itemReader) fail(err error) {
+	r.err = err
+	r.body.Close()
+}
+
+// importDocs groups the items by docID and passes the documents to add, ordered by docID.
+func importDocs(input index.ItemReader, add func(docID uint32, terms []uint32) error) error {
+	docs := make(map[uint32][]uint32)
+	for {
+		block, err := input.ReadBlock()
+		for _, item := range block {
+			docs[item.DocID] = append(docs[item.DocID], item.Term)
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrap(err, "read failed")
+		}
+	}
+
+	docIDs := make([]uint32, 0, len(docs))
+	for docID := range docs {
+		docIDs = append(docIDs, docID)
+	}
+	sort.Slice(docIDs, func(i, j int) bool { return docIDs[i] < docIDs[j] })
+
+	for _, docID := range docIDs {
+		err := add(docID, docs[docID])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseItem(line string) (index.Item, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return index.Item{}, errors.Errorf("invalid item %q", line)
+	}
+	term, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return index.Item{}, errors.Wrapf(err, "invalid term in item %q", line)
+	}
+	docID, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return index.Item{}, errors.Wrapf(err, "invalid docID in item %q", line)
+	}
+	return index.Item{Term: uint32(term), DocID: uint32(docID)}, nil
+}
+
+func (c *Client) get(path string) (*http.Response, error) {
+	return c.do("GET", path, "", nil)
+}
+
+func (c *Client) post(path, contentType string, body io.Reader) (*http.Response, error) {
+	return c.do("POST", path, contentType, body)
+}
+
+func (c *Client) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// readResponse decodes a JSON response into output, or converts an error response into an error.
+func readResponse(resp *http.Response, output interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		err := json.NewDecoder(resp.Body).Decode(&errResp)
+		if err != nil || errResp.Message == "" {
+			return errors.Errorf("unexpected status %v", resp.Status)
+		}
+		return errors.Errorf("server error: %v", errResp.Message)
+	}
+
+	if output == nil {
+		return nil
+	}
+	err := json.NewDecoder(resp.Body).Decode(output)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the response")
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package client
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/server"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func newTestServer(t *testing.T) (*index.DB, *httptest.Server) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	return db, httptest.NewServer(server.Handler(db))
+}
+
+func TestClient_AddSearch(t *testing.T) {
+	db, srv := newTestServer(t)
+	defer db.Close()
+	defer srv.Close()
+
+	c := New(srv.URL)
+	txn, err := c.Transaction()
+	require.NoError(t, err, "failed to start transaction")
+	require.NoError(t, txn.Add(1, []uint32{7, 8, 9}), "add failed")
+	require.NoError(t, txn.Add(2, []uint32{7, 10}), "add failed")
+	require.Equal(t, 0, db.NumDocs(), "changes should not be visible before commit")
+	require.NoError(t, txn.Commit(), "commit failed")
+	require.Equal(t, 2, db.NumDocs())
+
+	hits, err := c.Search([]uint32{7, 8})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 2, 2: 1}, hits)
+
+	require.NoError(t, c.RunInTransaction(func(txn index.Batch) error { return txn.Delete(1) }), "delete failed")
+
+	hits, err = c.Search([]uint32{7, 8})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{2: 1}, hits)
+
+	require.NoError(t, c.RunInTransaction(func(txn index.Batch) error {
+		for _, docID := range []uint32{3, 4, 5} {
+			err := txn.Add(docID, []uint32{7})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}), "add failed")
+	require.NoError(t, c.RunInTransaction(func(txn index.Batch) error {
+		docs := intset.NewSparseBitSet(0)
+		docs.Add(3)
+		err := txn.DeleteMany(docs)
+		if err != nil {
+			return err
+		}
+		return txn.DeleteRange(5, 10)
+	}), "delete failed")
+
+	hits, err = c.Search([]uint32{7})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{2: 1, 4: 1}, hits)
+
+	require.NoError(t, c.RunInTransaction(func(txn index.Batch) error { return txn.(*Batch).SetAttribute("max_document_id", "2") }), "set attribute failed")
+
+	attrs, err := c.Attributes()
+	require.NoError(t, err, "attributes failed")
+	require.Equal(t, map[string]string{"max_document_id": "2"}, attrs)
+
+	stats, err := c.Stats()
+	require.NoError(t, err, "stats failed")
+	require.Equal(t, &Stats{NumDocs: 5, NumDeletedDocs: 3, NumSegments: db.NumSegments()}, stats)
+}
+
+func TestClient_Concurrent(t *testing.T) {
+	db, srv := newTestServer(t)
+	defer db.Close()
+	defer srv.Close()
+
+	c := New(srv.URL)
+	var wg sync.WaitGroup
+	for i := uint32(1); i <= 10; i++ {
+		wg.Add(1)
+		go func(docID uint32) {
+			defer wg.Done()
+			assert.NoError(t, c.RunInTransaction(func(txn index.Batch) error { return txn.Add(docID, []uint32{7}) }), "add failed")
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 10, db.NumDocs())
+}
+
+func TestClient_Abort(t *testing.T) {
+	db, srv := newTestServer(t)
+	defer db.Close()
+	defer srv.Close()
+
+	c := New(srv.URL)
+	txn, err := c.Transaction()
+	require.NoError(t, err, "failed to start transaction")
+	require.NoError(t, txn.Add(1, []uint32{1}), "add failed")
+	require.NoError(t, txn.Close(), "close failed")
+	require.Equal(t, ErrBatchClosed, txn.Commit())
+	require.Equal(t, 0, db.NumDocs(), "aborted changes should not be committed")
+}
+
+func TestClient_Reader(t *testing.T) {
+	db, srv := newTestServer(t)
+	defer db.Close()
+	defer srv.Close()
+
+	c := New(srv.URL)
+
+	items, err := index.ReadAllItems(c.Reader())
+	require.NoError(t, err, "read failed")
+	require.Empty(t, items)
+
+	var expected []index.Item
+	txn, err := c.Transaction()
+	require.NoError(t, err, "failed to start transaction")
+	for docID := uint32(1); docID <= 1000; docID++ {
+		terms := []uint32{docID % 7, 100 + docID%13}
+		require.NoError(t, txn.Add(docID, terms), "add failed")
+		for _, term := range terms {
+			expected = append(expected, index.Item{Term: term, DocID: docID})
+		}
+	}
+	require.NoError(t, txn.Commit(), "commit failed")
+
+	items, err = index.ReadAllItems(c.Reader())
+	require.NoError(t, err, "read failed")
+	sort.Sort(index.ItemSliceSortedByTerm(expected))
+	require.Equal(t, expected, items)
+}
+
+func TestClient_Import(t *testing.T) {
+	src, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create source db")
+	defer src.Close()
+	require.NoError(t, src.RunInTransaction(func(txn index.Batch) error {
+		for docID := uint32(1); docID <= 1000; docID++ {
+			err := txn.Add(docID, []uint32{7, 8 + docID%3})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}), "add failed")
+
+	db, srv := newTestServer(t)
+	defer db.Close()
+	defer srv.Close()
+
+	snapshot := src.Snapshot()
+	defer snapshot.Close()
+
+	c := New(srv.URL)
+	txn, err := c.Transaction()
+	require.NoError(t, err, "failed to start transaction")
+	require.NoError(t, txn.Add(2000, []uint32{7}), "add failed")
+	require.NoError(t, txn.Import(snapshot.Reader()), "import failed")
+	require.NoError(t, txn.Add(2001, []uint32{7}), "add failed")
+	require.NoError(t, txn.Commit(), "commit failed")
+	require.Equal(t, 1002, db.NumDocs())
+
+	expected, err := index.ReadAllItems(snapshot.Reader())
+	require.NoError(t, err, "read failed")
+	items, err := index.ReadAllItems(db.Reader())
+	require.NoError(t, err, "read failed")
+	require.Len(t, items, len(expected)+2)
+
+	hits, err := db.Search([]uint32{8})
+	require.NoError(t, err, "search failed")
+	require.Len(t, hits, 333)
+}
+
+func TestClient_Error(t *testing.T) {
+	opts := *index.DefaultOptions
+	opts.ReadOnly = true
+	fs := vfs.CreateMemDir()
+	db, err := index.Open(fs, true, nil)
+	require.NoError(t, err, "failed to create test db")
+	db.Close()
+	db, err = index.Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open test db")
+	defer db.Close()
+
+	srv := httptest.NewServer(server.Handler(db))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	err = c.RunInTransaction(func(txn index.Batch) error { return txn.Add(1, []uint32{1}) })
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "read-only")
+}
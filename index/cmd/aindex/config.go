@@ -0,0 +1,268 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The configuration file is a JSON object which provides values for command line flags. Top-level keys set
+// global flags and flags shared by multiple commands, like "dbpath". Keys in an object named after a command
+// only set flags of that command and take precedence over the top-level keys. For example:
+//
+//	{
+//	  "dbpath": "/var/lib/aindex",
+//	  "max-buffered-items": 1000000,
+//	  "server": {"port": 7765, "read-key": ["secret"]}
+//	}
+//
+// Flags given on the command line or through environment variables override the configuration file.
+// Lists set all values of a repeatable flag and durations are strings like "10s".
+
+var dbPathFlag = cli.StringFlag{Name: "dbpath", EnvVar: "AINDEX_DBPATH", Usage: "path to the database directory"}
+
+var indexFlags = []cli.Flag{
+	cli.BoolFlag{Name: "auto-compact", EnvVar: "AINDEX_AUTO_COMPACT", Usage: "run compactions in the background"},
+	cli.DurationFlag{Name: "auto-compact-interval", EnvVar: "AINDEX_AUTO_COMPACT_INTERVAL", Value: index.DefaultOptions.AutoCompactInterval, Usage: "how often to run background compactions"},
+	cli.IntFlag{Name: "max-buffered-items", EnvVar: "AINDEX_MAX_BUFFERED_ITEMS", Value: index.MaxBufferedItems, Usage: "number of items a transaction keeps in memory before writing a segment"},
+	cli.IntFlag{Name: "merge-floor-segment-size", EnvVar: "AINDEX_MERGE_FLOOR_SEGMENT_SIZE", Value: index.NewTieredMergePolicy().FloorSegmentSize, Usage: "segments smaller than this are treated as equal in size when merging"},
+	cli.IntFlag{Name: "merge-max-segment-size", EnvVar: "AINDEX_MERGE_MAX_SEGMENT_SIZE", Value: index.NewTieredMergePolicy().MaxMergedSegmentSize, Usage: "maximum size of a segment produced by merging"},
+	cli.IntFlag{Name: "merge-max-at-once", EnvVar: "AINDEX_MERGE_MAX_AT_ONCE", Value: index.NewTieredMergePolicy().MaxMergeAtOnce, Usage: "maximum number of segments merged at once"},
+	cli.IntFlag{Name: "merge-segments-per-tier", EnvVar: "AINDEX_MERGE_SEGMENTS_PER_TIER", Value: index.NewTieredMergePolicy().MaxSegmentsPerTier, Usage: "allowed number of segments per tier"},
+	cli.IntFlag{Name: "block-size", EnvVar: "AINDEX_BLOCK_SIZE", Value: index.DefaultBlockSize, Usage: "block size of new segments in bytes"},
+	cli.StringFlag{Name: "term-encoding", EnvVar: "AINDEX_TERM_ENCODING", Value: index.TermEncodingAuto.String(), Usage: "term encoding of new segments (auto, varint or packed)"},
+	cli.IntFlag{Name: "merge-block-size", EnvVar: "AINDEX_MERGE_BLOCK_SIZE", Usage: "block size of merged segments in bytes (defaults to --block-size)"},
+	cli.StringFlag{Name: "merge-term-encoding", EnvVar: "AINDEX_MERGE_TERM_ENCODING", Usage: "term encoding of merged segments (defaults to --term-encoding)"},
+}
+
+// indexOptions returns the database options set by the global flags. The flags are validated
+// by parseIndexOptions before running any command.
+func indexOptions(ctx *cli.Context) index.Options {
+	opts, _ := parseIndexOptions(ctx)
+	return opts
+}
+
+func parseIndexOptions(ctx *cli.Context) (index.Options, error) {
+	opts := *index.DefaultOptions
+	opts.EnableAutoCompact = ctx.GlobalBool("auto-compact")
+	opts.AutoCompactInterval = ctx.GlobalDuration("auto-compact-interval")
+	opts.MaxBufferedItems = ctx.GlobalInt("max-buffered-items")
+	mp := index.NewTieredMergePolicy()
+	mp.FloorSegmentSize = ctx.GlobalInt("merge-floor-segment-size")
+	mp.MaxMergedSegmentSize = ctx.GlobalInt("merge-max-segment-size")
+	mp.MaxMergeAtOnce = ctx.GlobalInt("merge-max-at-once")
+	mp.MaxSegmentsPerTier = ctx.GlobalInt("merge-segments-per-tier")
+	opts.MergePolicy = mp
+
+	var err error
+	opts.SegmentOptions.BlockSize = ctx.GlobalInt("block-size")
+	opts.SegmentOptions.TermEncoding, err = index.ParseTermEncoding(ctx.GlobalString("term-encoding"))
+	if err != nil {
+		return opts, err
+	}
+	err = opts.SegmentOptions.Validate()
+	if err != nil {
+		return opts, err
+	}
+
+	if ctx.GlobalInt("merge-block-size") != 0 || ctx.GlobalString("merge-term-encoding") != "" {
+		mergedOpts := opts.SegmentOptions
+		if ctx.GlobalInt("merge-block-size") != 0 {
+			mergedOpts.BlockSize = ctx.GlobalInt("merge-block-size")
+		}
+		if ctx.GlobalString("merge-term-encoding") != "" {
+			mergedOpts.TermEncoding, err = index.ParseTermEncoding(ctx.GlobalString("merge-term-encoding"))
+			if err != nil {
+				return opts, err
+			}
+		}
+		err = mergedOpts.Validate()
+		if err != nil {
+			return opts, errors.Wrap(err, "invalid merge options")
+		}
+		mp.SegmentOptions = &mergedOpts
+	}
+	return opts, nil
+}
+
+// config holds the raw values from the configuration file.
+type config map[string]json.RawMessage
+
+var loadedConfig config
+
+func loadConfig(name string) (config, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(data)
+}
+
+// parseConfig parses a JSON object with settings. Underscores in keys are accepted in place of dashes.
+func parseConfig(data []byte) (config, error) {
+	var cfg config
+	err := json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(config, len(cfg))
+	for key, value := range cfg {
+		normalized[strings.Replace(key, "_", "-", -1)] = value
+	}
+	return normalized, nil
+}
+
+// section returns the settings for a command, or nil if there are none.
+func (cfg config) section(name string) (config, error) {
+	raw, exists := cfg[name]
+	if !exists {
+		return nil, nil
+	}
+	section, err := parseConfig(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid section %q", name)
+	}
+	return section, nil
+}
+
+// applyGlobal verifies that all top-level keys are known and applies them to the global flags.
+func (cfg config) applyGlobal(app *cli.App, ctx *cli.Context) error {
+	commands := make(map[string]bool)
+	commandFlags := make(map[string]bool)
+	for _, command := range app.Commands {
+		commands[command.Name] = true
+		for _, flag := range command.Flags {
+			commandFlags[flagName(flag)] = true
+		}
+	}
+	for key := range cfg {
+		if commands[key] {
+			_, err := cfg.section(key)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if findFlag(app.Flags, key) == nil && !commandFlags[key] {
+			return errors.Errorf("unknown setting %q", key)
+		}
+	}
+	return cfg.apply(ctx, app.Flags, ctx.Set, make(map[string]bool))
+}
+
+// applyCommand applies the command's section and the matching top-level keys to the command flags.
+func (cfg config) applyCommand(ctx *cli.Context, command cli.Command) error {
+	section, err := cfg.section(command.Name)
+	if err != nil {
+		return err
+	}
+	for key := range section {
+		if findFlag(command.Flags, key) == nil {
+			return errors.Errorf("unknown setting %q in section %q", key, command.Name)
+		}
+	}
+	applied := make(map[string]bool)
+	err = section.apply(ctx, command.Flags, ctx.Set, applied)
+	if err != nil {
+		return err
+	}
+	return cfg.apply(ctx, command.Flags, ctx.Set, applied)
+}
+
+// apply sets flags that were not given on the command line or through environment variables.
+func (cfg config) apply(ctx *cli.Context, flags []cli.Flag, set func(name, value string) error, applied map[string]bool) error {
+	for key, raw := range cfg {
+		flag := findFlag(flags, key)
+		if flag == nil || applied[key] || ctx.IsSet(key) || isSetInEnv(flag) {
+			continue
+		}
+		values, err := configValues(raw)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for %q", key)
+		}
+		for _, value := range values {
+			err = set(key, value)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value for %q", key)
+			}
+		}
+		applied[key] = true
+	}
+	return nil
+}
+
+// configValues converts a JSON value to strings accepted by flags. Lists are converted to multiple values.
+func configValues(raw json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := value.([]interface{}); ok {
+		values := make([]string, len(list))
+		for i, item := range list {
+			values[i], err = configValue(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	s, err := configValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+func configValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", errors.New("expected a string, number or boolean")
+}
+
+func flagName(flag cli.Flag) string {
+	return strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		if flagName(flag) == name {
+			return flag
+		}
+	}
+	return nil
+}
+
+// isSetInEnv checks the flag's environment variables. Context.IsSet does not see them for command flags
+// until the command's action is running.
+func isSetInEnv(flag cli.Flag) bool {
+	v := reflect.Indirect(reflect.ValueOf(flag)).FieldByName("EnvVar")
+	if !v.IsValid() || v.Kind() != reflect.String {
+		return false
+	}
+	for _, name := range strings.Split(v.String(), ",") {
+		if os.Getenv(strings.TrimSpace(name)) != "" {
+			return true
+		}
+	}
+	return false
+}
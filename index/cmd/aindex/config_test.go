@@ -0,0 +1,129 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/urfave/cli.v1"
+	"io/ioutil"
+	"testing"
+)
+
+type configTestResult struct {
+	blockSize int
+	port      int
+	readKeys  []string
+}
+
+// runConfigTestApp runs a small app wired to the configuration file the same way as main.
+func runConfigTestApp(cfg config, args ...string) (configTestResult, error) {
+	// The app would exit the test on errors returned from Before.
+	exiter, errWriter := cli.OsExiter, cli.ErrWriter
+	cli.OsExiter, cli.ErrWriter = func(int) {}, ioutil.Discard
+	defer func() { cli.OsExiter, cli.ErrWriter = exiter, errWriter }()
+
+	var result configTestResult
+	app := cli.NewApp()
+	app.Writer = ioutil.Discard
+	app.Flags = []cli.Flag{
+		cli.IntFlag{Name: "block-size", EnvVar: "AINDEX_TEST_BLOCK_SIZE", Value: 512},
+	}
+	app.Commands = []cli.Command{
+		{
+			Name: "test",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "port", EnvVar: "AINDEX_TEST_PORT", Value: 7765},
+				cli.StringSliceFlag{Name: "read-key", EnvVar: "AINDEX_TEST_READ_KEYS"},
+			},
+			Action: func(ctx *cli.Context) error {
+				result.blockSize = ctx.GlobalInt("block-size")
+				result.port = ctx.Int("port")
+				result.readKeys = ctx.StringSlice("read-key")
+				return nil
+			},
+		},
+	}
+	app.Commands[0].Before = func(ctx *cli.Context) error {
+		return cfg.applyCommand(ctx, app.Commands[0])
+	}
+	app.Before = func(ctx *cli.Context) error {
+		return cfg.applyGlobal(app, ctx)
+	}
+	err := app.Run(append([]string{"aindex"}, args...))
+	return result, err
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		args     []string
+		expected configTestResult
+	}{
+		{
+			name:     "defaults",
+			config:   `{}`,
+			args:     []string{"test"},
+			expected: configTestResult{blockSize: 512, port: 7765, readKeys: []string{}},
+		},
+		{
+			name:     "config file",
+			config:   `{"block_size": 1024, "port": 7000, "test": {"read-key": ["a", "b"]}}`,
+			args:     []string{"test"},
+			expected: configTestResult{blockSize: 1024, port: 7000, readKeys: []string{"a", "b"}},
+		},
+		{
+			name:     "command section overrides top-level keys",
+			config:   `{"port": 7000, "test": {"port": 8000}}`,
+			args:     []string{"test"},
+			expected: configTestResult{blockSize: 512, port: 8000, readKeys: []string{}},
+		},
+		{
+			name:     "env overrides config file",
+			config:   `{"block-size": 1024, "test": {"port": 8000, "read-key": ["a"]}}`,
+			env:      map[string]string{"AINDEX_TEST_BLOCK_SIZE": "2048", "AINDEX_TEST_PORT": "9000", "AINDEX_TEST_READ_KEYS": "c"},
+			args:     []string{"test"},
+			expected: configTestResult{blockSize: 2048, port: 9000, readKeys: []string{"c"}},
+		},
+		{
+			name:     "flags override config file",
+			config:   `{"block-size": 1024, "test": {"port": 8000, "read-key": ["a"]}}`,
+			args:     []string{"--block-size", "4096", "test", "--port", "9001", "--read-key", "d"},
+			expected: configTestResult{blockSize: 4096, port: 9001, readKeys: []string{"d"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			cfg, err := parseConfig([]byte(test.config))
+			require.NoError(t, err)
+			result, err := runConfigTestApp(cfg, test.args...)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"unknown key", `{"foo": 1}`},
+		{"unknown key in section", `{"test": {"block-size": 1024}}`},
+		{"invalid section", `{"test": 1}`},
+		{"invalid value", `{"block-size": "big"}`},
+		{"nested value", `{"port": {"value": 1}}`},
+	}
+	for _, test := range tests {
+		cfg, err := parseConfig([]byte(test.config))
+		require.NoError(t, err, test.name)
+		_, err = runConfigTestApp(cfg, "test")
+		assert.Error(t, err, test.name)
+	}
+}
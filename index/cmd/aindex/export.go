@@ -5,32 +5,38 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/dump"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 	"io"
-	"os"
 )
 
 var exportCommand = cli.Command{
 	Name:  "export",
-	Usage: "Export all term/docID pairs from the index",
+	Usage: "Export all term/docID pairs or docs from the index",
 	Flags: []cli.Flag{
-		cli.StringFlag{Name: "dbpath", Usage: "path to the database directory"},
+		dbPathFlag,
+		cli.StringFlag{Name: "format", Value: "text", Usage: "output format (text, json or binary)"},
+		cli.StringFlag{Name: "output", Value: "-", Usage: "output file, \"-\" for stdout"},
+		cli.StringFlag{Name: "compress", Value: "none", Usage: "output compression (none or gzip)"},
+		cli.StringFlag{Name: "tmpdir", Usage: "directory for temporary files when grouping items by docID"},
+		cli.IntFlag{Name: "run-size", Value: index.DefaultRunSize, Usage: "number of items to sort in memory when grouping items by docID"},
 	},
 	Action: runExport,
 }
 
 func runExport(ctx *cli.Context) error {
-	fs, err := vfs.OpenDir(ctx.String("dbpath"), true)
+	fs, err := vfs.OpenDir(ctx.String("dbpath"), false)
 	if err != nil {
 		return errors.Wrap(err, "unable to open the database directory")
 	}
 
-	opts := *index.DefaultOptions
-	opts.EnableAutoCompact = false
+	opts := indexOptions(ctx)
+	opts.ReadOnly = true
 
 	idx, err := index.Open(fs, false, &opts)
 	if err != nil {
@@ -38,11 +44,51 @@ func runExport(ctx *cli.Context) error {
 	}
 	defer idx.Close()
 
+	format := ctx.String("format")
+	switch format {
+	case "text", "json", "binary":
+	default:
+		return errors.Errorf("unknown format %v", format)
+	}
+
+	output, err := createOutput(ctx.String("output"), ctx.String("compress"))
+	if err != nil {
+		return errors.Wrap(err, "unable to create the output")
+	}
+	defer output.Close()
+
 	snapshot := idx.Snapshot()
 	defer snapshot.Close()
 
-	reader := snapshot.Reader()
-	writer := bufio.NewWriter(os.Stdout)
+	writer := bufio.NewWriter(output)
+
+	switch format {
+	case "text":
+		err = exportText(snapshot.Reader(), writer)
+	case "json":
+		err = exportJSON(ctx, snapshot.Reader(), writer, opts.Logger)
+	case "binary":
+		err = dump.Write(writer, snapshot.Reader())
+	}
+	if err != nil {
+		return err
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return errors.Wrap(err, "flush failed")
+	}
+
+	err = output.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close the output")
+	}
+
+	return nil
+}
+
+// exportText writes "term docID" lines in term order, suitable for "aindex import".
+func exportText(reader index.ItemReader, writer io.Writer) error {
 	for {
 		items, err := reader.ReadBlock()
 		for _, item := range items {
@@ -57,12 +103,43 @@ func runExport(ctx *cli.Context) error {
 			}
 			return errors.Wrap(err, "read failed")
 		}
+	}
+	return nil
+}
 
+// exportJSON writes one JSON-encoded document per line, suitable for "aindex load -f json".
+func exportJSON(ctx *cli.Context, reader index.ItemReader, writer io.Writer, logger index.Logger) error {
+	var tmp vfs.FileSystem
+	var err error
+	if ctx.String("tmpdir") != "" {
+		tmp, err = vfs.OpenDir(ctx.String("tmpdir"), true)
+	} else {
+		tmp, err = vfs.CreateTempDir()
 	}
-	err = writer.Flush()
 	if err != nil {
-		return errors.Wrap(err, "flush failed")
+		return errors.Wrap(err, "unable to open the temporary directory")
 	}
+	defer tmp.Close()
 
+	docs, err := index.NewDocReaderWithOptions(reader, tmp, &index.DocReaderOptions{RunSize: ctx.Int("run-size"), Logger: logger})
+	if err != nil {
+		return errors.Wrap(err, "sort failed")
+	}
+	defer docs.Close()
+
+	encoder := json.NewEncoder(writer)
+	for {
+		doc, err := docs.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrap(err, "read failed")
+		}
+		err = encoder.Encode(doc)
+		if err != nil {
+			return errors.Wrap(err, "write failed")
+		}
+	}
 	return nil
 }
@@ -6,12 +6,14 @@ package main
 import (
 	"bufio"
 	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/dump"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 	"io"
-	"os"
+	"log"
 	"strconv"
+	"time"
 )
 
 type itemBlockWithErr struct {
@@ -67,22 +69,74 @@ func readTextStream(input io.Reader) <-chan itemBlockWithErr {
 	return ch
 }
 
+// progressReader counts items read from the underlying reader and periodically logs the import rate.
+type progressReader struct {
+	reader    index.ItemReader
+	interval  time.Duration
+	started   time.Time
+	lastLog   time.Time
+	numItems  int
+	lastItems int
+}
+
+func newProgressReader(reader index.ItemReader, interval time.Duration) *progressReader {
+	now := time.Now()
+	return &progressReader{reader: reader, interval: interval, started: now, lastLog: now}
+}
+
+func (r *progressReader) ReadBlock() ([]index.Item, error) {
+	items, err := r.reader.ReadBlock()
+	r.numItems += len(items)
+	if r.interval > 0 {
+		now := time.Now()
+		if elapsed := now.Sub(r.lastLog); elapsed >= r.interval {
+			rate := float64(r.numItems-r.lastItems) / elapsed.Seconds()
+			log.Printf("read %v items (%.0f items/s)", r.numItems, rate)
+			r.lastLog, r.lastItems = now, r.numItems
+		}
+	}
+	return items, err
+}
+
+func (r *progressReader) logSummary() {
+	elapsed := time.Since(r.started)
+	log.Printf("imported %v items in %s (%.0f items/s)", r.numItems, elapsed, float64(r.numItems)/elapsed.Seconds())
+}
+
 var importCommand = cli.Command{
 	Name:  "import",
-	Usage: "Import a stream of term/docID pairs into the index",
+	Usage: "Import a stream of term/docID pairs in any order into the index",
 	Flags: []cli.Flag{
-		cli.StringFlag{Name: "dbpath", Usage: "path to the database directory"},
+		dbPathFlag,
+		cli.StringFlag{Name: "format", Value: "text", Usage: "input format (text or binary), gzip-compressed input is detected automatically"},
+		cli.StringFlag{Name: "input", Value: "-", Usage: "input file, \"-\" for stdin"},
+		cli.IntFlag{Name: "chunk-size", Value: index.DefaultImportOptions.ChunkSize, Usage: "number of items sorted in memory at once, 8 bytes per item"},
+		cli.IntFlag{Name: "concurrency", Value: index.DefaultImportOptions.Concurrency, Usage: "number of chunks sorted in parallel, each one is kept in memory"},
+		cli.DurationFlag{Name: "progress-interval", Value: 10 * time.Second, Usage: "how often to report progress, zero disables it"},
 	},
 	Action: runImport,
 }
 
 func runImport(ctx *cli.Context) error {
+	format := ctx.String("format")
+	switch format {
+	case "text", "binary":
+	default:
+		return errors.Errorf("unknown format %v", format)
+	}
+
+	input, err := openInput(ctx.String("input"))
+	if err != nil {
+		return errors.Wrap(err, "unable to open the input")
+	}
+	defer input.Close()
+
 	fs, err := vfs.OpenDir(ctx.String("dbpath"), true)
 	if err != nil {
 		return errors.Wrap(err, "unable to open the database directory")
 	}
 
-	opts := *index.DefaultOptions
+	opts := indexOptions(ctx)
 	opts.EnableAutoCompact = false
 
 	idx, err := index.Open(fs, true, &opts)
@@ -91,6 +145,25 @@ func runImport(ctx *cli.Context) error {
 	}
 	defer idx.Close()
 
-	reader := channelReader{ch: readTextStream(os.Stdin)}
-	return idx.Import(&reader)
+	var reader index.ItemReader
+	if format == "binary" {
+		reader, err = dump.NewReader(input)
+		if err != nil {
+			return errors.Wrap(err, "invalid binary input")
+		}
+	} else {
+		reader = &channelReader{ch: readTextStream(input)}
+	}
+
+	progress := newProgressReader(reader, ctx.Duration("progress-interval"))
+	importOpts := &index.ImportOptions{
+		ChunkSize:   ctx.Int("chunk-size"),
+		Concurrency: ctx.Int("concurrency"),
+	}
+	err = idx.ImportUnsorted(progress, importOpts)
+	if err != nil {
+		return err
+	}
+	progress.logSummary()
+	return nil
 }
@@ -0,0 +1,75 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+	"text/tabwriter"
+)
+
+var infoCommand = cli.Command{
+	Name:  "info",
+	Usage: "Show information about the index and its segments",
+	Flags: []cli.Flag{
+		dbPathFlag,
+		cli.BoolFlag{Name: "json", Usage: "output the information as JSON"},
+	},
+	Action: runInfo,
+}
+
+func runInfo(ctx *cli.Context) error {
+	path := ctx.String("dbpath")
+	if path == "" {
+		return errors.New("no database directory specified")
+	}
+
+	fs, err := vfs.OpenDir(path, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database directory")
+	}
+
+	opts := indexOptions(ctx)
+	opts.ReadOnly = true
+	opts.Logger = index.NopLogger
+
+	idx, err := index.Open(fs, false, &opts)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database")
+	}
+	defer idx.Close()
+
+	stats := idx.Stats()
+
+	if ctx.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	fmt.Printf("Transaction:  %v\n", stats.TxID)
+	fmt.Printf("Docs:         %v\n", stats.NumDocs-stats.NumDeletedDocs)
+	fmt.Printf("Deleted docs: %v\n", stats.NumDeletedDocs)
+	fmt.Printf("Items:        %v\n", stats.NumItems)
+	fmt.Printf("Segments:     %v\n", stats.NumSegments)
+	fmt.Printf("Size:         %v\n", stats.Size)
+
+	if len(stats.Segments) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "ID\tUpdate\tDocs\tDeleted\tItems\tBlocks\tMin term\tMax term\tMin docID\tMax docID\tSize\t")
+	for _, s := range stats.Segments {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", s.ID, s.UpdateID, s.Meta.NumDocs, s.Meta.NumDeletedDocs,
+			s.Meta.NumItems, s.Meta.NumBlocks, s.Meta.MinTerm, s.Meta.MaxTerm, s.Meta.MinDocID, s.Meta.MaxDocID, s.Size)
+	}
+	return w.Flush()
+}
@@ -3,16 +3,24 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"github.com/acoustid/go-acoustid/chromaprint"
 	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/pgcopy"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 	"io"
+	"log"
 	"os"
 	"strconv"
 	"strings"
 )
 
+// hashToTerm converts a 32-bit fingerprint hash to a 28-bit term stored in the index.
+func hashToTerm(hash uint32) uint32 {
+	return hash >> (32 - 28)
+}
+
 func loadCSV(input io.Reader, batch index.Batch) error {
 	stream := bufio.NewReader(input)
 	var lastDocID uint32
@@ -36,7 +44,7 @@ func loadCSV(input io.Reader, batch index.Batch) error {
 			if err != nil {
 				return errors.Wrapf(err, "invalid input")
 			}
-			terms[i] = uint32(term) >> (32 - 28)
+			terms[i] = hashToTerm(uint32(term))
 		}
 		lastDocID = uint32(docID)
 		err = batch.Add(lastDocID, terms)
@@ -65,12 +73,121 @@ func loadJSON(input io.Reader, batch index.Batch) error {
 	}
 }
 
+type pgCopyOptions struct {
+	header            bool
+	columns           []string
+	idColumn          string
+	fingerprintColumn string
+	fingerprintFormat string
+}
+
+// loadPgCopy loads docs from the PostgreSQL COPY text format, e.g. a dump of the fingerprint table.
+// The fingerprint column is either an int array or a compressed base64-encoded fingerprint.
+func loadPgCopy(input io.Reader, batch index.Batch, opts *pgCopyOptions) error {
+	var parseHashes func(value string, hashes []uint32) ([]uint32, error)
+	switch opts.fingerprintFormat {
+	case "array":
+		var values []int32
+		parseHashes = func(value string, hashes []uint32) ([]uint32, error) {
+			var err error
+			values, err = pgcopy.ParseInt32Array(value, values[:0])
+			if err != nil {
+				return nil, err
+			}
+			for _, x := range values {
+				hashes = append(hashes, uint32(x))
+			}
+			return hashes, nil
+		}
+	case "base64":
+		var decoder chromaprint.Decoder
+		parseHashes = func(value string, hashes []uint32) ([]uint32, error) {
+			fp, err := decoder.DecodeString(value)
+			if err != nil {
+				return nil, err
+			}
+			return append(hashes, fp.Hashes...), nil
+		}
+	default:
+		return errors.Errorf("unknown fingerprint format %v", opts.fingerprintFormat)
+	}
+
+	reader := pgcopy.NewReader(input)
+
+	columns := opts.columns
+	if opts.header {
+		var err error
+		columns, err = reader.ReadHeader()
+		if err != nil {
+			return errors.Wrap(err, "invalid input")
+		}
+	}
+	idIndex, fingerprintIndex := -1, -1
+	for i, name := range columns {
+		switch name {
+		case opts.idColumn:
+			idIndex = i
+		case opts.fingerprintColumn:
+			fingerprintIndex = i
+		}
+	}
+	if idIndex == -1 {
+		return errors.Errorf("column %q not found", opts.idColumn)
+	}
+	if fingerprintIndex == -1 {
+		return errors.Errorf("column %q not found", opts.fingerprintColumn)
+	}
+
+	var hashes []uint32
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrap(err, "invalid input")
+		}
+		if len(row) != len(columns) {
+			return errors.Errorf("invalid input, expected %d columns on line %d, got %d", len(columns), reader.Line(), len(row))
+		}
+		id, fingerprint := row[idIndex], row[fingerprintIndex]
+		if !id.Valid {
+			return errors.Errorf("invalid input, NULL id on line %d", reader.Line())
+		}
+		docID, err := strconv.ParseUint(id.String, 10, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid input, bad id on line %d", reader.Line())
+		}
+		if !fingerprint.Valid {
+			log.Printf("skipping doc %v with NULL fingerprint", docID)
+			continue
+		}
+		hashes, err = parseHashes(fingerprint.String, hashes[:0])
+		if err != nil {
+			return errors.Wrapf(err, "invalid input, bad fingerprint on line %d", reader.Line())
+		}
+		terms := make([]uint32, len(hashes))
+		for i, hash := range hashes {
+			terms[i] = hashToTerm(hash)
+		}
+		err = batch.Add(uint32(docID), terms)
+		if err != nil {
+			return errors.Wrap(err, "add failed")
+		}
+	}
+}
+
 var loadCommand = cli.Command{
 	Name:  "load",
 	Usage: "Load docs into the index",
 	Flags: []cli.Flag{
-		cli.StringFlag{Name: "dbpath", Usage: "path to the database directory"},
-		cli.StringFlag{Name: "fmt, f", Usage: "input format"},
+		dbPathFlag,
+		cli.StringFlag{Name: "fmt, f", Usage: "input format (csv, json or pgcopy)"},
+		cli.BoolFlag{Name: "header", Usage: "pgcopy: the first line contains column names"},
+		cli.StringFlag{Name: "columns", Value: "id,fingerprint", Usage: "pgcopy: comma-separated column names, if there is no header"},
+		cli.StringFlag{Name: "id-column", Value: "id", Usage: "pgcopy: name of the column with doc IDs"},
+		cli.StringFlag{Name: "fingerprint-column", Value: "fingerprint", Usage: "pgcopy: name of the column with fingerprints"},
+		cli.StringFlag{Name: "fingerprint-format", Value: "array", Usage: "pgcopy: fingerprint format (array or base64)"},
 	},
 	Action: runLoad,
 }
@@ -86,7 +203,7 @@ func runLoad(ctx *cli.Context) error {
 		return errors.Wrap(err, "unable to open the database directory")
 	}
 
-	opts := *index.DefaultOptions
+	opts := indexOptions(ctx)
 	opts.EnableAutoCompact = false
 
 	idx, err := index.Open(fs, true, &opts)
@@ -103,6 +220,15 @@ func runLoad(ctx *cli.Context) error {
 		loader = loadCSV
 	case "json":
 		loader = loadJSON
+	case "pgcopy":
+		opts := &pgCopyOptions{
+			header:            ctx.Bool("header"),
+			columns:           strings.Split(ctx.String("columns"), ","),
+			idColumn:          ctx.String("id-column"),
+			fingerprintColumn: ctx.String("fingerprint-column"),
+			fingerprintFormat: ctx.String("fingerprint-format"),
+		}
+		loader = func(input io.Reader, batch index.Batch) error { return loadPgCopy(input, batch, opts) }
 	case "":
 		return errors.New("input format not specified")
 	default:
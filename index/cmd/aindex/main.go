@@ -4,6 +4,7 @@
 package main
 
 import (
+	"github.com/acoustid/go-acoustid/index"
 	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 	"log"
@@ -23,13 +24,28 @@ func main() {
 
 	app.Flags = []cli.Flag{
 		cli.StringFlag{Name: "cpuprofile", Usage: "write cpu profile to file", Hidden: true},
+		cli.StringFlag{Name: "config", EnvVar: "AINDEX_CONFIG", Usage: "path to a JSON configuration file"},
+		cli.StringFlag{Name: "log-level", EnvVar: "AINDEX_LOG_LEVEL", Value: "info", Usage: "minimum level of index log messages (debug, info, warn or error)"},
 	}
+	app.Flags = append(app.Flags, indexFlags...)
 
 	app.Commands = []cli.Command{
 		serverCommand,
 		importCommand,
 		exportCommand,
+		infoCommand,
 		loadCommand,
+		restoreCommand,
+		splitCommand,
+	}
+	for i := range app.Commands {
+		command := app.Commands[i]
+		app.Commands[i].Before = func(ctx *cli.Context) error {
+			if loadedConfig == nil {
+				return nil
+			}
+			return loadedConfig.applyCommand(ctx, command)
+		}
 	}
 
 	app.Before = func(ctx *cli.Context) error {
@@ -41,7 +57,23 @@ func main() {
 			pprof.StartCPUProfile(file)
 		}
 		log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-		return nil
+		if ctx.GlobalString("config") != "" {
+			cfg, err := loadConfig(ctx.GlobalString("config"))
+			if err == nil {
+				err = cfg.applyGlobal(app, ctx)
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to load the configuration file")
+			}
+			loadedConfig = cfg
+		}
+		level, err := index.ParseLogLevel(ctx.GlobalString("log-level"))
+		if err != nil {
+			return err
+		}
+		index.DefaultLogger = &index.StdLogger{MinLevel: level}
+		_, err = parseIndexOptions(ctx)
+		return err
 	}
 
 	app.After = func(ctx *cli.Context) error {
@@ -0,0 +1,72 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+	"log"
+)
+
+var restoreCommand = cli.Command{
+	Name:  "restore",
+	Usage: "Restore the index to the state after a previous transaction",
+	Flags: []cli.Flag{
+		dbPathFlag,
+		cli.UintFlag{Name: "txid", Usage: "ID of the transaction to restore"},
+		cli.IntFlag{Name: "retain-manifests", Usage: "number of old manifests to keep for future restores"},
+		cli.BoolFlag{Name: "list", Usage: "only list transactions that can be restored"},
+	},
+	Action: runRestore,
+}
+
+func runRestore(ctx *cli.Context) error {
+	path := ctx.String("dbpath")
+	if path == "" {
+		return errors.New("no database directory specified")
+	}
+
+	fs, err := vfs.OpenDir(path, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database directory")
+	}
+
+	if ctx.Bool("list") {
+		ids, err := index.ListVersions(fs)
+		if err != nil {
+			return errors.Wrap(err, "unable to list retained manifests")
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		return nil
+	}
+
+	txid := uint32(ctx.Uint("txid"))
+	if txid == 0 {
+		return errors.New("no transaction ID specified")
+	}
+
+	opts := indexOptions(ctx)
+	opts.EnableAutoCompact = false
+	opts.NumRetainedManifests = ctx.Int("retain-manifests")
+
+	idx, err := index.OpenAt(fs, txid, &opts)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database")
+	}
+	defer idx.Close()
+
+	// Committing an empty transaction makes the restored state current.
+	err = idx.RunInTransaction(func(txn index.Batch) error { return nil })
+	if err != nil {
+		return errors.Wrap(err, "commit failed")
+	}
+
+	log.Printf("restored the database to transaction %v", txid)
+	return nil
+}
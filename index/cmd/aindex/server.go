@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"github.com/acoustid/go-acoustid/index"
 	"github.com/acoustid/go-acoustid/index/server"
 	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
 	"gopkg.in/urfave/cli.v1"
 	"log"
 	"net"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 var serverCommand = cli.Command{
@@ -16,35 +24,195 @@ var serverCommand = cli.Command{
 	Flags: []cli.Flag{
 		cli.StringFlag{Name: "host", Value: "localhost", Usage: "address on which to listen"},
 		cli.IntFlag{Name: "port", Value: 7765, Usage: "port number on which to listen"},
-		cli.StringFlag{Name: "dbpath", Usage: "path to the database directory"},
+		cli.IntFlag{Name: "binary-port", Usage: "port number on which to listen for the binary protocol (disabled if 0)"},
+		cli.IntFlag{Name: "legacy-port", Usage: "port number on which to listen for the legacy acoustid-index protocol (disabled if 0)"},
+		dbPathFlag,
+		cli.BoolFlag{Name: "read-only", Usage: "open the database in read-only mode"},
+		cli.DurationFlag{Name: "refresh-interval", Usage: "how often to check for changes in read-only mode"},
+		cli.IntFlag{Name: "retain-manifests", Usage: "number of old manifests to keep for point-in-time restores"},
+		cli.StringFlag{Name: "follow", Usage: "URL of a leader server to replicate the database from"},
+		cli.DurationFlag{Name: "follow-interval", Value: time.Second, Usage: "how often to check the leader server for changes"},
+		cli.StringFlag{Name: "admin-token", EnvVar: "AINDEX_ADMIN_TOKEN", Usage: "token required by the admin endpoints (disabled if empty)"},
+		cli.StringFlag{Name: "checkpoint-dir", Usage: "directory in which to create checkpoints requested through the admin endpoints"},
+		cli.StringSliceFlag{Name: "read-key", EnvVar: "AINDEX_READ_KEYS", Usage: "API key allowed to search and read the index (can be repeated)"},
+		cli.StringSliceFlag{Name: "write-key", EnvVar: "AINDEX_WRITE_KEYS", Usage: "API key allowed to read and change the index (can be repeated)"},
+		cli.StringFlag{Name: "api-keys-file", Usage: "file with API keys, one \"read <key>\" or \"write <key>\" per line"},
+		cli.Float64Flag{Name: "rate-limit", Usage: "maximum number of HTTP requests per second for each API key or client address (disabled if 0)"},
+		cli.IntFlag{Name: "rate-burst", Usage: "number of HTTP requests a client can make at once before being limited (defaults to the rate limit)"},
+		cli.StringFlag{Name: "follow-api-key", EnvVar: "AINDEX_FOLLOW_API_KEY", Usage: "API key for the leader server"},
+		cli.DurationFlag{Name: "shutdown-timeout", Value: 30 * time.Second, Usage: "how long to wait for open transactions and merges when shutting down"},
 	},
 	Action: runServer,
 }
 
 func runServer(ctx *cli.Context) error {
+	serverOpts := &server.Options{
+		AdminToken:    ctx.String("admin-token"),
+		CheckpointDir: ctx.String("checkpoint-dir"),
+		ReadKeys:      ctx.StringSlice("read-key"),
+		WriteKeys:     ctx.StringSlice("write-key"),
+		RateLimit:     ctx.Float64("rate-limit"),
+		RateBurst:     ctx.Int("rate-burst"),
+	}
+	if ctx.String("api-keys-file") != "" {
+		err := readAPIKeysFile(ctx.String("api-keys-file"), serverOpts)
+		if err != nil {
+			log.Fatalf("Failed to read the API keys: %v", err)
+		}
+	}
+	if len(serverOpts.ReadKeys)+len(serverOpts.WriteKeys) > 0 {
+		log.Printf("API key authentication enabled (read keys: %v, write keys: %v)", len(serverOpts.ReadKeys), len(serverOpts.WriteKeys))
+	}
+	err := checkProtocolListeners(serverOpts, ctx.Int("binary-port"), ctx.Int("legacy-port"))
+	if err != nil {
+		return err
+	}
+
 	var fs vfs.FileSystem
 	path := ctx.String("dbpath")
 	if path == "" {
 		fs = vfs.CreateMemDir()
 	} else {
-		var err error
 		fs, err = vfs.OpenDir(path, true)
 		if err != nil {
 			log.Fatalf("Failed to open the database directory: %v", err)
 		}
 	}
 
-	opts := *index.DefaultOptions
+	opts := indexOptions(ctx)
+	opts.NumRetainedManifests = ctx.Int("retain-manifests")
+	opts.ReadOnly = ctx.Bool("read-only")
+	opts.RefreshInterval = ctx.Duration("refresh-interval")
+
+	var follower *server.Follower
+	if ctx.String("follow") != "" {
+		follower = server.NewFollower(ctx.String("follow"), fs)
+		follower.APIKey = ctx.String("follow-api-key")
+		log.Printf("replicating database from %v", follower.URL)
+		_, err := follower.Sync()
+		if err != nil {
+			log.Fatalf("Failed to replicate the database: %v", err)
+		}
+		opts.ReadOnly = true
+	}
 
 	log.Printf("opening database in %v", fs)
-	idx, err := index.Open(fs, true, &opts)
+	idx, err := index.Open(fs, !opts.ReadOnly, &opts)
 	if err != nil {
 		log.Fatalf("Failed to open the database: %v", err)
 	}
 	defer idx.Close()
 
+	var stopFollower chan struct{}
+	if follower != nil {
+		stopFollower = make(chan struct{})
+		go follower.Run(idx, ctx.Duration("follow-interval"), stopFollower)
+	}
+
+	srv := server.NewServer(idx, serverOpts)
+
+	errs := make(chan error, 3)
+
+	if ctx.Int("binary-port") != 0 {
+		binaryAddr := net.JoinHostPort(ctx.String("host"), strconv.Itoa(ctx.Int("binary-port")))
+		log.Printf("listening for the binary protocol on %v", binaryAddr)
+		go func() {
+			err := srv.ListenAndServeBinary(binaryAddr)
+			if err != nil {
+				errs <- errors.Wrap(err, "binary protocol listener failed")
+			}
+		}()
+	}
+
+	if ctx.Int("legacy-port") != 0 {
+		legacyAddr := net.JoinHostPort(ctx.String("host"), strconv.Itoa(ctx.Int("legacy-port")))
+		log.Printf("listening for the legacy protocol on %v", legacyAddr)
+		go func() {
+			err := srv.ListenAndServeLegacy(legacyAddr)
+			if err != nil {
+				errs <- errors.Wrap(err, "legacy protocol listener failed")
+			}
+		}()
+	}
+
 	addr := net.JoinHostPort(ctx.String("host"), strconv.Itoa(ctx.Int("port")))
 	log.Printf("listening on %v", addr)
+	go func() {
+		err := srv.ListenAndServe(addr)
+		if err != nil {
+			errs <- errors.Wrap(err, "HTTP listener failed")
+		}
+	}()
 
-	return server.ListenAndServe(addr, idx)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var result error
+	select {
+	case sig := <-signals:
+		log.Printf("received %v, shutting down", sig)
+	case result = <-errs:
+		log.Printf("shutting down: %v", result)
+	}
+
+	if stopFollower != nil {
+		close(stopFollower)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ctx.Duration("shutdown-timeout"))
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Printf("graceful shutdown failed: %v", err)
+	} else {
+		log.Printf("shutdown complete")
+	}
+	return result
+}
+
+// checkProtocolListeners returns an error if the binary or legacy protocol is enabled together with
+// API keys or rate limiting. Neither protocol supports them, so clients could bypass the HTTP access control.
+func checkProtocolListeners(opts *server.Options, binaryPort, legacyPort int) error {
+	if binaryPort == 0 && legacyPort == 0 {
+		return nil
+	}
+	if len(opts.ReadKeys)+len(opts.WriteKeys) > 0 {
+		return errors.New("API keys can't be used with the binary or legacy protocol, which have no authentication")
+	}
+	if opts.RateLimit > 0 {
+		return errors.New("rate limiting can't be used with the binary or legacy protocol")
+	}
+	return nil
+}
+
+// readAPIKeysFile adds API keys from a file to the server options. Each line contains the scope
+// ("read" or "write") and the key, separated by whitespace. Empty lines and lines starting with # are ignored.
+func readAPIKeysFile(name string, opts *server.Options) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return errors.Errorf("line %d: expected scope and key", lineNo)
+		}
+		switch fields[0] {
+		case "read":
+			opts.ReadKeys = append(opts.ReadKeys, fields[1])
+		case "write":
+			opts.WriteKeys = append(opts.WriteKeys, fields[1])
+		default:
+			return errors.Errorf("line %d: unknown scope %q", lineNo, fields[0])
+		}
+	}
+	return scanner.Err()
 }
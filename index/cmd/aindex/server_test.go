@@ -0,0 +1,73 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"github.com/acoustid/go-acoustid/index/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckProtocolListeners(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       server.Options
+		binaryPort int
+		legacyPort int
+		valid      bool
+	}{
+		{"no protocols", server.Options{ReadKeys: []string{"a"}, RateLimit: 10}, 0, 0, true},
+		{"binary without auth", server.Options{}, 7766, 0, true},
+		{"legacy without auth", server.Options{AdminToken: "secret"}, 0, 7767, true},
+		{"binary with read keys", server.Options{ReadKeys: []string{"a"}}, 7766, 0, false},
+		{"legacy with write keys", server.Options{WriteKeys: []string{"a"}}, 0, 7767, false},
+		{"binary with rate limit", server.Options{RateLimit: 10}, 7766, 0, false},
+	}
+	for _, test := range tests {
+		err := checkProtocolListeners(&test.opts, test.binaryPort, test.legacyPort)
+		if test.valid {
+			assert.NoError(t, err, test.name)
+		} else {
+			assert.Error(t, err, test.name)
+		}
+	}
+}
+
+func TestReadAPIKeysFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		readKeys  []string
+		writeKeys []string
+		valid     bool
+	}{
+		{"empty", "", []string{"x"}, nil, true},
+		{"scopes", "read a\nwrite b\nread c\n", []string{"x", "a", "c"}, []string{"b"}, true},
+		{"comments and whitespace", "# keys\n\n  read\ta  \n#write b\nwrite  c", []string{"x", "a"}, []string{"c"}, true},
+		{"missing key", "read a\nwrite\n", nil, nil, false},
+		{"extra field", "read a b\n", nil, nil, false},
+		{"unknown scope", "admin a\n", nil, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "keys")
+			require.NoError(t, ioutil.WriteFile(name, []byte(test.content), 0600))
+			opts := &server.Options{ReadKeys: []string{"x"}}
+			err := readAPIKeysFile(name, opts)
+			if !test.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.readKeys, opts.ReadKeys)
+			assert.Equal(t, test.writeKeys, opts.WriteKeys)
+		})
+	}
+
+	err := readAPIKeysFile(filepath.Join(t.TempDir(), "missing"), &server.Options{})
+	assert.Error(t, err, "missing files should be rejected")
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/shard"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v1"
+	"strconv"
+	"strings"
+)
+
+var splitCommand = cli.Command{
+	Name:  "split",
+	Usage: "Split the index into multiple shards",
+	Flags: []cli.Flag{
+		dbPathFlag,
+		cli.StringSliceFlag{Name: "target", Usage: "path to the database directory of a new shard (can be repeated)"},
+		cli.StringFlag{Name: "bounds", Usage: "comma-separated list of docIDs at which to split, hash partitioning is used if not set"},
+	},
+	Action: runSplit,
+}
+
+func parsePartitioner(bounds string, numShards int) (shard.Partitioner, error) {
+	if bounds == "" {
+		return shard.HashPartitioner(numShards), nil
+	}
+	var p shard.RangePartitioner
+	for _, s := range strings.Split(bounds, ",") {
+		docID, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid bound %q", s)
+		}
+		if len(p) > 0 && uint32(docID) <= p[len(p)-1] {
+			return nil, errors.New("bounds must be in increasing order")
+		}
+		p = append(p, uint32(docID))
+	}
+	return p, nil
+}
+
+func runSplit(ctx *cli.Context) error {
+	path := ctx.String("dbpath")
+	if path == "" {
+		return errors.New("no database directory specified")
+	}
+
+	targets := ctx.StringSlice("target")
+	if len(targets) < 2 {
+		return errors.New("at least two target directories must be specified")
+	}
+
+	partitioner, err := parsePartitioner(ctx.String("bounds"), len(targets))
+	if err != nil {
+		return err
+	}
+
+	fs, err := vfs.OpenDir(path, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database directory")
+	}
+
+	opts := indexOptions(ctx)
+	opts.ReadOnly = true
+
+	src, err := index.Open(fs, false, &opts)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database")
+	}
+	defer src.Close()
+
+	var dsts []*index.DB
+	defer func() {
+		for _, db := range dsts {
+			db.Close()
+		}
+	}()
+	for _, target := range targets {
+		fs, err := vfs.OpenDir(target, true)
+		if err != nil {
+			return errors.Wrapf(err, "unable to open the target directory %v", target)
+		}
+		opts := indexOptions(ctx)
+		opts.EnableAutoCompact = false
+		db, err := index.Open(fs, true, &opts)
+		if err != nil {
+			return errors.Wrapf(err, "unable to open the target database in %v", target)
+		}
+		dsts = append(dsts, db)
+	}
+
+	snapshot := src.Snapshot()
+	defer snapshot.Close()
+
+	return shard.Split(snapshot, dsts, partitioner)
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+)
+
+// openInput opens the named file for reading, or stdin if the name is empty or "-".
+// Gzip-compressed input is detected and decompressed automatically.
+func openInput(name string) (io.ReadCloser, error) {
+	var file *os.File
+	if name == "" || name == "-" {
+		file = os.Stdin
+	} else {
+		var err error
+		file, err = os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buffered := bufio.NewReader(file)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "invalid gzip stream")
+		}
+		return &readCloser{Reader: gz, closers: []io.Closer{gz, file}}, nil
+	}
+	return &readCloser{Reader: buffered, closers: []io.Closer{file}}, nil
+}
+
+// createOutput creates the named file for writing, or uses stdout if the name is empty or "-".
+// The output is optionally compressed, supported compression methods are "none" and "gzip".
+func createOutput(name string, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case "", "none", "gzip":
+	default:
+		return nil, errors.Errorf("unknown compression %v", compression)
+	}
+
+	var file *os.File
+	if name == "" || name == "-" {
+		file = os.Stdout
+	} else {
+		var err error
+		file, err = os.Create(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if compression == "gzip" {
+		gz := gzip.NewWriter(file)
+		return &writeCloser{Writer: gz, closers: []io.Closer{gz, file}}, nil
+	}
+	return &writeCloser{Writer: file, closers: []io.Closer{file}}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	return closeAll(r.closers)
+}
+
+type writeCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w *writeCloser) Close() error {
+	return closeAll(w.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	var firstErr error
+	for _, c := range closers {
+		err := c.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
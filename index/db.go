@@ -4,21 +4,26 @@
 package index
 
 import (
+	"context"
+	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
 	"go4.org/syncutil"
 	"io"
-	"io/ioutil"
-	"log"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-var debugLog = log.New(ioutil.Discard, "", log.LstdFlags)
-
 var ErrAlreadyClosed = errors.New("already closed")
 
+// ErrShuttingDown is returned when trying to start a transaction or a merge while the database is shutting down.
+var ErrShuttingDown = errors.New("database is shutting down")
+
+// ErrReadOnly is returned when trying to modify a database that was opened in read-only mode.
+var ErrReadOnly = errors.New("database is read-only")
+
 // Options represents the options that can be set when opening a database.
 type Options struct {
 	// When enabled, the database will automatically run compactions in the background.
@@ -26,6 +31,35 @@ type Options struct {
 
 	// How often to run automatic compactions. Only used if EnableAutoCompact is true.
 	AutoCompactInterval time.Duration
+
+	// Number of most recent manifests to keep on disk, together with all files they reference.
+	// Retained manifests can be used to go back to a previous state of the database using OpenAt.
+	// Zero disables the retention of new manifests. Previously retained manifests are left untouched and
+	// the files they reference are never deleted, so they can still be opened.
+	NumRetainedManifests int
+
+	// When enabled, the database can be only used for searching. It will never acquire the write lock,
+	// never delete any files and will not run any background tasks. Changes made by other processes
+	// can be loaded by calling Refresh.
+	ReadOnly bool
+
+	// How often to check for manifest changes made by other processes. Only used if ReadOnly is true.
+	// Zero disables the automatic checks.
+	RefreshInterval time.Duration
+
+	// Logger receives all log messages of the database. DefaultLogger is used if nil.
+	Logger Logger
+
+	// Number of items a transaction keeps in memory before writing them to a new segment.
+	// MaxBufferedItems is used if zero.
+	MaxBufferedItems int
+
+	// MergePolicy selects segments for merging. NewTieredMergePolicy is used if nil.
+	MergePolicy MergePolicy
+
+	// SegmentOptions control how new segments are written. Merged segments can use different options,
+	// see TieredMergePolicy.SegmentOptions.
+	SegmentOptions SegmentOptions
 }
 
 // DefaultOptions represent the options used if nil options are passed into Open().
@@ -36,103 +70,273 @@ var DefaultOptions = &Options{
 type DB struct {
 	fs              vfs.FileSystem
 	mu              sync.RWMutex
+	refreshMu       sync.Mutex
 	wlock           io.Closer
 	txid            uint32
 	manifest        atomic.Value
 	closed          bool
+	shuttingDown    bool
+	mergeRunning    int32
 	closing         chan struct{}
 	numSnapshots    int64
 	numTransactions int64
 	refs            map[string]int
+	retained        []*Manifest
+	replaced        *Manifest
 	orphanedFiles   chan string
-	mergeRequests   chan chan error
+	mergeRequests   chan *mergeRequest
 	mergePolicy     MergePolicy
 	bg              syncutil.Group
 	opts            *Options
+	metrics         *dbMetrics
+	logger          Logger
+	merging         atomic.Value
 }
 
 func Open(fs vfs.FileSystem, create bool, opts *Options) (*DB, error) {
+	return open(fs, create, 0, opts)
+}
+
+// OpenAt opens the database in the state right after transaction txid was committed.
+// The manifest of that transaction must have been retained, see Options.NumRetainedManifests.
+// Nothing is changed on disk until the next commit, which will make the old state current again.
+func OpenAt(fs vfs.FileSystem, txid uint32, opts *Options) (*DB, error) {
+	if txid == 0 {
+		return nil, errors.New("invalid transaction ID")
+	}
+	return open(fs, false, txid, opts)
+}
+
+func open(fs vfs.FileSystem, create bool, txid uint32, opts *Options) (*DB, error) {
 	if opts == nil {
 		opts = DefaultOptions
 	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = DefaultLogger
+	}
+
+	err := opts.SegmentOptions.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid segment options")
+	}
+	if mp, ok := opts.MergePolicy.(*TieredMergePolicy); ok && mp.SegmentOptions != nil {
+		err = mp.SegmentOptions.Validate()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid segment options of the merge policy")
+		}
+	}
 
 	var manifest Manifest
-	err := manifest.Load(fs, create)
+	err = manifest.Load(fs, create && !opts.ReadOnly)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open the manifest")
 	}
 
+	lastID := manifest.ID
+	var replaced *Manifest
+	if txid != 0 && txid != lastID {
+		if txid > lastID {
+			return nil, errors.Errorf("transaction %v has not been committed yet", txid)
+		}
+		current := manifest
+		replaced = &current
+		manifest = Manifest{}
+		err = manifest.LoadVersion(fs, txid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open the manifest of transaction %v", txid)
+		}
+		logger.Log(LogInfo, "opening database at an old transaction", Field{"txid", txid}, Field{"current_txid", lastID})
+	}
+
 	for _, segment := range manifest.Segments {
 		err = segment.Open(fs)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to open segment %v", segment.ID)
 		}
+		logger.Log(LogDebug, "opened segment", Field{"segment", segment.ID}, Field{"update", segment.UpdateID},
+			Field{"deleted_docs", segment.NumDeletedDocs()})
 	}
 
-	db := &DB{fs: fs, opts: opts}
-	db.init(&manifest)
+	var retained []*Manifest
+	if !opts.ReadOnly {
+		retained, err = loadRetainedManifests(fs, lastID, logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load retained manifests")
+		}
+	}
+
+	db := &DB{fs: fs, opts: opts, txid: lastID, metrics: newDBMetrics(), logger: logger}
+	db.init(&manifest, retained, replaced)
 	return db, nil
 }
 
-func (db *DB) init(manifest *Manifest) {
-	db.txid = manifest.ID
+func loadRetainedManifests(fs vfs.FileSystem, lastID uint32, logger Logger) ([]*Manifest, error) {
+	ids, err := ListVersions(fs)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []*Manifest
+	for _, id := range ids {
+		if id > lastID {
+			logger.Log(LogWarn, "ignoring manifest newer than the current one", Field{"txid", id})
+			continue
+		}
+		var manifest Manifest
+		err = manifest.LoadVersion(fs, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load manifest %v", id)
+		}
+		manifests = append(manifests, &manifest)
+	}
+	return manifests, nil
+}
+
+func (db *DB) init(manifest *Manifest, retained []*Manifest, replaced *Manifest) {
 	db.manifest.Store(manifest)
 
+	db.closing = make(chan struct{})
+
 	db.refs = make(map[string]int)
 	db.incFileRefs(manifest)
 
-	db.closing = make(chan struct{})
-
-	if db.opts.EnableAutoCompact {
-		db.bg.Go(db.autoCompact)
+	if db.opts.ReadOnly {
+		if db.opts.RefreshInterval > 0 {
+			db.bg.Go(db.watchManifest)
+		}
+		return
 	}
 
 	db.orphanedFiles = make(chan string, 16)
 	db.bg.Go(db.deleteOrphanedFiles)
 
-	db.mergePolicy = NewTieredMergePolicy()
-	db.mergeRequests = make(chan chan error)
+	for _, m := range retained {
+		db.incFileRefs(m)
+	}
+	db.retained = retained
+	db.trimRetainedManifests()
+
+	// Files of the manifest which is being replaced by OpenAt are still referenced from disk until the next commit.
+	if replaced != nil {
+		db.incFileRefs(replaced)
+		db.replaced = replaced
+	}
+
+	if db.opts.EnableAutoCompact {
+		db.bg.Go(db.autoCompact)
+	}
+
+	db.mergePolicy = db.opts.MergePolicy
+	if db.mergePolicy == nil {
+		db.mergePolicy = NewTieredMergePolicy()
+	}
+	db.mergeRequests = make(chan *mergeRequest)
 	db.bg.Go(db.runMerges)
 
 }
 
-func (db *DB) Close() {
+func (db *DB) isShuttingDown() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.shuttingDown
+}
+
+// Shutdown gracefully closes the database. New transactions and merges are rejected, and the database waits
+// until all open transactions are closed and the running merge is finished. If the context is done before that,
+// the database is closed anyway and the context error is returned.
+func (db *DB) Shutdown(ctx context.Context) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
+	db.shuttingDown = true
+	db.mu.Unlock()
 
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	var err error
+wait:
+	for atomic.LoadInt64(&db.numTransactions) > 0 || atomic.LoadInt32(&db.mergeRunning) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+			db.logger.Log(LogWarn, "closing the database with pending work", Field{"transactions", atomic.LoadInt64(&db.numTransactions)},
+				Field{"merges", atomic.LoadInt32(&db.mergeRunning)})
+			break wait
+		}
+	}
+
+	db.Close()
+	return err
+}
+
+func (db *DB) Close() {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return
+	}
+	db.closed = true
 	close(db.closing)
-	close(db.mergeRequests)
-	close(db.orphanedFiles)
+	if db.orphanedFiles != nil {
+		// Files are only queued for deletion under the locked mutex and never after the DB is closed.
+		close(db.orphanedFiles)
+	}
+	db.mu.Unlock()
+
+	// Background tasks might need the mutex to finish, so we must not hold it while waiting for them.
 	db.bg.Wait()
 
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
 	if db.wlock != nil {
 		db.wlock.Close()
 		db.wlock = nil
-		log.Println("released write lock")
+		db.logger.Log(LogInfo, "released write lock")
 	}
+}
 
-	db.closed = true
+type mergeRequest struct {
+	run  func() error
+	done chan error
 }
 
+// runInMergeThread executes fn in the background merge thread, so that it never runs concurrently with other merges.
+func (db *DB) runInMergeThread(fn func() error) error {
+	if db.opts.ReadOnly {
+		return ErrReadOnly
+	}
+	if db.isShuttingDown() {
+		return ErrShuttingDown
+	}
+	req := &mergeRequest{run: fn, done: make(chan error, 1)}
+	select {
+	case db.mergeRequests <- req:
+	case <-db.closing:
+		return ErrAlreadyClosed
+	}
+	return <-req.done
+}
+
+// Compact runs one merge selected by the merge policy, if there is any.
 func (db *DB) Compact() error {
-	ch := make(chan error)
-	db.mergeRequests <- ch
-	err := <-ch
-	return err
+	return db.runInMergeThread(func() error { return db.runOneMerge(0) })
 }
 
 func (db *DB) autoCompact() error {
 	interval := db.opts.AutoCompactInterval
-	log.Printf("scheduling auto-compact to run every %v", interval)
+	db.logger.Log(LogInfo, "scheduling auto-compact", Field{"interval", interval})
 	ticker := time.NewTicker(interval)
 	for {
 		select {
 		case <-ticker.C:
 			err := db.Compact()
+			if err == ErrShuttingDown {
+				continue
+			}
 			if err != nil {
-				log.Printf("auto-compact failed: %v", err)
 				interval += interval / 2
-				log.Printf("increasing auto-compact interval to %v", interval)
+				db.logger.Log(LogError, "auto-compact failed, increasing interval", Field{"error", err}, Field{"interval", interval})
 				ticker.Stop()
 				ticker = time.NewTicker(interval)
 			}
@@ -144,10 +348,35 @@ func (db *DB) autoCompact() error {
 }
 
 func (db *DB) runMerges() error {
-	for ch := range db.mergeRequests {
-		ch <- db.runOneMerge(0)
+	for {
+		select {
+		case req := <-db.mergeRequests:
+			atomic.AddInt32(&db.mergeRunning, 1)
+			err := req.run()
+			atomic.AddInt32(&db.mergeRunning, -1)
+			req.done <- err
+		case <-db.closing:
+			return nil
+		}
+	}
+}
+
+func (db *DB) watchManifest() error {
+	interval := db.opts.RefreshInterval
+	db.logger.Log(LogInfo, "checking for manifest changes", Field{"interval", interval})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := db.Refresh()
+			if err != nil {
+				db.logger.Log(LogError, "refresh failed", Field{"error", err})
+			}
+		case <-db.closing:
+			return nil
+		}
 	}
-	return nil
 }
 
 func (db *DB) runOneMerge(maxSize int) error {
@@ -166,9 +395,10 @@ func (db *DB) deleteOrphanedFiles() error {
 	for name := range db.orphanedFiles {
 		err := db.fs.Remove(name)
 		if err != nil {
-			log.Printf("[ERROR] failed to delete file %q: %v", name, err)
+			db.logger.Log(LogError, "failed to delete file", Field{"file", name}, Field{"error", err})
 		} else {
-			debugLog.Printf("deleted file %q", name)
+			db.logger.Log(LogDebug, "deleted file", Field{"file", name})
+			db.metrics.deletedFiles.Inc()
 		}
 	}
 	return nil
@@ -177,7 +407,7 @@ func (db *DB) deleteOrphanedFiles() error {
 // Note: This must be called under a locked mutex.
 func (db *DB) incFileRefs(m *Manifest) {
 	for _, segment := range m.Segments {
-		for _, name := range segment.fileNames() {
+		for _, name := range segment.FileNames() {
 			db.refs[name]++
 		}
 	}
@@ -186,16 +416,50 @@ func (db *DB) incFileRefs(m *Manifest) {
 // Note: This must be called under a locked mutex.
 func (db *DB) decFileRefs(m *Manifest) {
 	for _, segment := range m.Segments {
-		for _, name := range segment.fileNames() {
+		for _, name := range segment.FileNames() {
 			db.refs[name]--
-			if db.refs[name] <= 0 {
-				log.Printf("file %q is no longer needed", name)
+			if db.refs[name] > 0 {
+				continue
+			}
+			if db.opts.ReadOnly {
+				// We are not allowed to delete the file, but we can at least release it.
+				if name == segment.fileName() {
+					db.logger.Log(LogDebug, "closing segment", Field{"segment", segment.ID})
+					segment.Close()
+				}
+			} else if !db.closed {
+				db.logger.Log(LogInfo, "file is no longer needed", Field{"file", name})
 				db.orphanedFiles <- name
 			}
 		}
 	}
 }
 
+// Note: This must be called under a locked mutex.
+func (db *DB) retainManifest(m *Manifest) {
+	if db.opts.NumRetainedManifests <= 0 {
+		return
+	}
+	db.incFileRefs(m)
+	db.retained = append(db.retained, m)
+	db.trimRetainedManifests()
+}
+
+// Note: This must be called under a locked mutex.
+func (db *DB) trimRetainedManifests() {
+	if db.opts.NumRetainedManifests <= 0 {
+		return
+	}
+	for len(db.retained) > db.opts.NumRetainedManifests {
+		m := db.retained[0]
+		db.retained = db.retained[1:]
+		db.decFileRefs(m)
+		if !db.closed {
+			db.orphanedFiles <- manifestFileName(m.ID)
+		}
+	}
+}
+
 func (db *DB) Add(docID uint32, hashes []uint32) error {
 	return db.RunInTransaction(func(txn Batch) error { return txn.Add(docID, hashes) })
 }
@@ -204,6 +468,16 @@ func (db *DB) Delete(docID uint32) error {
 	return db.RunInTransaction(func(txn Batch) error { return txn.Delete(docID) })
 }
 
+// DeleteMany deletes all docs in the set from the index.
+func (db *DB) DeleteMany(docs *intset.SparseBitSet) error {
+	return db.RunInTransaction(func(txn Batch) error { return txn.DeleteMany(docs) })
+}
+
+// DeleteRange deletes all docs between min and max, inclusive, from the index.
+func (db *DB) DeleteRange(min, max uint32) error {
+	return db.RunInTransaction(func(txn Batch) error { return txn.DeleteRange(min, max) })
+}
+
 // Import adds a stream of items to the index.
 func (db *DB) Import(input ItemReader) error {
 	return db.RunInTransaction(func(txn Batch) error { return txn.Import(input) })
@@ -224,6 +498,16 @@ func (db *DB) Truncate() error {
 }
 
 func (db *DB) commit(prepareCommit func(base *Manifest) (*Manifest, error)) error {
+	err := db.doCommit(prepareCommit)
+	if err != nil {
+		db.metrics.commitErrors.Inc()
+	} else {
+		db.metrics.commits.Inc()
+	}
+	return err
+}
+
+func (db *DB) doCommit(prepareCommit func(base *Manifest) (*Manifest, error)) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -231,6 +515,10 @@ func (db *DB) commit(prepareCommit func(base *Manifest) (*Manifest, error)) erro
 		return ErrAlreadyClosed
 	}
 
+	if db.opts.ReadOnly {
+		return ErrReadOnly
+	}
+
 	base := db.manifest.Load().(*Manifest)
 
 	manifest, err := prepareCommit(base)
@@ -247,6 +535,13 @@ func (db *DB) commit(prepareCommit func(base *Manifest) (*Manifest, error)) erro
 		}
 	}
 
+	if db.opts.NumRetainedManifests > 0 {
+		err = manifest.SaveVersion(db.fs)
+		if err != nil {
+			return errors.Wrap(err, "save failed")
+		}
+	}
+
 	err = manifest.Save(db.fs)
 	if err != nil {
 		return errors.Wrap(err, "save failed")
@@ -254,19 +549,94 @@ func (db *DB) commit(prepareCommit func(base *Manifest) (*Manifest, error)) erro
 
 	db.incFileRefs(manifest)
 	db.decFileRefs(base)
+	if db.replaced != nil {
+		db.decFileRefs(db.replaced)
+		db.replaced = nil
+	}
+	db.retainManifest(manifest)
 
 	db.manifest.Store(manifest)
 
-	log.Printf("committed transaction %d (docs=%v, items=%v, segments=%v, checksum=%d)",
-		manifest.ID, manifest.NumDocs-manifest.NumDeletedDocs, manifest.NumItems, len(manifest.Segments), manifest.Checksum)
+	db.logger.Log(LogInfo, "committed transaction", manifestFields(manifest)...)
+
+	return nil
+}
+
+// Refresh loads the current manifest from disk, if it was changed by another process since the last time.
+// Segments that were already open are reused, only new segments and deletes are read from disk.
+// This is only possible in read-only mode.
+func (db *DB) Refresh() error {
+	if !db.opts.ReadOnly {
+		return errors.New("refresh is only supported in read-only mode")
+	}
+
+	db.refreshMu.Lock()
+	defer db.refreshMu.Unlock()
+
+	var manifest Manifest
+	err := manifest.Load(db.fs, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the manifest")
+	}
+
+	current := db.manifest.Load().(*Manifest)
+	if manifest.ID <= current.ID {
+		return nil
+	}
+
+	var opened []*Segment
+	for _, segment := range manifest.Segments {
+		prev, exists := current.Segments[segment.ID]
+		if exists {
+			err = segment.Reopen(db.fs, prev)
+		} else {
+			err = segment.Open(db.fs)
+			if err == nil {
+				opened = append(opened, segment)
+				db.logger.Log(LogDebug, "opened segment", Field{"segment", segment.ID}, Field{"update", segment.UpdateID},
+					Field{"deleted_docs", segment.NumDeletedDocs()})
+			}
+		}
+		if err != nil {
+			for _, segment := range opened {
+				segment.Close()
+			}
+			return errors.Wrapf(err, "failed to open segment %v", segment.ID)
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		for _, segment := range opened {
+			segment.Close()
+		}
+		return ErrAlreadyClosed
+	}
+
+	db.incFileRefs(&manifest)
+	db.decFileRefs(current)
+	db.manifest.Store(&manifest)
+	db.txid = manifest.ID
+
+	db.logger.Log(LogInfo, "refreshed to transaction", manifestFields(&manifest)...)
 
 	return nil
 }
 
 func (db *DB) Search(query []uint32) (map[uint32]int, error) {
+	started := time.Now()
 	snapshot := db.newSnapshot()
 	defer snapshot.Close()
-	return snapshot.Search(query)
+	hits, err := snapshot.Search(query)
+	db.metrics.searches.Inc()
+	if err != nil {
+		db.metrics.searchErrors.Inc()
+	} else {
+		db.metrics.searchDuration.Observe(time.Since(started).Seconds())
+	}
+	return hits, err
 }
 
 // Snapshot creates a consistent read-only view of the DB.
@@ -276,16 +646,20 @@ func (db *DB) Snapshot() Searcher {
 
 func (db *DB) closeTransaction(tx *Transaction) error {
 	numTransactions := atomic.AddInt64(&db.numTransactions, -1)
-	if numTransactions == 0 {
-		debugLog.Printf("closed transaction %p", tx)
-	} else {
-		debugLog.Printf("closed transaction %p, %v transactions still open", tx, numTransactions)
-	}
+	db.logger.Log(LogDebug, "closed transaction", Field{"base_txid", tx.snapshot.manifest.ID}, Field{"open_transactions", numTransactions})
 	return nil
 }
 
 // Transaction starts a new write transaction. You need to explicitly call Commit for the changes to be applied.
 func (db *DB) Transaction() (Batch, error) {
+	if db.opts.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if db.isShuttingDown() {
+		return nil, ErrShuttingDown
+	}
+
 	snapshot := db.newSnapshot()
 
 	db.mu.Lock()
@@ -302,7 +676,7 @@ func (db *DB) Transaction() (Batch, error) {
 			snapshot.Close()
 			return nil, errors.Wrap(err, "unable to acquire write lock")
 		}
-		log.Println("acquired write lock")
+		db.logger.Log(LogInfo, "acquired write lock")
 		db.wlock = lock
 	}
 
@@ -311,7 +685,7 @@ func (db *DB) Transaction() (Batch, error) {
 
 	atomic.AddInt64(&db.numTransactions, 1)
 
-	debugLog.Printf("created transaction %p (base=%v)", tx, tx.snapshot.manifest.ID)
+	db.logger.Log(LogDebug, "created transaction", Field{"base_txid", tx.snapshot.manifest.ID})
 
 	return tx, nil
 }
@@ -334,24 +708,20 @@ func (db *DB) RunInTransaction(fn func(txn Batch) error) error {
 }
 
 func (db *DB) closeSnapshot(snapshot *Snapshot) error {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
 	db.decFileRefs(snapshot.manifest)
 
 	numSnapshots := atomic.AddInt64(&db.numSnapshots, -1)
-	if numSnapshots == 0 {
-		debugLog.Printf("closed snapshot %p (%v)", snapshot, snapshot.manifest.ID)
-	} else {
-		debugLog.Printf("closed snapshot %p (%v), %v snapshots still open", snapshot, snapshot.manifest.ID, numSnapshots)
-	}
+	db.logger.Log(LogDebug, "closed snapshot", Field{"txid", snapshot.manifest.ID}, Field{"open_snapshots", numSnapshots})
 
 	return nil
 }
 
 func (db *DB) newSnapshot() *Snapshot {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
 	snapshot := &Snapshot{
 		manifest: db.manifest.Load().(*Manifest),
@@ -361,13 +731,46 @@ func (db *DB) newSnapshot() *Snapshot {
 	db.incFileRefs(snapshot.manifest)
 	atomic.AddInt64(&db.numSnapshots, 1)
 
-	debugLog.Printf("created snapshot %p (id=%v)", snapshot, snapshot.manifest.ID)
+	db.logger.Log(LogDebug, "created snapshot", Field{"txid", snapshot.manifest.ID})
 
 	return snapshot
 }
 
-func (db *DB) createSegment(input ItemReader) (*Segment, error) {
-	return CreateSegment(db.fs, atomic.AddUint32(&db.txid, 1), input)
+// createSegment writes a new segment. The database options are used if opts is nil.
+func (db *DB) createSegment(input ItemReader, opts *SegmentOptions) (*Segment, error) {
+	if opts == nil {
+		opts = &db.opts.SegmentOptions
+	}
+	started := time.Now()
+	segment, err := CreateSegmentWithOptions(db.fs, atomic.AddUint32(&db.txid, 1), input, opts)
+	if err != nil {
+		return nil, err
+	}
+	db.logger.Log(LogInfo, "created segment", Field{"segment", segment.ID}, Field{"docs", segment.Meta.NumDocs},
+		Field{"items", segment.Meta.NumItems}, Field{"blocks", segment.Meta.NumBlocks}, Field{"checksum", segment.Meta.Checksum},
+		Field{"duration", time.Since(started)})
+	return segment, nil
+}
+
+// removeSegment deletes the file of a segment that was never committed.
+func (db *DB) removeSegment(segment *Segment) {
+	segment.Close()
+	err := segment.Remove(db.fs)
+	if err != nil {
+		db.logger.Log(LogError, "failed to remove segment", Field{"segment", segment.ID}, Field{"error", err})
+		return
+	}
+	db.logger.Log(LogInfo, "removed segment", Field{"segment", segment.ID})
+}
+
+func manifestFields(m *Manifest) []Field {
+	return []Field{
+		{"txid", m.ID},
+		{"docs", m.NumDocs - m.NumDeletedDocs},
+		{"items", m.NumItems},
+		{"segments", len(m.Segments)},
+		{"checksum", m.Checksum},
+	}
 }
 
 func (db *DB) Reader() ItemReader {
@@ -379,6 +782,23 @@ func (db *DB) Reader() ItemReader {
 	return MergeItemReaders(readers...)
 }
 
+// Manifest returns the current manifest. The returned value must not be modified.
+func (db *DB) Manifest() *Manifest {
+	return db.manifest.Load().(*Manifest)
+}
+
+// OpenFile opens one of the files used by the database, e.g. for copying it to another host.
+// Only files referenced by the current manifest, open snapshots or retained manifests can be opened.
+func (db *DB) OpenFile(name string) (vfs.InputFile, error) {
+	db.mu.Lock()
+	refs := db.refs[name]
+	db.mu.Unlock()
+	if refs <= 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return db.fs.OpenFile(name)
+}
+
 func (db *DB) NumSegments() int {
 	manifest := db.manifest.Load().(*Manifest)
 	return len(manifest.Segments)
@@ -394,6 +814,17 @@ func (db *DB) NumDeletedDocs() int {
 	return manifest.NumDeletedDocs
 }
 
+// Attribute returns the committed value of an index attribute, or an empty string if the attribute is not set.
+func (db *DB) Attribute(name string) string {
+	manifest := db.manifest.Load().(*Manifest)
+	return manifest.Attribute(name)
+}
+
+// SetAttribute sets an index attribute in a new transaction.
+func (db *DB) SetAttribute(name, value string) error {
+	return db.RunInTransaction(func(txn Batch) error { return txn.(*Transaction).SetAttribute(name, value) })
+}
+
 // Contains returns true if the DB contains the given docID.
 func (db *DB) Contains(docID uint32) bool {
 	manifest := db.manifest.Load().(*Manifest)
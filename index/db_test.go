@@ -4,11 +4,15 @@
 package index
 
 import (
+	"context"
+	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math/rand"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDB(t *testing.T) {
@@ -106,6 +110,30 @@ func TestDB_Delete(t *testing.T) {
 	}()
 }
 
+func TestDB_DeleteThenAdd(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	func() {
+		db, err := Open(fs, true, nil)
+		require.NoError(t, err, "failed to create a new db")
+		defer db.Close()
+
+		require.NoError(t, db.Add(1, []uint32{7, 8, 9}), "add failed")
+		require.NoError(t, db.Delete(1), "delete failed")
+		require.NoError(t, db.Add(2, []uint32{3, 4, 5}), "add failed")
+	}()
+
+	func() {
+		db, err := Open(fs, false, nil)
+		require.NoError(t, err, "failed to open db")
+		defer db.Close()
+
+		assertNoHits(t, db, []uint32{9})
+		assertHitsEqual(t, db, []uint32{3}, map[uint32]int{2: 1})
+	}()
+}
+
 func TestDB_Add(t *testing.T) {
 	fs := vfs.CreateMemDir()
 	defer fs.Close()
@@ -379,3 +407,424 @@ func TestDB_Commit_ConcurrentDeleteAndUpdate(t *testing.T) {
 	assertNoHits(t, db, []uint32{2})
 	assertHitsEqual(t, db, []uint32{3}, map[uint32]int{1: 1})
 }
+
+func TestDB_OpenAt(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	opts := *DefaultOptions
+	opts.NumRetainedManifests = 3
+
+	var txid uint32
+	func() {
+		db, err := Open(fs, true, &opts)
+		require.NoError(t, err, "failed to create a new db")
+		defer db.Close()
+
+		require.NoError(t, db.Add(1, []uint32{7, 8, 9}), "add failed")
+		require.NoError(t, db.Add(2, []uint32{3, 4, 5}), "add failed")
+		txid = db.manifest.Load().(*Manifest).ID
+		require.NoError(t, db.Truncate(), "truncate failed")
+		assertNoHits(t, db, []uint32{7, 8, 9, 3, 4, 5})
+	}()
+
+	func() {
+		db, err := OpenAt(fs, txid, &opts)
+		require.NoError(t, err, "failed to open db at transaction %v", txid)
+		defer db.Close()
+
+		assertHitsEqual(t, db, []uint32{7, 8, 9, 3, 4, 5}, map[uint32]int{1: 3, 2: 3})
+		require.NoError(t, db.RunInTransaction(func(txn Batch) error { return nil }), "commit failed")
+	}()
+
+	func() {
+		db, err := Open(fs, false, &opts)
+		require.NoError(t, err, "failed to open db")
+		defer db.Close()
+
+		assertHitsEqual(t, db, []uint32{7, 8, 9, 3, 4, 5}, map[uint32]int{1: 3, 2: 3})
+	}()
+}
+
+func TestDB_RetainedManifests(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	opts := *DefaultOptions
+	opts.NumRetainedManifests = 2
+
+	db, err := Open(fs, true, &opts)
+	require.NoError(t, err, "failed to create a new db")
+	for i := uint32(1); i <= 5; i++ {
+		require.NoError(t, db.Add(i, []uint32{i}), "add failed")
+	}
+	require.NoError(t, db.Truncate(), "truncate failed")
+	db.Close()
+
+	ids, err := ListVersions(fs)
+	require.NoError(t, err)
+	require.Len(t, ids, 2, "only the last two manifests should be retained")
+
+	_, err = OpenAt(fs, ids[0]-1, &opts)
+	require.Error(t, err, "manifests which were not retained should not be possible to open")
+
+	db, err = OpenAt(fs, ids[0], &opts)
+	require.NoError(t, err, "failed to open db at transaction %v", ids[0])
+	defer db.Close()
+	require.Equal(t, 5, db.NumDocs())
+}
+
+func TestDB_RetainedManifestsWithoutRetention(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	opts := *DefaultOptions
+	opts.NumRetainedManifests = 3
+
+	db, err := Open(fs, true, &opts)
+	require.NoError(t, err, "failed to create a new db")
+	require.NoError(t, db.Add(1, []uint32{7, 8, 9}), "add failed")
+	require.NoError(t, db.Add(2, []uint32{3, 4, 5}), "add failed")
+	txid := db.manifest.Load().(*Manifest).ID
+	db.Close()
+
+	opts.NumRetainedManifests = 0
+
+	db, err = Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open db")
+	require.NoError(t, db.Truncate(), "truncate failed")
+	require.NoError(t, db.Add(3, []uint32{1, 2, 3}), "add failed")
+	db.Close()
+
+	db, err = OpenAt(fs, txid, &opts)
+	require.NoError(t, err, "failed to open db at transaction %v", txid)
+	assertHitsEqual(t, db, []uint32{7, 8, 9, 3, 4, 5}, map[uint32]int{1: 3, 2: 3})
+	require.NoError(t, db.RunInTransaction(func(txn Batch) error { return nil }), "commit failed")
+	db.Close()
+
+	entries, err := fs.ReadDir()
+	require.NoError(t, err)
+	numSegmentFiles := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".dat") {
+			numSegmentFiles++
+		}
+	}
+	require.Equal(t, 2, numSegmentFiles, "the segment added after the restored transaction should be deleted")
+}
+
+func TestDB_ReadOnly(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	require.NoError(t, db.Add(1, []uint32{7, 8, 9}), "add failed")
+
+	opts := *DefaultOptions
+	opts.ReadOnly = true
+
+	ro, err := Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open db in read-only mode")
+	defer ro.Close()
+
+	_, err = ro.Transaction()
+	require.Equal(t, ErrReadOnly, err, "transactions should not be allowed")
+	require.Equal(t, ErrReadOnly, ro.Add(2, []uint32{3}), "adds should not be allowed")
+	require.Equal(t, ErrReadOnly, ro.Truncate(), "truncates should not be allowed")
+	require.Equal(t, ErrReadOnly, ro.Compact(), "compactions should not be allowed")
+
+	assertHitsEqual(t, ro, []uint32{7, 8, 9}, map[uint32]int{1: 3})
+
+	require.NoError(t, db.Add(2, []uint32{3, 4, 5}), "add failed")
+	require.NoError(t, db.Delete(1), "delete failed")
+	assertHitsEqual(t, ro, []uint32{3, 4, 5, 7, 8, 9}, map[uint32]int{1: 3})
+
+	require.NoError(t, ro.Refresh(), "refresh failed")
+	assertHitsEqual(t, ro, []uint32{3, 4, 5, 7, 8, 9}, map[uint32]int{2: 3})
+}
+
+func TestDB_ReadOnly_Create(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ReadOnly = true
+
+	_, err := Open(vfs.CreateMemDir(), true, &opts)
+	require.Error(t, err, "a new db should not be created in read-only mode")
+}
+
+func TestDB_ReadOnly_RefreshInterval(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	opts := *DefaultOptions
+	opts.ReadOnly = true
+	opts.RefreshInterval = time.Millisecond
+
+	ro, err := Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open db in read-only mode")
+	defer ro.Close()
+
+	require.NoError(t, db.Add(1, []uint32{7, 8, 9}), "add failed")
+
+	for i := 0; i < 1000 && ro.NumDocs() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assertHitsEqual(t, ro, []uint32{7, 8, 9}, map[uint32]int{1: 3})
+}
+
+func TestDB_ReadOnly_ReleaseSegments(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	for i := uint32(0); i < 10; i++ {
+		require.NoError(t, db.Add(i, []uint32{i}), "add failed")
+	}
+
+	opts := *DefaultOptions
+	opts.ReadOnly = true
+
+	ro, err := Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open db in read-only mode")
+	defer ro.Close()
+
+	snapshot := ro.newSnapshot()
+	defer snapshot.Close()
+
+	require.NoError(t, db.Compact(), "compact failed")
+	require.NoError(t, ro.Refresh(), "refresh failed")
+	require.Equal(t, 1, ro.NumSegments(), "refreshed db should see the compacted segment")
+
+	hits, err := snapshot.Search([]uint32{3, 4})
+	require.NoError(t, err, "old snapshot should be still searchable")
+	require.Equal(t, map[uint32]int{3: 1, 4: 1}, hits)
+
+	require.NoError(t, snapshot.Close())
+	for _, segment := range snapshot.manifest.Segments {
+		require.Nil(t, segment.reader, "segment %v should be closed", segment.ID)
+	}
+	assertHitsEqual(t, ro, []uint32{3, 4}, map[uint32]int{3: 1, 4: 1})
+}
+
+func TestDB_Attributes(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	func() {
+		db, err := Open(fs, true, nil)
+		require.NoError(t, err, "failed to create a new db")
+		defer db.Close()
+
+		require.Equal(t, "", db.Attribute("max_document_id"))
+
+		txn, err := db.Transaction()
+		require.NoError(t, err, "failed to start transaction")
+		defer txn.Close()
+		require.NoError(t, txn.Add(1, []uint32{7, 8, 9}), "add failed")
+		require.NoError(t, txn.(*Transaction).SetAttribute("max_document_id", "1"), "set attribute failed")
+		require.Equal(t, "1", txn.(*Transaction).Attribute("max_document_id"))
+
+		snapshot := db.Snapshot().(*Snapshot)
+		defer snapshot.Close()
+		require.Equal(t, "", snapshot.Attribute("max_document_id"), "uncommitted attribute should not be visible")
+
+		require.NoError(t, db.SetAttribute("last_fingerprint_id", "100"), "set attribute failed")
+		require.NoError(t, txn.Commit(), "commit failed")
+
+		require.Equal(t, "1", db.Attribute("max_document_id"))
+		require.Equal(t, "100", db.Attribute("last_fingerprint_id"), "concurrently set attribute should be kept")
+		require.Equal(t, map[string]string{}, snapshot.Attributes(), "snapshot should not see later commits")
+	}()
+
+	func() {
+		db, err := Open(fs, false, nil)
+		require.NoError(t, err, "failed to open db")
+		defer db.Close()
+
+		snapshot := db.Snapshot().(*Snapshot)
+		defer snapshot.Close()
+		require.Equal(t, map[string]string{"max_document_id": "1", "last_fingerprint_id": "100"}, snapshot.Attributes())
+		assertHitsEqual(t, db, []uint32{7, 8, 9}, map[uint32]int{1: 3})
+
+		require.NoError(t, db.SetAttribute("max_document_id", ""), "set attribute failed")
+		require.Equal(t, "", db.Attribute("max_document_id"))
+	}()
+}
+
+func TestDB_DeleteRange(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	func() {
+		db, err := Open(fs, true, nil)
+		require.NoError(t, err, "failed to create a new db")
+		defer db.Close()
+
+		for docID := uint32(1); docID <= 10; docID++ {
+			require.NoError(t, db.Add(docID, []uint32{docID, 100}), "add failed")
+		}
+
+		err = db.RunInTransaction(func(txn Batch) error {
+			txn.Add(11, []uint32{11, 100})
+			txn.Add(12, []uint32{12, 100})
+			return txn.DeleteRange(3, 11)
+		})
+		require.NoError(t, err, "delete failed")
+		require.Equal(t, 8, db.NumDeletedDocs())
+
+		assertHitsEqual(t, db, []uint32{100}, map[uint32]int{1: 1, 2: 1, 12: 1})
+	}()
+
+	func() {
+		db, err := Open(fs, false, nil)
+		require.NoError(t, err, "failed to open db")
+		defer db.Close()
+
+		assertHitsEqual(t, db, []uint32{100}, map[uint32]int{1: 1, 2: 1, 12: 1})
+	}()
+}
+
+func TestDB_DeleteMany(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	func() {
+		db, err := Open(fs, true, nil)
+		require.NoError(t, err, "failed to create a new db")
+		defer db.Close()
+
+		for docID := uint32(1); docID <= 10; docID++ {
+			require.NoError(t, db.Add(docID, []uint32{docID, 100}), "add failed")
+		}
+
+		docs := intset.NewSparseBitSet(0)
+		for _, docID := range []uint32{2, 4, 6, 8, 10, 11, 1000} {
+			docs.Add(docID)
+		}
+		err = db.RunInTransaction(func(txn Batch) error {
+			txn.Add(11, []uint32{11, 100})
+			txn.Add(12, []uint32{12, 100})
+			return txn.DeleteMany(docs)
+		})
+		require.NoError(t, err, "delete failed")
+		require.Equal(t, 5, db.NumDeletedDocs())
+
+		assertHitsEqual(t, db, []uint32{100}, map[uint32]int{1: 1, 3: 1, 5: 1, 7: 1, 9: 1, 12: 1})
+	}()
+
+	func() {
+		db, err := Open(fs, false, nil)
+		require.NoError(t, err, "failed to open db")
+		defer db.Close()
+
+		assertHitsEqual(t, db, []uint32{100}, map[uint32]int{1: 1, 3: 1, 5: 1, 7: 1, 9: 1, 12: 1})
+	}()
+}
+
+func TestDB_Stats(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Add(1, []uint32{7, 8, 9}))
+	require.NoError(t, db.Add(5, []uint32{3, 4}))
+	require.NoError(t, db.Delete(1))
+
+	snapshot := db.Snapshot()
+	defer snapshot.Close()
+
+	stats := db.Stats()
+	assert.Equal(t, db.Manifest().ID, stats.TxID)
+	assert.Equal(t, 2, stats.NumDocs)
+	assert.Equal(t, 1, stats.NumDeletedDocs)
+	assert.Equal(t, 5, stats.NumItems)
+	assert.Equal(t, 2, stats.NumSegments)
+	assert.Equal(t, int64(1), stats.NumSnapshots)
+	assert.Equal(t, int64(0), stats.NumTransactions)
+	assert.False(t, stats.MergeInProgress)
+	require.Len(t, stats.Segments, 2)
+
+	s1, s2 := stats.Segments[0], stats.Segments[1]
+	assert.True(t, s1.ID < s2.ID, "segments should be sorted by ID")
+	assert.Equal(t, 1, s1.Meta.NumDocs)
+	assert.Equal(t, 1, s1.Meta.NumDeletedDocs)
+	assert.NotZero(t, s1.UpdateID)
+	assert.Equal(t, uint32(7), s1.Meta.MinTerm)
+	assert.Equal(t, uint32(9), s1.Meta.MaxTerm)
+	assert.Equal(t, uint32(5), s2.Meta.MinDocID)
+	assert.Equal(t, s1.Size+s2.Size, stats.Size)
+}
+
+func TestDB_Shutdown(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	txn, err := db.Transaction()
+	require.NoError(t, err)
+	require.NoError(t, txn.Add(1, []uint32{1, 2, 3}))
+
+	done := make(chan error, 1)
+	go func() { done <- db.Shutdown(context.Background()) }()
+
+	for !db.isShuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = db.Transaction()
+	assert.Equal(t, ErrShuttingDown, err, "new transactions should be rejected")
+	assert.Equal(t, ErrShuttingDown, db.Compact(), "new merges should be rejected")
+
+	select {
+	case <-done:
+		t.Fatal("shutdown should wait for the open transaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, txn.Commit())
+	txn.Close()
+	require.NoError(t, <-done)
+	assert.True(t, db.closed)
+}
+
+func TestDB_Shutdown_Timeout(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	txn, err := db.Transaction()
+	require.NoError(t, err)
+	defer txn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, db.Shutdown(ctx))
+	assert.True(t, db.closed, "database should be closed even after the timeout")
+}
+
+func TestDB_MaxBufferedItems(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MaxBufferedItems = 4
+	db, err := Open(vfs.CreateMemDir(), true, &opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	txn, err := db.Transaction()
+	require.NoError(t, err)
+	defer txn.Close()
+	for docID := uint32(1); docID <= 3; docID++ {
+		require.NoError(t, txn.Add(docID, []uint32{1, 2, 3}))
+	}
+	require.NoError(t, txn.Commit())
+
+	assert.Equal(t, 3, db.NumDocs())
+	assert.True(t, db.NumSegments() > 1, "buffered items should be flushed to multiple segments")
+}
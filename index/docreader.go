@@ -0,0 +1,262 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"bufio"
+	"container/heap"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/acoustid/go-acoustid/util"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"io"
+)
+
+// DefaultRunSize is the default number of items sorted in memory by DocReader.
+const DefaultRunSize = 16 * 1024 * 1024
+
+// DocReader reconstructs documents from a stream of items in any order, e.g. from Searcher.Reader.
+// The items are sorted by docID using an external merge sort. Sorted runs of items are stored
+// in temporary files in a filesystem and merged while reading the documents.
+type DocReader struct {
+	fs     vfs.FileSystem
+	prefix string
+	names  []string
+	runs   runHeap
+	err    error
+	logger Logger
+}
+
+// DocReaderOptions control how DocReader sorts the items.
+type DocReaderOptions struct {
+	// Number of items sorted in memory at once. DefaultRunSize is used if zero.
+	RunSize int
+
+	// Logger receives all log messages of the reader. DefaultLogger is used if nil.
+	Logger Logger
+}
+
+// NewDocReader reads all items from input and prepares them for reading as documents.
+// At most runSize items are kept in memory at once, the rest is stored in fs.
+func NewDocReader(input ItemReader, fs vfs.FileSystem, runSize int) (*DocReader, error) {
+	return NewDocReaderWithOptions(input, fs, &DocReaderOptions{RunSize: runSize})
+}
+
+// NewDocReaderWithOptions reads all items from input and prepares them for reading as documents.
+// The default options are used if opts is nil. Temporary files have names unique to the reader,
+// so multiple readers can share fs.
+func NewDocReaderWithOptions(input ItemReader, fs vfs.FileSystem, opts *DocReaderOptions) (*DocReader, error) {
+	if opts == nil {
+		opts = &DocReaderOptions{}
+	}
+	runSize := opts.RunSize
+	if runSize <= 0 {
+		runSize = DefaultRunSize
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = DefaultLogger
+	}
+
+	var id [8]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate a name for temporary files")
+	}
+
+	r := &DocReader{fs: fs, prefix: "docs-" + hex.EncodeToString(id[:]), logger: logger}
+
+	var items []Item
+	for {
+		block, err := input.ReadBlock()
+		for len(block) > 0 {
+			n := runSize - len(items)
+			if n > len(block) {
+				n = len(block)
+			}
+			items = append(items, block[:n]...)
+			block = block[n:]
+			if len(items) == runSize {
+				err2 := r.writeRun(items)
+				if err2 != nil {
+					r.Close()
+					return nil, err2
+				}
+				items = items[:0]
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			r.Close()
+			return nil, errors.Wrap(err, "read failed")
+		}
+	}
+	if len(items) > 0 {
+		err := r.writeRun(items)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+
+	for _, name := range r.names {
+		run, err := openRun(fs, name)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if !run.next() {
+			run.file.Close()
+			if run.err != nil {
+				r.Close()
+				return nil, run.err
+			}
+			continue
+		}
+		r.runs = append(r.runs, run)
+	}
+	heap.Init(&r.runs)
+
+	return r, nil
+}
+
+func (r *DocReader) writeRun(items []Item) error {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DocID < items[j].DocID || (items[i].DocID == items[j].DocID && items[i].Term < items[j].Term)
+	})
+
+	name := fmt.Sprintf("%s-%d.tmp", r.prefix, len(r.names))
+	file, err := r.fs.CreateFile(name, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary file")
+	}
+	r.names = append(r.names, name)
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	var buf [2 * util.MaxVarintLen32]byte
+	var lastDocID uint32
+	for _, item := range items {
+		n := util.PutUvarint32(buf[:], item.DocID-lastDocID)
+		n += util.PutUvarint32(buf[n:], item.Term)
+		_, err = writer.Write(buf[:n])
+		if err != nil {
+			return errors.Wrap(err, "write failed")
+		}
+		lastDocID = item.DocID
+	}
+	err = writer.Flush()
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+
+	r.logger.Log(LogDebug, "wrote sorted items", Field{"items", len(items)}, Field{"file", name})
+	return nil
+}
+
+// Read returns the next document. Documents are returned in increasing order of docID and their terms are sorted.
+// Returns io.EOF when there are no more documents.
+func (r *DocReader) Read() (*Doc, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.runs) == 0 {
+		return nil, io.EOF
+	}
+
+	doc := &Doc{ID: r.runs[0].item.DocID}
+	for len(r.runs) > 0 && r.runs[0].item.DocID == doc.ID {
+		run := r.runs[0]
+		doc.Terms = append(doc.Terms, run.item.Term)
+		if run.next() {
+			heap.Fix(&r.runs, 0)
+			continue
+		}
+		if run.err != nil {
+			r.err = run.err
+			return nil, r.err
+		}
+		run.file.Close()
+		heap.Pop(&r.runs)
+	}
+	if len(doc.Terms) > 1 {
+		sort.Slice(doc.Terms, func(i, j int) bool { return doc.Terms[i] < doc.Terms[j] })
+	}
+	return doc, nil
+}
+
+// Close deletes all temporary files.
+func (r *DocReader) Close() error {
+	for _, run := range r.runs {
+		run.file.Close()
+	}
+	r.runs = nil
+	for _, name := range r.names {
+		err := r.fs.Remove(name)
+		if err != nil {
+			r.logger.Log(LogError, "failed to delete temporary file", Field{"file", name}, Field{"error", err})
+		}
+	}
+	r.names = nil
+	if r.err == nil {
+		r.err = ErrAlreadyClosed
+	}
+	return nil
+}
+
+type docRun struct {
+	file   vfs.InputFile
+	reader *bufio.Reader
+	item   Item
+	err    error
+}
+
+func openRun(fs vfs.FileSystem, name string) (*docRun, error) {
+	file, err := fs.OpenFile(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open a temporary file")
+	}
+	return &docRun{file: file, reader: bufio.NewReader(file)}, nil
+}
+
+func (run *docRun) next() bool {
+	delta, err := binary.ReadUvarint(run.reader)
+	if err != nil {
+		if err != io.EOF {
+			run.err = errors.Wrap(err, "read failed")
+		}
+		return false
+	}
+	term, err := binary.ReadUvarint(run.reader)
+	if err != nil {
+		run.err = errors.Wrap(err, "invalid temporary file")
+		return false
+	}
+	run.item.DocID += uint32(delta)
+	run.item.Term = uint32(term)
+	return true
+}
+
+type runHeap []*docRun
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	return h[i].item.DocID < h[j].item.DocID || (h[i].item.DocID == h[j].item.DocID && h[i].item.Term < h[j].item.Term)
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*docRun)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
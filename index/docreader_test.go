@@ -0,0 +1,115 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestDocReader(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	expected := make(map[uint32][]uint32)
+	err = db.RunInTransaction(func(txn Batch) error {
+		for i := 0; i < 100; i++ {
+			docID := uint32(rand.Intn(1000)) + 1
+			terms := []uint32{uint32(i), uint32(i) + 1000, uint32(rand.Intn(10000))}
+			if terms[2] < terms[1] {
+				terms[1], terms[2] = terms[2], terms[1]
+			}
+			if terms[1] < terms[0] {
+				terms[0], terms[1] = terms[1], terms[0]
+			}
+			expected[docID] = terms
+			err := txn.Add(docID, terms)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err, "add failed")
+
+	tmp := vfs.CreateMemDir()
+	defer tmp.Close()
+
+	snapshot := db.Snapshot()
+	defer snapshot.Close()
+
+	reader, err := NewDocReader(snapshot.Reader(), tmp, 7)
+	require.NoError(t, err, "failed to create doc reader")
+
+	files, err := tmp.ReadDir()
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "runs should be stored in temporary files")
+
+	actual := make(map[uint32][]uint32)
+	var lastDocID uint32
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, "read failed")
+		require.True(t, doc.ID > lastDocID, "docs should be sorted by docID")
+		lastDocID = doc.ID
+		actual[doc.ID] = doc.Terms
+	}
+	require.Equal(t, expected, actual)
+
+	require.NoError(t, reader.Close())
+	files, err = tmp.ReadDir()
+	require.NoError(t, err)
+	require.Empty(t, files, "temporary files should be deleted")
+}
+
+func TestDocReader_SharedTempDir(t *testing.T) {
+	tmp := vfs.CreateMemDir()
+	defer tmp.Close()
+
+	var buf1, buf2 ItemBuffer
+	for docID := uint32(1); docID <= 20; docID++ {
+		buf1.Add(docID, []uint32{1, 2})
+		buf2.Add(docID+100, []uint32{3})
+	}
+
+	opts := &DocReaderOptions{RunSize: 7, Logger: NopLogger}
+	reader1, err := NewDocReaderWithOptions(buf1.Reader(), tmp, opts)
+	require.NoError(t, err, "failed to create doc reader")
+	defer reader1.Close()
+	reader2, err := NewDocReaderWithOptions(buf2.Reader(), tmp, opts)
+	require.NoError(t, err, "failed to create doc reader")
+	defer reader2.Close()
+
+	for docID := uint32(1); docID <= 20; docID++ {
+		doc, err := reader1.Read()
+		require.NoError(t, err, "read failed")
+		require.Equal(t, &Doc{ID: docID, Terms: []uint32{1, 2}}, doc)
+		doc, err = reader2.Read()
+		require.NoError(t, err, "read failed")
+		require.Equal(t, &Doc{ID: docID + 100, Terms: []uint32{3}}, doc)
+	}
+}
+
+func TestDocReader_Empty(t *testing.T) {
+	tmp := vfs.CreateMemDir()
+	defer tmp.Close()
+
+	var buf ItemBuffer
+	reader, err := NewDocReader(buf.Reader(), tmp, 0)
+	require.NoError(t, err, "failed to create doc reader")
+	defer reader.Close()
+
+	_, err = reader.Read()
+	require.Equal(t, io.EOF, err)
+}
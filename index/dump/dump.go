@@ -0,0 +1,278 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package dump implements a compact binary format for streams of index items.
+//
+// The stream starts with a header, consisting of the magic string "AIDXDUMP" and a version byte.
+// It is followed by blocks of items, each prefixed with the number of items as uvarint32.
+// Items in a block are delta-encoded, terms relative to the previous term and docIDs relative
+// to the previous docID if the term did not change, both as uvarint32. A block with zero items
+// marks the end of the stream and is followed by the CRC-32 (IEEE) checksum of all previous bytes,
+// as 4 bytes in little-endian order.
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util"
+	"github.com/pkg/errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	magic   = "AIDXDUMP"
+	version = 1
+)
+
+// MaxBlockSize is the maximum number of items in one block.
+const MaxBlockSize = 1024 * 1024
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Writer writes items in the binary format.
+type Writer struct {
+	w      *bufio.Writer
+	crc    hash.Hash32
+	buf    []byte
+	closed bool
+}
+
+// NewWriter creates a new Writer and writes the stream header to w.
+func NewWriter(w io.Writer) (*Writer, error) {
+	crc := crc32.NewIEEE()
+	writer := &Writer{w: bufio.NewWriter(io.MultiWriter(w, crc)), crc: crc}
+	_, err := writer.w.WriteString(magic)
+	if err == nil {
+		err = writer.w.WriteByte(version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write header")
+	}
+	return writer, nil
+}
+
+// WriteBlock writes a block of items. Large blocks are split to fit MaxBlockSize.
+func (w *Writer) WriteBlock(items []index.Item) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	for len(items) > 0 {
+		n := len(items)
+		if n > MaxBlockSize {
+			n = MaxBlockSize
+		}
+		err := w.writeBlock(items[:n])
+		if err != nil {
+			return err
+		}
+		items = items[n:]
+	}
+	return nil
+}
+
+func (w *Writer) writeBlock(items []index.Item) error {
+	w.buf = w.buf[:0]
+	w.putUvarint32(uint32(len(items)))
+	var lastTerm, lastDocID uint32
+	for _, item := range items {
+		termDelta := item.Term - lastTerm
+		w.putUvarint32(termDelta)
+		if termDelta == 0 {
+			w.putUvarint32(item.DocID - lastDocID)
+		} else {
+			w.putUvarint32(item.DocID)
+		}
+		lastTerm, lastDocID = item.Term, item.DocID
+	}
+	_, err := w.w.Write(w.buf)
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+	return nil
+}
+
+func (w *Writer) putUvarint32(x uint32) {
+	var tmp [util.MaxVarintLen32]byte
+	n := util.PutUvarint32(tmp[:], x)
+	w.buf = append(w.buf, tmp[:n]...)
+}
+
+// Close writes the end of the stream and flushes all buffered data. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.w.WriteByte(0)
+	if err == nil {
+		err = w.w.Flush()
+	}
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+	var checksum [4]byte
+	binary.LittleEndian.PutUint32(checksum[:], w.crc.Sum32())
+	_, err = w.w.Write(checksum[:])
+	if err == nil {
+		err = w.w.Flush()
+	}
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+	return nil
+}
+
+// Write copies all items from reader to w in the binary format.
+func Write(w io.Writer, reader index.ItemReader) error {
+	writer, err := NewWriter(w)
+	if err != nil {
+		return err
+	}
+	for {
+		items, err := reader.ReadBlock()
+		if len(items) > 0 {
+			err2 := writer.WriteBlock(items)
+			if err2 != nil {
+				return err2
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrap(err, "read failed")
+		}
+	}
+	return writer.Close()
+}
+
+// Reader reads items in the binary format. It implements index.ItemReader.
+type Reader struct {
+	r     *hashingReader
+	items []index.Item
+	err   error
+}
+
+// NewReader creates a new Reader and reads the stream header from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: &hashingReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}}
+	var header [len(magic) + 1]byte
+	_, err := io.ReadFull(reader.r, header[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read header")
+	}
+	if !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return nil, errors.New("invalid header")
+	}
+	if header[len(magic)] != version {
+		return nil, errors.Errorf("unsupported version %v", header[len(magic)])
+	}
+	return reader, nil
+}
+
+// ReadBlock reads the next block of items. The returned slice is only valid until the next call.
+// At the end of the stream, the checksum is verified and io.EOF is returned.
+func (r *Reader) ReadBlock() ([]index.Item, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	items, err := r.readBlock()
+	if err != nil {
+		r.err = err
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Reader) readBlock() ([]index.Item, error) {
+	n, err := r.readUvarint32()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, r.readChecksum()
+	}
+	if n > MaxBlockSize {
+		return nil, errors.Errorf("block too large (%v items)", n)
+	}
+
+	if cap(r.items) < int(n) {
+		r.items = make([]index.Item, n)
+	}
+	items := r.items[:n]
+	var lastTerm, lastDocID uint32
+	for i := range items {
+		termDelta, err := r.readUvarint32()
+		if err != nil {
+			return nil, err
+		}
+		docID, err := r.readUvarint32()
+		if err != nil {
+			return nil, err
+		}
+		items[i].Term = lastTerm + termDelta
+		if termDelta == 0 {
+			items[i].DocID = lastDocID + docID
+		} else {
+			items[i].DocID = docID
+		}
+		lastTerm, lastDocID = items[i].Term, items[i].DocID
+	}
+	return items, nil
+}
+
+func (r *Reader) readChecksum() error {
+	expected := r.r.crc.Sum32()
+	var checksum [4]byte
+	_, err := io.ReadFull(r.r.r, checksum[:])
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return errors.Wrap(err, "failed to read checksum")
+	}
+	if binary.LittleEndian.Uint32(checksum[:]) != expected {
+		return ErrChecksumMismatch
+	}
+	return io.EOF
+}
+
+func (r *Reader) readUvarint32() (uint32, error) {
+	x, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, errors.Wrap(err, "read failed")
+	}
+	if x > 0xffffffff {
+		return 0, errors.New("varint overflow")
+	}
+	return uint32(x), nil
+}
+
+// hashingReader updates the checksum with all bytes that were consumed from the buffered reader.
+type hashingReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	b   [1]byte
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.crc.Write(p[:n])
+	return n, err
+}
+
+func (h *hashingReader) ReadByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err == nil {
+		h.b[0] = b
+		h.crc.Write(h.b[:])
+	}
+	return b, err
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package dump
+
+import (
+	"bytes"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	var buf index.ItemBuffer
+	for docID := uint32(1); docID < 1000; docID++ {
+		buf.Add(docID, []uint32{rand.Uint32(), rand.Uint32() % 100, 0xffffffff})
+	}
+	buf.Add(0xffffffff, []uint32{0, 1})
+	expected, err := index.ReadAllItems(buf.Reader())
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, buf.Reader()))
+	require.True(t, out.Len() < len(expected)*8, "binary format should be smaller than raw items")
+
+	reader, err := NewReader(&out)
+	require.NoError(t, err)
+	actual, err := index.ReadAllItems(reader)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestWriteRead_Empty(t *testing.T) {
+	var buf index.ItemBuffer
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, buf.Reader()))
+
+	reader, err := NewReader(&out)
+	require.NoError(t, err)
+	_, err = reader.ReadBlock()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestRead_Corrupted(t *testing.T) {
+	var buf index.ItemBuffer
+	buf.Add(1, []uint32{100, 200, 300})
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, buf.Reader()))
+	data := out.Bytes()
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(magic)+3]++
+	reader, err := NewReader(bytes.NewReader(corrupted))
+	require.NoError(t, err)
+	_, err = index.ReadAllItems(reader)
+	require.Equal(t, ErrChecksumMismatch, err)
+
+	reader, err = NewReader(bytes.NewReader(data[:len(data)-2]))
+	require.NoError(t, err)
+	_, err = index.ReadAllItems(reader)
+	require.Error(t, err, "truncated stream should fail")
+
+	_, err = NewReader(bytes.NewReader([]byte("100 1\n")))
+	require.Error(t, err, "text stream should be rejected")
+}
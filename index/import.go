@@ -0,0 +1,173 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/pkg/errors"
+	"go4.org/syncutil"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ImportOptions control how ImportUnsorted builds segments.
+// The import keeps up to Concurrency+1 chunks of ChunkSize items in memory, 8 bytes per item.
+type ImportOptions struct {
+	// Number of items sorted in memory and written into one temporary segment.
+	ChunkSize int
+
+	// Number of chunks that are sorted and written at the same time.
+	Concurrency int
+}
+
+// MaxImportConcurrency limits the default concurrency of ImportUnsorted, so that the memory usage
+// does not grow with the number of CPUs.
+const MaxImportConcurrency = 4
+
+// DefaultImportOptions represent the options used if nil options are passed into ImportUnsorted().
+var DefaultImportOptions = &ImportOptions{
+	ChunkSize:   16 * 1024 * 1024,
+	Concurrency: defaultImportConcurrency(),
+}
+
+func defaultImportConcurrency() int {
+	n := runtime.NumCPU()
+	if n > MaxImportConcurrency {
+		n = MaxImportConcurrency
+	}
+	return n
+}
+
+// ImportUnsorted adds all items from input to the index. Unlike Import, the items can be in any order.
+// The input is split into chunks, which are sorted and written to temporary segments in parallel.
+// Items of one doc can end up in different chunks, so the temporary segments are merged into one
+// new segment at the end.
+func (txn *Transaction) ImportUnsorted(input ItemReader, opts *ImportOptions) error {
+	if txn.Committed() {
+		return ErrCommitted
+	}
+
+	if opts == nil {
+		opts = DefaultImportOptions
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultImportOptions.ChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		segments []*Segment
+		writers  syncutil.Group
+	)
+
+	sem := make(chan struct{}, concurrency)
+	writeChunk := func(items []Item) {
+		sem <- struct{}{}
+		writers.Go(func() error {
+			defer func() { <-sem }()
+			sort.Sort(ItemSliceSortedByTerm(items))
+			segment, err := txn.db.createSegment(&itemSliceReader{items: items}, nil)
+			if err != nil {
+				return errors.Wrap(err, "failed to create a new segment")
+			}
+			mu.Lock()
+			segments = append(segments, segment)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	var readErr error
+	items := make([]Item, 0, chunkSize)
+	for readErr == nil {
+		block, err := input.ReadBlock()
+		for len(block) > 0 {
+			n := chunkSize - len(items)
+			if n > len(block) {
+				n = len(block)
+			}
+			items = append(items, block[:n]...)
+			block = block[n:]
+			if len(items) == chunkSize {
+				writeChunk(items)
+				items = make([]Item, 0, chunkSize)
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			readErr = errors.Wrap(err, "read failed")
+		}
+	}
+	if readErr == nil && len(items) > 0 {
+		writeChunk(items)
+	}
+
+	err := writers.Err()
+	if readErr != nil {
+		err = readErr
+	}
+	if err == nil && len(segments) > 1 {
+		var merged *Segment
+		merged, err = txn.mergeImportedSegments(segments)
+		if err == nil {
+			segments = []*Segment{merged}
+		}
+	}
+	if err != nil {
+		txn.removeImportedSegments(segments)
+		return err
+	}
+
+	if len(segments) > 0 {
+		txn.manifest.AddSegment(segments[0])
+	}
+	return nil
+}
+
+func (txn *Transaction) mergeImportedSegments(segments []*Segment) (*Segment, error) {
+	readers := make([]ItemReader, len(segments))
+	for i, segment := range segments {
+		readers[i] = segment.Reader()
+	}
+	merged, err := txn.db.createSegment(MergeItemReaders(readers...), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "segment merge failed")
+	}
+	txn.db.logger.Log(LogInfo, "merged imported segments", Field{"segments", len(segments)}, Field{"segment", merged.ID})
+	txn.removeImportedSegments(segments)
+	return merged, nil
+}
+
+// removeImportedSegments deletes segments that were created by an import, but never added to the manifest.
+func (txn *Transaction) removeImportedSegments(segments []*Segment) {
+	for _, segment := range segments {
+		txn.db.removeSegment(segment)
+	}
+}
+
+// ImportUnsorted adds all items from input to the index in a single transaction. See Transaction.ImportUnsorted.
+func (db *DB) ImportUnsorted(input ItemReader, opts *ImportOptions) error {
+	return db.RunInTransaction(func(txn Batch) error { return txn.(*Transaction).ImportUnsorted(input, opts) })
+}
+
+type itemSliceReader struct {
+	items []Item
+}
+
+func (r *itemSliceReader) ReadBlock() ([]Item, error) {
+	if len(r.items) == 0 {
+		return nil, io.EOF
+	}
+	items := r.items
+	r.items = nil
+	return items, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func shuffledItems(numDocs, numTerms int) []Item {
+	var items []Item
+	for docID := 1; docID <= numDocs; docID++ {
+		for term := 0; term < numTerms; term++ {
+			items = append(items, Item{Term: uint32(docID*numTerms + term), DocID: uint32(docID)})
+		}
+	}
+	r := rand.New(rand.NewSource(0))
+	for i := range items {
+		j := r.Intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+	return items
+}
+
+func TestDB_ImportUnsorted(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	require.NoError(t, db.Add(5, []uint32{1, 2, 3}), "add failed")
+
+	items := shuffledItems(100, 10)
+	opts := &ImportOptions{ChunkSize: 300, Concurrency: 2}
+	require.NoError(t, db.ImportUnsorted(&itemSliceReader{items: items}, opts), "import failed")
+	require.Equal(t, 2, db.NumSegments())
+	require.Equal(t, 101, db.NumDocs(), "each imported doc should be counted once")
+	require.Equal(t, 1, db.NumDeletedDocs(), "only the replaced doc should be deleted")
+
+	hits, err := db.Search([]uint32{1, 2, 3, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{5: 10}, hits, "the imported doc should replace the existing one")
+
+	imported, err := ReadAllItems(db.Reader())
+	require.NoError(t, err)
+	require.Len(t, imported, len(items), "items of the replaced doc should not be returned")
+
+	require.NoError(t, db.Delete(7), "delete failed")
+	require.Equal(t, 101, db.NumDocs())
+	require.Equal(t, 2, db.NumDeletedDocs(), "the deleted doc should be counted once")
+}
+
+func TestDB_ImportUnsorted_Merge(t *testing.T) {
+	fs := vfs.CreateMemDir()
+	defer fs.Close()
+
+	db, err := Open(fs, true, nil)
+	require.NoError(t, err, "failed to create a new db")
+	defer db.Close()
+
+	items := shuffledItems(100, 10)
+	opts := &ImportOptions{ChunkSize: 300, Concurrency: 4}
+	require.NoError(t, db.ImportUnsorted(&itemSliceReader{items: items}, opts), "import failed")
+	require.Equal(t, 1, db.NumSegments())
+	require.Equal(t, 100, db.NumDocs())
+
+	imported, err := ReadAllItems(db.Reader())
+	require.NoError(t, err)
+	require.Len(t, imported, len(items))
+	for i := 1; i < len(imported); i++ {
+		require.True(t, ItemSliceSortedByTerm(imported).Less(i-1, i), "items should be sorted")
+	}
+
+	entries, err := fs.ReadDir()
+	require.NoError(t, err)
+	numSegmentFiles := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".dat") {
+			numSegmentFiles++
+		}
+	}
+	require.Equal(t, 1, numSegmentFiles, "only the merged segment file should be left")
+}
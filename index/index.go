@@ -7,7 +7,10 @@
 
 package index
 
-import "io"
+import (
+	"github.com/acoustid/go-acoustid/util/intset"
+	"io"
+)
 
 type Searcher interface {
 	io.Closer
@@ -26,6 +29,12 @@ type Batch interface {
 	// Delete deletes a document from the index.
 	Delete(docID uint32) error
 
+	// DeleteMany deletes all documents in the set from the index.
+	DeleteMany(docs *intset.SparseBitSet) error
+
+	// DeleteRange deletes all documents with docIDs between min and max, inclusive, from the index.
+	DeleteRange(min, max uint32) error
+
 	// Import adds a pre-sorted stream of document terms into the index.
 	Import(items ItemReader) error
 
@@ -110,6 +110,48 @@ func (ib *ItemBuffer) Delete(docID uint32) bool {
 	return true
 }
 
+// DeleteMulti deletes all docs in the set from the buffer.
+func (ib *ItemBuffer) DeleteMulti(docs *intset.SparseBitSet) bool {
+	return ib.deleteFunc(docs.Contains)
+}
+
+// DeleteRange deletes all docs between min and max, inclusive, from the buffer.
+func (ib *ItemBuffer) DeleteRange(min, max uint32) bool {
+	if ib.numDocs == 0 || max < ib.minDocID || min > ib.maxDocID {
+		return false
+	}
+	return ib.deleteFunc(func(docID uint32) bool { return docID >= min && docID <= max })
+}
+
+func (ib *ItemBuffer) deleteFunc(fn func(docID uint32) bool) bool {
+	if ib.docs == nil {
+		return false
+	}
+
+	n := 0
+	for _, item := range ib.items {
+		if fn(item.DocID) {
+			if ib.docs.Contains(item.DocID) {
+				ib.docs.Remove(item.DocID)
+				ib.numDocs--
+			}
+			continue
+		}
+		ib.items[n] = item
+		n++
+	}
+
+	if n == len(ib.items) {
+		return false
+	}
+
+	ib.items = ib.items[:n]
+	ib.minDocID = ib.docs.Min()
+	ib.maxDocID = ib.docs.Max()
+
+	return true
+}
+
 func (ib *ItemBuffer) Reader() ItemReader {
 	sort.Sort(ItemSliceSortedByTerm(ib.items))
 	return &itemBufferReader{ib: ib}
@@ -192,7 +234,7 @@ func (r *multiItemReader) ReadBlock() (items []Item, err error) {
 		for i := range items {
 			v1 := r.block1[0]
 			v2 := r.block2[0]
-			if v1.Term <= v2.Term || (v1.Term == v2.Term && v1.DocID <= v2.DocID) {
+			if v1.Term < v2.Term || (v1.Term == v2.Term && v1.DocID <= v2.DocID) {
 				items[i] = v1
 				r.block1 = r.block1[1:]
 				if len(r.block1) == 0 {
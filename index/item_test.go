@@ -4,6 +4,7 @@
 package index
 
 import (
+	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io"
@@ -41,6 +42,10 @@ func TestMergeItemReaders(t *testing.T) {
 		{Term: 1002, DocID: 1},
 	}
 	assert.Equal(t, expected, items)
+
+	items, err := ReadAllItems(MergeItemReaders(buf3.Reader(), buf2.Reader(), buf1.Reader()))
+	require.NoError(t, err)
+	assert.Equal(t, expected, items, "items with the same term should be sorted by docID regardless of the reader order")
 }
 
 func TestItemBuffer_Delete(t *testing.T) {
@@ -63,3 +68,33 @@ func TestItemBuffer_Delete(t *testing.T) {
 	require.Equal(t, uint32(1), buf.MinDocID())
 	require.Equal(t, uint32(1), buf.MaxDocID())
 }
+
+func TestItemBuffer_DeleteRange(t *testing.T) {
+	var buf ItemBuffer
+	buf.Add(1, []uint32{100, 101})
+	buf.Add(3, []uint32{300, 301})
+	buf.Add(5, []uint32{500})
+	require.False(t, buf.DeleteRange(6, 10))
+	require.True(t, buf.DeleteRange(2, 5))
+	require.Equal(t, 1, buf.NumDocs())
+	require.Equal(t, 2, buf.NumItems())
+	require.Equal(t, uint32(1), buf.MinDocID())
+	require.Equal(t, uint32(1), buf.MaxDocID())
+}
+
+func TestItemBuffer_DeleteMulti(t *testing.T) {
+	var buf ItemBuffer
+	buf.Add(1, []uint32{100, 101})
+	buf.Add(3, []uint32{300, 301})
+	buf.Add(5, []uint32{500})
+	docs := intset.NewSparseBitSet(0)
+	docs.Add(1)
+	docs.Add(2)
+	docs.Add(5)
+	require.True(t, buf.DeleteMulti(docs))
+	require.Equal(t, 1, buf.NumDocs())
+	require.Equal(t, 2, buf.NumItems())
+	require.Equal(t, uint32(3), buf.MinDocID())
+	require.Equal(t, uint32(3), buf.MaxDocID())
+	require.False(t, buf.DeleteMulti(docs))
+}
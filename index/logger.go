@@ -0,0 +1,99 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL%d", int(l))
+}
+
+// ParseLogLevel converts a level name, e.g. "debug", to a LogLevel.
+func ParseLogLevel(name string) (LogLevel, error) {
+	for l := LogDebug; l <= LogError; l++ {
+		if strings.EqualFold(name, l.String()) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// Field is a key/value pair attached to a log message, e.g. the transaction or segment ID.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger is the interface used by the database for all logging.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...Field)
+}
+
+// StdLogger writes log messages with level at least MinLevel to a standard library logger.
+// Fields are appended to the message as key=value pairs.
+type StdLogger struct {
+	// Logger is the destination, the global logger from the log package is used if nil.
+	Logger *log.Logger
+
+	// MinLevel is the lowest level of messages that will be written.
+	MinLevel LogLevel
+}
+
+func (l *StdLogger) Log(level LogLevel, msg string, fields ...Field) {
+	if level < l.MinLevel {
+		return
+	}
+	var buf bytes.Buffer
+	if level != LogInfo {
+		buf.WriteString("[")
+		buf.WriteString(level.String())
+		buf.WriteString("] ")
+	}
+	buf.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, " %s=%v", f.Key, f.Value)
+	}
+	if l.Logger != nil {
+		l.Logger.Output(2, buf.String())
+	} else {
+		log.Output(2, buf.String())
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level LogLevel, msg string, fields ...Field) {}
+
+// NopLogger discards all log messages.
+var NopLogger Logger = nopLogger{}
+
+// DefaultLogger is used by databases opened without a logger in the options and by functions that
+// are not tied to a database. It writes informational messages and errors to the global logger.
+var DefaultLogger Logger = &StdLogger{MinLevel: LogInfo}
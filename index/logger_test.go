@@ -0,0 +1,93 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"bytes"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) Log(level LogLevel, msg string, fields ...Field) {
+	entry := logEntry{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, f := range fields {
+		entry.fields[f.Key] = f.Value
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) find(msg string) *logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.entries {
+		if l.entries[i].msg == msg {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &StdLogger{Logger: log.New(&buf, "", 0), MinLevel: LogInfo}
+
+	logger.Log(LogDebug, "hidden", Field{"txid", 1})
+	logger.Log(LogInfo, "committed transaction", Field{"txid", 2}, Field{"segments", 3})
+	logger.Log(LogError, "failed", Field{"error", "boom"})
+
+	assert.Equal(t, "committed transaction txid=2 segments=3\n[ERROR] failed error=boom\n", buf.String())
+}
+
+func TestParseLogLevel(t *testing.T) {
+	level, err := ParseLogLevel("debug")
+	require.NoError(t, err)
+	assert.Equal(t, LogDebug, level)
+
+	level, err = ParseLogLevel("WARN")
+	require.NoError(t, err)
+	assert.Equal(t, LogWarn, level)
+
+	_, err = ParseLogLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestDB_Logger(t *testing.T) {
+	logger := &testLogger{}
+	opts := *DefaultOptions
+	opts.Logger = logger
+
+	db, err := Open(vfs.CreateMemDir(), true, &opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Add(1, []uint32{1, 2, 3}))
+
+	entry := logger.find("created segment")
+	require.NotNil(t, entry, "segment creation should be logged")
+	assert.Equal(t, LogInfo, entry.level)
+	assert.Equal(t, 3, entry.fields["items"])
+
+	entry = logger.find("committed transaction")
+	require.NotNil(t, entry, "commit should be logged")
+	assert.Equal(t, uint32(2), entry.fields["txid"], "the first ID is used by the new segment")
+
+	require.NotNil(t, logger.find("added doc to the transaction buffer"), "debug messages should be passed to the logger")
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"io"
+)
+
+// ForceMerge merges the smallest segments, so that the database has at most maxSegments segments.
+// Segments committed while the merge is running are not included.
+func (db *DB) ForceMerge(maxSegments int) error {
+	if maxSegments < 1 {
+		maxSegments = 1
+	}
+	return db.runInMergeThread(func() error {
+		snapshot := db.newSnapshot()
+		defer snapshot.Close()
+
+		if len(snapshot.manifest.Segments) <= maxSegments {
+			return nil
+		}
+
+		segments := make([]*Segment, 0, len(snapshot.manifest.Segments))
+		for _, segment := range snapshot.manifest.Segments {
+			segments = append(segments, segment)
+		}
+		sort.Slice(segments, func(i, j int) bool { return segments[i].Size() < segments[j].Size() })
+
+		merge := &Merge{Segments: segments[:len(segments)-maxSegments+1], SegmentOptions: db.mergedSegmentOptions()}
+		return merge.Run(db)
+	})
+}
+
+// ExpungeDeletes rewrites all segments that contain deleted docs, so that the space used by them is reclaimed.
+// Segments with no live docs are removed.
+func (db *DB) ExpungeDeletes() error {
+	return db.runInMergeThread(func() error {
+		snapshot := db.newSnapshot()
+		defer snapshot.Close()
+
+		var empty []*Segment
+		for _, segment := range snapshot.manifest.Segments {
+			if segment.NumDeletedDocs() == 0 {
+				continue
+			}
+			if segment.NumLiveDocs() == 0 {
+				empty = append(empty, segment)
+				continue
+			}
+			merge := &Merge{Segments: []*Segment{segment}, SegmentOptions: db.mergedSegmentOptions()}
+			err := merge.Run(db)
+			if err != nil {
+				return errors.Wrapf(err, "failed to rewrite segment %v", segment.ID)
+			}
+		}
+
+		if len(empty) == 0 {
+			return nil
+		}
+		return db.commit(func(base *Manifest) (*Manifest, error) {
+			manifest := base.Clone()
+			for _, segment := range empty {
+				if manifest.RemoveSegment(segment) {
+					db.logger.Log(LogInfo, "removed segment with no live docs", Field{"segment", segment.ID})
+				}
+			}
+			return manifest, nil
+		})
+	})
+}
+
+// Checkpoint copies the committed state of the database into the target filesystem, which can be later
+// opened as an independent database. Returns the ID of the copied transaction.
+func (db *DB) Checkpoint(target vfs.FileSystem) (uint32, error) {
+	snapshot := db.newSnapshot()
+	defer snapshot.Close()
+
+	manifest := snapshot.manifest
+	for _, segment := range manifest.Segments {
+		for _, name := range segment.FileNames() {
+			err := copyFile(db.fs, target, name)
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to copy %v", name)
+			}
+		}
+	}
+
+	err := manifest.Save(target)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to save the manifest")
+	}
+
+	db.logger.Log(LogInfo, "created checkpoint", Field{"txid", manifest.ID}, Field{"path", target.Path()})
+	return manifest.ID, nil
+}
+
+func copyFile(src, dest vfs.FileSystem, name string) error {
+	file, err := src.OpenFile(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vfs.WriteFile(dest, name, func(w io.Writer) error {
+		_, err := io.Copy(w, io.NewSectionReader(file, 0, file.Size()))
+		return err
+	})
+}
+
+// mergedSegmentOptions returns the options for merges that were not selected by the merge policy.
+func (db *DB) mergedSegmentOptions() *SegmentOptions {
+	if mp, ok := db.mergePolicy.(*TieredMergePolicy); ok {
+		return mp.SegmentOptions
+	}
+	return nil
+}
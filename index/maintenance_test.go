@@ -0,0 +1,87 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDB_ForceMerge(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for i := uint32(1); i <= 5; i++ {
+		require.NoError(t, db.Add(i, []uint32{i, 100}))
+	}
+	require.Equal(t, 5, db.NumSegments())
+
+	require.NoError(t, db.ForceMerge(2))
+	assert.Equal(t, 2, db.NumSegments())
+
+	require.NoError(t, db.ForceMerge(0))
+	assert.Equal(t, 1, db.NumSegments())
+	assert.Nil(t, db.MergeProgress(), "no merge should be running")
+
+	hits, err := db.Search([]uint32{100})
+	require.NoError(t, err)
+	assert.Len(t, hits, 5)
+}
+
+func TestDB_ExpungeDeletes(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.RunInTransaction(func(txn Batch) error {
+		txn.Add(1, []uint32{1, 2})
+		txn.Add(2, []uint32{3, 4})
+		return nil
+	}))
+	require.NoError(t, db.Add(3, []uint32{5}))
+	require.NoError(t, db.Add(4, []uint32{6}))
+	require.NoError(t, db.Delete(1))
+	require.NoError(t, db.Delete(3))
+	require.Equal(t, 3, db.NumSegments())
+	require.Equal(t, 2, db.NumDeletedDocs())
+
+	require.NoError(t, db.ExpungeDeletes())
+	assert.Equal(t, 2, db.NumSegments(), "the segment with no live docs should be removed")
+	assert.Equal(t, 0, db.NumDeletedDocs())
+	assert.Equal(t, 2, db.NumDocs())
+
+	items, err := ReadAllItems(db.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, []Item{{3, 2}, {4, 2}, {6, 4}}, items)
+}
+
+func TestDB_Checkpoint(t *testing.T) {
+	db, err := Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Add(1, []uint32{1, 2}))
+	require.NoError(t, db.Add(2, []uint32{3, 4}))
+	require.NoError(t, db.Delete(1))
+
+	target := vfs.CreateMemDir()
+	txid, err := db.Checkpoint(target)
+	require.NoError(t, err)
+	assert.Equal(t, db.Manifest().ID, txid)
+
+	require.NoError(t, db.Add(3, []uint32{5}))
+
+	db2, err := Open(target, false, nil)
+	require.NoError(t, err)
+	defer db2.Close()
+
+	assert.Equal(t, txid, db2.Manifest().ID)
+	assert.False(t, db2.Contains(3), "changes after the checkpoint should not be included")
+	hits, err := db2.Search([]uint32{1, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]int{2: 1}, hits)
+}
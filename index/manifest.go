@@ -5,14 +5,35 @@ package index
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
+	"go4.org/sort"
 	"io"
-	"log"
+	"strconv"
+	"strings"
 )
 
 const ManifestFilename = "manifest.json"
 
+// manifestFileName returns the name of the file in which a retained copy of the manifest with the given ID is stored.
+func manifestFileName(id uint32) string {
+	return fmt.Sprintf("manifest-%d.json", id)
+}
+
+// parseManifestFileName extracts the manifest ID from a name created by manifestFileName.
+func parseManifestFileName(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, "manifest-") || !strings.HasSuffix(name, ".json") {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, "manifest-"), ".json"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(id), true
+}
+
 type Manifest struct {
 	ID              uint32              `json:"id"`
 	BaseID          uint32              `json:"-"`
@@ -21,8 +42,10 @@ type Manifest struct {
 	NumItems        int                 `json:"items"`
 	Checksum        uint32              `json:"checksum"`
 	Segments        map[uint32]*Segment `json:"segments"`
+	Attributes      map[string]string   `json:"attributes,omitempty"`
 	addedSegments   map[uint32]struct{}
 	removedSegments map[uint32]struct{}
+	changedAttrs    map[string]struct{}
 }
 
 func NewManifest() *Manifest {
@@ -31,7 +54,7 @@ func NewManifest() *Manifest {
 	return &m
 }
 
-// Resets removes all segments from the manifest.
+// Resets removes all segments from the manifest. Attributes are not affected.
 func (m *Manifest) Reset() {
 	m.NumDocs = 0
 	m.NumDeletedDocs = 0
@@ -54,13 +77,41 @@ func (m *Manifest) Clone() *Manifest {
 		Segments:        make(map[uint32]*Segment, len(m.Segments)),
 		addedSegments:   make(map[uint32]struct{}),
 		removedSegments: make(map[uint32]struct{}),
+		changedAttrs:    make(map[string]struct{}),
 	}
 	for id, segment := range m.Segments {
 		m2.Segments[id] = segment.Clone()
 	}
+	if len(m.Attributes) > 0 {
+		m2.Attributes = make(map[string]string, len(m.Attributes))
+		for name, value := range m.Attributes {
+			m2.Attributes[name] = value
+		}
+	}
 	return m2
 }
 
+// Attribute returns the value of an attribute, or an empty string if the attribute is not set.
+func (m *Manifest) Attribute(name string) string {
+	return m.Attributes[name]
+}
+
+// SetAttribute sets the value of an attribute. Setting an empty value removes the attribute.
+func (m *Manifest) SetAttribute(name, value string) {
+	if value == "" {
+		delete(m.Attributes, name)
+	} else {
+		if m.Attributes == nil {
+			m.Attributes = make(map[string]string)
+		}
+		m.Attributes[name] = value
+	}
+	if m.changedAttrs == nil {
+		m.changedAttrs = make(map[string]struct{})
+	}
+	m.changedAttrs[name] = struct{}{}
+}
+
 func (m *Manifest) addSegment(s *Segment, dedupe bool) {
 	m.NumDocs += s.Meta.NumDocs
 	m.NumItems += s.Meta.NumItems
@@ -98,26 +149,61 @@ func (m *Manifest) RemoveSegment(s *Segment) bool {
 	return true
 }
 
-func (m *Manifest) Delete(docID uint32) {
+// Delete deletes the doc from all segments. Returns true if the doc was found in any of them.
+func (m *Manifest) Delete(docID uint32) bool {
+	deleted := false
 	m.NumDeletedDocs = 0
 	for _, segment := range m.Segments {
 		if segment.Delete(docID) {
-			log.Printf("deleted doc %v from segment %v", docID, segment.ID)
+			deleted = true
 		}
 		m.NumDeletedDocs += segment.NumDeletedDocs()
 	}
+	return deleted
+}
+
+// DeleteMulti deletes all docs in the set, updating each segment only once.
+func (m *Manifest) DeleteMulti(docs *intset.SparseBitSet) {
+	m.NumDeletedDocs = 0
+	for _, segment := range m.Segments {
+		segment.DeleteMulti(docs)
+		m.NumDeletedDocs += segment.NumDeletedDocs()
+	}
+}
+
+// DeleteRange deletes all docs between min and max, inclusive.
+func (m *Manifest) DeleteRange(min, max uint32) {
+	m.NumDeletedDocs = 0
+	for _, segment := range m.Segments {
+		segment.DeleteRange(min, max)
+		m.NumDeletedDocs += segment.NumDeletedDocs()
+	}
 }
 
 func (m *Manifest) Load(fs vfs.FileSystem, create bool) error {
-	file, err := fs.OpenFile(ManifestFilename)
+	err := m.load(fs, ManifestFilename)
 	if err != nil {
-		if vfs.IsNotExist(err) && create {
+		if vfs.IsNotExist(errors.Cause(err)) && create {
 			m.Reset()
 			m.ID = 0
 			return m.Save(fs)
 		}
+		return err
+	}
+	return nil
+}
+
+// LoadVersion loads a retained copy of the manifest committed in the given transaction.
+func (m *Manifest) LoadVersion(fs vfs.FileSystem, id uint32) error {
+	return m.load(fs, manifestFileName(id))
+}
+
+func (m *Manifest) load(fs vfs.FileSystem, name string) error {
+	file, err := fs.OpenFile(name)
+	if err != nil {
 		return errors.Wrap(err, "open failed")
 	}
+	defer file.Close()
 	err = json.NewDecoder(file).Decode(m)
 	if err != nil {
 		return errors.Wrap(err, "decode failed")
@@ -126,13 +212,39 @@ func (m *Manifest) Load(fs vfs.FileSystem, create bool) error {
 }
 
 func (m *Manifest) Save(fs vfs.FileSystem) error {
-	return vfs.WriteFile(fs, ManifestFilename, func(w io.Writer) error {
+	return m.save(fs, ManifestFilename)
+}
+
+// SaveVersion saves a copy of the manifest that will not be overwritten by future commits.
+func (m *Manifest) SaveVersion(fs vfs.FileSystem) error {
+	return m.save(fs, manifestFileName(m.ID))
+}
+
+func (m *Manifest) save(fs vfs.FileSystem, name string) error {
+	return vfs.WriteFile(fs, name, func(w io.Writer) error {
 		encoder := json.NewEncoder(w)
 		encoder.SetIndent("", "  ")
 		return encoder.Encode(m)
 	})
 }
 
+// ListVersions returns IDs of all retained manifests in the filesystem, sorted in increasing order.
+func ListVersions(fs vfs.FileSystem) ([]uint32, error) {
+	entries, err := fs.ReadDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list files")
+	}
+	var ids []uint32
+	for _, entry := range entries {
+		id, ok := parseManifestFileName(entry.Name())
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
 func (m *Manifest) rebase(base *Manifest) error {
 	for _, s := range base.Segments {
 		if s.dirty {
@@ -208,6 +320,24 @@ func (m *Manifest) rebase(base *Manifest) error {
 		m.NumDeletedDocs += s.NumDeletedDocs()
 	}
 
+	// Take attributes from the base manifest and apply our changes on top of them.
+	attrs := make(map[string]string, len(base.Attributes)+len(m.changedAttrs))
+	for name, value := range base.Attributes {
+		attrs[name] = value
+	}
+	for name := range m.changedAttrs {
+		value, exists := m.Attributes[name]
+		if exists {
+			attrs[name] = value
+		} else {
+			delete(attrs, name)
+		}
+	}
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+	m.Attributes = attrs
+
 	m.BaseID = base.ID
 	return nil
 }
@@ -237,11 +367,12 @@ func (m *Manifest) Commit(fs vfs.FileSystem, id uint32, base *Manifest) error {
 
 	m.addedSegments = nil
 	m.removedSegments = nil
+	m.changedAttrs = nil
 	return nil
 }
 
 func (m *Manifest) HasChanges() bool {
-	if len(m.addedSegments) > 0 || len(m.removedSegments) > 0 {
+	if len(m.addedSegments) > 0 || len(m.removedSegments) > 0 || len(m.changedAttrs) > 0 {
 		return true
 	}
 	for _, segment := range m.Segments {
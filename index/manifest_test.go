@@ -309,3 +309,26 @@ func TestManifest_Commit_ReapplyDelete(t *testing.T) {
 	require.False(t, m3.Segments[1].Contains(1))
 	require.False(t, m3.Segments[2].Contains(1))
 }
+
+func TestManifest_Commit_MergeAttributes(t *testing.T) {
+	fs := vfs.CreateMemDir()
+
+	m := NewManifest()
+	m.SetAttribute("a", "1")
+	m.SetAttribute("b", "1")
+	require.NoError(t, m.Commit(fs, 1, nil))
+
+	m2 := m.Clone()
+	m2.SetAttribute("b", "2")
+	m2.SetAttribute("c", "2")
+	require.NoError(t, m2.Commit(fs, 2, m))
+
+	m3 := m.Clone()
+	m3.SetAttribute("a", "")
+	m3.SetAttribute("d", "3")
+	m3.AddSegment(newTestSegment(t, fs, 3, 1, []uint32{1}))
+	require.NoError(t, m3.Commit(fs, 3, m2))
+
+	require.Equal(t, map[string]string{"b": "2", "c": "2", "d": "3"}, m3.Attributes)
+	require.Equal(t, map[string]string{"a": "1", "b": "1"}, m.Attributes, "committed manifest should not be modified")
+}
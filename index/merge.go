@@ -9,16 +9,21 @@ import (
 	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/pkg/errors"
 	"go4.org/sort"
-	"log"
 	"math"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Merge provides information necessary to perform a merge operation, resulting in one new segment.
 type Merge struct {
-	Segments   []*Segment
-	Score      float64
-	Size       int
+	Segments []*Segment
+	Score    float64
+	Size     int
+
+	// SegmentOptions control how the merged segment is written. The database options are used if nil.
+	SegmentOptions *SegmentOptions
+
 	newSegment *Segment
 }
 
@@ -39,26 +44,42 @@ func (m Merge) String() string {
 }
 
 func (m *Merge) Run(db *DB) error {
+	started := time.Now()
+
 	sort.Slice(m.Segments, func(i, j int) bool {
 		return m.Segments[i].ID < m.Segments[j].ID
 	})
 
 	var ids []string
 	var readers []ItemReader
+	progress := &MergeProgress{}
 	for _, segment := range m.Segments {
 		ids = append(ids, fmt.Sprintf("%v", segment.ID))
 		readers = append(readers, segment.Reader())
+		progress.Segments = append(progress.Segments, segment.ID)
+		progress.NumItems += segment.NumItems()
 	}
 
-	segment, err := db.createSegment(MergeItemReaders(readers...))
+	db.merging.Store(progress)
+	defer db.merging.Store((*MergeProgress)(nil))
+
+	segment, err := db.createSegment(&progressItemReader{reader: MergeItemReaders(readers...), progress: progress}, m.SegmentOptions)
 	if err != nil {
 		return errors.Wrap(err, "segment merge failed")
 	}
 
-	log.Printf("merged segments %v into %v", strings.Join(ids, ", "), segment.ID)
+	db.logger.Log(LogInfo, "merged segments", Field{"segments", strings.Join(ids, ",")}, Field{"segment", segment.ID})
 	m.newSegment = segment
 
-	return db.commit(m.prepareCommit)
+	err = db.commit(m.prepareCommit)
+	if err != nil {
+		return err
+	}
+
+	db.metrics.merges.Inc()
+	db.metrics.mergeDuration.Observe(time.Since(started).Seconds())
+	db.metrics.mergedBytes.Add(float64(segment.Size()))
+	return nil
 }
 
 func (m *Merge) prepareCommit(base *Manifest) (*Manifest, error) {
@@ -92,6 +113,38 @@ func (m *Merge) prepareCommit(base *Manifest) (*Manifest, error) {
 	return manifest, nil
 }
 
+// MergeProgress describes a running merge.
+type MergeProgress struct {
+	Segments     []uint32 `json:"segments"`
+	NumItems     int      `json:"items"`
+	NumItemsDone int64    `json:"items_done"`
+}
+
+type progressItemReader struct {
+	reader   ItemReader
+	progress *MergeProgress
+}
+
+func (r *progressItemReader) ReadBlock() ([]Item, error) {
+	items, err := r.reader.ReadBlock()
+	atomic.AddInt64(&r.progress.NumItemsDone, int64(len(items)))
+	return items, err
+}
+
+// MergeProgress returns the progress of the currently running merge, or nil if no merge is running.
+// The number of items includes deleted docs, which are skipped by the merge, so it is only an estimate.
+func (db *DB) MergeProgress() *MergeProgress {
+	progress, _ := db.merging.Load().(*MergeProgress)
+	if progress == nil {
+		return nil
+	}
+	return &MergeProgress{
+		Segments:     progress.Segments,
+		NumItems:     progress.NumItems,
+		NumItemsDone: atomic.LoadInt64(&progress.NumItemsDone),
+	}
+}
+
 // MergePolicy determines a sequence of merge operations.
 type MergePolicy interface {
 	FindBestMerge(manifest *Manifest, maxSize int) *Merge
@@ -119,6 +172,10 @@ type TieredMergePolicy struct {
 	// but fewer segments.  This should be >= MaxMergeAtOnce otherwise you'll force too much merging to occur.
 	// Default is 10.
 	MaxSegmentsPerTier int
+
+	// SegmentOptions control how merged segments are written. Merged segments are larger than new ones,
+	// so they can benefit from larger blocks. The database options are used if nil.
+	SegmentOptions *SegmentOptions
 }
 
 // NewTieredMergePolicy creates a new TieredMergePolicy instance with the default options.
@@ -180,7 +237,7 @@ func (mp *TieredMergePolicy) FindBestMerge(manifest *Manifest, maxSize int) *Mer
 
 	var bestMerge *Merge
 	for i := 0; i <= len(segments)-mp.MaxMergeAtOnce; i++ {
-		var merge Merge
+		merge := Merge{SegmentOptions: mp.SegmentOptions}
 		var mergeSize, mergeSizeFloored int
 		var hitTooLarge bool
 		for j := i; j < len(segments); j++ {
@@ -4,6 +4,7 @@
 package index
 
 import (
+	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -109,3 +110,36 @@ func TestTieredMergePolicy_FindMerges_Floored(t *testing.T) {
 	require.Contains(t, merge.Segments, manifest.Segments[3])
 	require.Contains(t, merge.Segments, manifest.Segments[4])
 }
+
+func TestTieredMergePolicy_SegmentOptions(t *testing.T) {
+	mp := NewTieredMergePolicy()
+	mp.FloorSegmentSize = 0
+	mp.MaxMergeAtOnce = 3
+	mp.MaxSegmentsPerTier = 1
+	mp.SegmentOptions = &SegmentOptions{BlockSize: 4096}
+
+	db, err := Open(vfs.CreateMemDir(), true, &Options{MergePolicy: mp, SegmentOptions: SegmentOptions{BlockSize: 512}})
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Add(1, []uint32{1, 2, 3}))
+	require.NoError(t, db.Add(2, []uint32{4, 5, 6}))
+	require.NoError(t, db.Add(3, []uint32{7, 8, 9}))
+	for _, segment := range db.Stats().Segments {
+		require.Equal(t, 512, segment.Meta.BlockSize, "new segments should use the database options")
+	}
+
+	snapshot := db.newSnapshot()
+	merge := mp.FindBestMerge(snapshot.manifest, 0)
+	snapshot.Close()
+	require.NotNil(t, merge)
+	require.Equal(t, mp.SegmentOptions, merge.SegmentOptions)
+
+	require.NoError(t, db.ForceMerge(1))
+	stats := db.Stats()
+	require.Len(t, stats.Segments, 1)
+	require.Equal(t, 4096, stats.Segments[0].Meta.BlockSize, "merged segments should use the merge policy options")
+
+	_, err = Open(vfs.CreateMemDir(), true, &Options{SegmentOptions: SegmentOptions{BlockSize: 1}})
+	require.Error(t, err, "invalid segment options should be rejected")
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"github.com/acoustid/go-acoustid/util/metrics"
+	"strconv"
+	"sync/atomic"
+)
+
+var mergeDurationBuckets = []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+type dbMetrics struct {
+	searches       metrics.Counter
+	searchErrors   metrics.Counter
+	searchDuration *metrics.Histogram
+	commits        metrics.Counter
+	commitErrors   metrics.Counter
+	merges         metrics.Counter
+	mergeDuration  *metrics.Histogram
+	mergedBytes    metrics.Counter
+	deletedFiles   metrics.Counter
+}
+
+func newDBMetrics() *dbMetrics {
+	return &dbMetrics{
+		searchDuration: metrics.NewHistogram(metrics.DefaultBuckets),
+		mergeDuration:  metrics.NewHistogram(mergeDurationBuckets),
+	}
+}
+
+// RegisterMetrics adds metrics of the database to the registry.
+func (db *DB) RegisterMetrics(r *metrics.Registry) {
+	m := db.metrics
+	r.Register("aindex_searches_total", "Total number of searches.", &m.searches)
+	r.Register("aindex_search_errors_total", "Total number of failed searches.", &m.searchErrors)
+	r.Register("aindex_search_duration_seconds", "Search latency in seconds.", m.searchDuration)
+	r.Register("aindex_commits_total", "Total number of committed transactions.", &m.commits)
+	r.Register("aindex_commit_errors_total", "Total number of failed commits.", &m.commitErrors)
+	r.Register("aindex_merges_total", "Total number of segment merges.", &m.merges)
+	r.Register("aindex_merge_duration_seconds", "Duration of segment merges in seconds.", m.mergeDuration)
+	r.Register("aindex_merged_bytes_total", "Total estimated size of segments written by merges.", &m.mergedBytes)
+	r.Register("aindex_deleted_files_total", "Total number of deleted orphaned files.", &m.deletedFiles)
+
+	r.GaugeFunc("aindex_docs", "Number of docs, including deleted docs.", func() float64 {
+		return float64(db.NumDocs())
+	})
+	r.GaugeFunc("aindex_deleted_docs", "Number of deleted docs that were not merged away yet.", func() float64 {
+		return float64(db.NumDeletedDocs())
+	})
+	r.GaugeFunc("aindex_segments", "Number of segments.", func() float64 {
+		return float64(db.NumSegments())
+	})
+	r.GaugeVecFunc("aindex_segment_size_bytes", "Estimated size of each segment.", "segment", func() map[string]float64 {
+		manifest := db.Manifest()
+		sizes := make(map[string]float64, len(manifest.Segments))
+		for id, segment := range manifest.Segments {
+			sizes[strconv.FormatUint(uint64(id), 10)] = float64(segment.Size())
+		}
+		return sizes
+	})
+	r.GaugeFunc("aindex_open_snapshots", "Number of open snapshots.", func() float64 {
+		return float64(atomic.LoadInt64(&db.numSnapshots))
+	})
+	r.GaugeFunc("aindex_open_transactions", "Number of open transactions.", func() float64 {
+		return float64(atomic.LoadInt64(&db.numTransactions))
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package proto implements the compact binary protocol of the index server.
+//
+// Every message is sent as a frame, which consists of the payload length encoded as uvarint32,
+// followed by the payload. The first byte of a request payload is the operation code, the first
+// byte of a response payload is the status. All integers are encoded as uvarint32 and lists
+// of integers are prefixed with their length.
+//
+// Requests:
+//
+//	OpAdd     docID terms...   adds a document to the connection's transaction
+//	OpDelete  docID            deletes a document in the connection's transaction
+//	OpSearch  terms...         searches the committed index
+//	OpCommit                   commits the connection's transaction
+//	OpDeleteMany  docIDs...    deletes multiple documents in the connection's transaction
+//	OpDeleteRange min max      deletes documents with docIDs between min and max, inclusive
+//
+// Every request gets exactly one response, in the same order as the requests were sent.
+// A successful response to OpSearch contains a list of (docID, hits) pairs, other successful
+// responses are empty. An error response contains the error message.
+package proto
+
+import (
+	"bufio"
+	"github.com/acoustid/go-acoustid/util"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const (
+	OpAdd    byte = 1
+	OpDelete byte = 2
+	OpSearch byte = 3
+	OpCommit byte = 4
+
+	OpDeleteMany  byte = 5
+	OpDeleteRange byte = 6
+)
+
+const (
+	StatusOK    byte = 0
+	StatusError byte = 1
+)
+
+// MaxFrameSize is the largest payload that will be accepted by ReadFrame.
+const MaxFrameSize = 16 * 1024 * 1024
+
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// WriteFrame writes the payload prefixed with its length to w.
+func WriteFrame(w io.Writer, payload []byte) error {
+	var buf [util.MaxVarintLen32]byte
+	n := util.PutUvarint32(buf[:], uint32(len(payload)))
+	_, err := w.Write(buf[:n])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+// ReadFrame reads one frame from r. The payload is stored in buf if it has enough capacity.
+func ReadFrame(r *bufio.Reader, buf []byte) ([]byte, error) {
+	var size uint32
+	var shift uint
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if i == util.MaxVarintLen32-1 && b > 15 {
+			return nil, errors.New("invalid frame size")
+		}
+		size |= uint32(b&0x7f) << shift
+		if b < 0x80 {
+			break
+		}
+		shift += 7
+	}
+	if size > MaxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+	if cap(buf) < int(size) {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	_, err := io.ReadFull(r, buf)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Encoder builds a message payload.
+type Encoder struct {
+	buf []byte
+}
+
+// Reset starts a new payload with the given operation code or status.
+func (e *Encoder) Reset(code byte) {
+	e.buf = append(e.buf[:0], code)
+}
+
+func (e *Encoder) PutUint32(x uint32) {
+	var buf [util.MaxVarintLen32]byte
+	n := util.PutUvarint32(buf[:], x)
+	e.buf = append(e.buf, buf[:n]...)
+}
+
+func (e *Encoder) PutUint32s(xs []uint32) {
+	e.PutUint32(uint32(len(xs)))
+	for _, x := range xs {
+		e.PutUint32(x)
+	}
+}
+
+func (e *Encoder) PutString(s string) {
+	e.PutUint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *Encoder) Bytes() []byte { return e.buf }
+
+// Decoder reads values from a message payload. Once an error occurs, all following reads
+// return zero values and the error is reported by Err.
+type Decoder struct {
+	buf []byte
+	err error
+}
+
+func NewDecoder(payload []byte) *Decoder {
+	return &Decoder{buf: payload}
+}
+
+// Code returns the operation code or status of the message.
+func (d *Decoder) Code() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	code := d.buf[0]
+	d.buf = d.buf[1:]
+	return code
+}
+
+func (d *Decoder) Uint32() uint32 {
+	if d.err != nil {
+		return 0
+	}
+	x, n := util.Uvarint32(d.buf)
+	if n <= 0 {
+		if n == 0 {
+			d.err = io.ErrUnexpectedEOF
+		} else {
+			d.err = errors.New("varint overflow")
+		}
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return x
+}
+
+// Uint32s reads a list of integers and appends it to dst.
+func (d *Decoder) Uint32s(dst []uint32) []uint32 {
+	n := d.Uint32()
+	// Each value takes at least one byte, which limits the size of invalid lists.
+	if d.err == nil && int(n) > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+	}
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		dst = append(dst, d.Uint32())
+	}
+	return dst
+}
+
+func (d *Decoder) String() string {
+	n := d.Uint32()
+	if d.err != nil {
+		return ""
+	}
+	if int(n) > len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+// Err returns the first error that occurred while decoding, or an error if there are unread bytes left.
+func (d *Decoder) Err() error {
+	if d.err == nil && len(d.buf) > 0 {
+		return errors.New("trailing data")
+	}
+	return d.err
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package proto
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, []byte("hello")))
+	require.NoError(t, WriteFrame(&buf, nil))
+	require.NoError(t, WriteFrame(&buf, bytes.Repeat([]byte{1}, 1000)))
+
+	r := bufio.NewReader(&buf)
+	payload, err := ReadFrame(r, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), payload)
+	payload, err = ReadFrame(r, payload[:0])
+	require.NoError(t, err)
+	require.Empty(t, payload)
+	payload, err = ReadFrame(r, payload[:0])
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte{1}, 1000), payload)
+	_, err = ReadFrame(r, nil)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestFrame_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, []byte("hello")))
+	_, err := ReadFrame(bufio.NewReader(bytes.NewReader(buf.Bytes()[:3])), nil)
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestFrame_TooLarge(t *testing.T) {
+	var enc Encoder
+	enc.PutUint32(MaxFrameSize + 1)
+	_, err := ReadFrame(bufio.NewReader(bytes.NewReader(enc.Bytes())), nil)
+	require.Equal(t, ErrFrameTooLarge, err)
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	var enc Encoder
+	enc.Reset(OpAdd)
+	enc.PutUint32(123456)
+	enc.PutUint32s([]uint32{1, 300, 0xffffffff})
+	enc.PutString("foo")
+
+	dec := NewDecoder(enc.Bytes())
+	require.Equal(t, OpAdd, dec.Code())
+	require.Equal(t, uint32(123456), dec.Uint32())
+	require.Equal(t, []uint32{1, 300, 0xffffffff}, dec.Uint32s(nil))
+	require.Equal(t, "foo", dec.String())
+	require.NoError(t, dec.Err())
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	var enc Encoder
+	enc.Reset(OpSearch)
+	enc.PutUint32(1000)
+	enc.PutUint32(1)
+
+	dec := NewDecoder(enc.Bytes())
+	require.Equal(t, OpSearch, dec.Code())
+	require.Empty(t, dec.Uint32s(nil))
+	require.Equal(t, io.ErrUnexpectedEOF, dec.Err())
+
+	enc.Reset(OpDelete)
+	enc.PutUint32(1)
+	enc.PutUint32(2)
+
+	dec = NewDecoder(enc.Bytes())
+	require.Equal(t, OpDelete, dec.Code())
+	require.Equal(t, uint32(1), dec.Uint32())
+	require.Error(t, dec.Err(), "trailing data should be an error")
+
+	dec = NewDecoder(nil)
+	dec.Code()
+	require.Equal(t, io.ErrUnexpectedEOF, dec.Err())
+}
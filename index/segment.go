@@ -13,17 +13,82 @@ import (
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/pkg/errors"
 	"io"
-	"log"
 	"sort"
-	"time"
 )
 
 const (
 	DefaultBlockSize = 1024
 	BlockHeaderSize  = 8
 	Fixed8BitTerms   = 1 << 15
+	BitPackedBlock   = 1 << 14
 )
 
+// MinBlockSize and MaxBlockSize limit the block size of new segments. Blocks must fit at least a few items,
+// and the number of items in a block is stored using 12 bits.
+const (
+	MinBlockSize = 64
+	MaxBlockSize = 8192
+)
+
+// TermEncoding selects how term deltas are stored in the blocks of new segments.
+// Blocks are self-describing, so segments written with any encoding can be read.
+type TermEncoding int
+
+const (
+	// TermEncodingAuto uses 8-bit deltas in blocks where all of them fit, and varints otherwise.
+	TermEncodingAuto TermEncoding = iota
+	// TermEncodingVarint always stores term deltas as varints.
+	TermEncodingVarint
+	// TermEncodingPacked stores term and docID deltas bit-packed, using the smallest bit width
+	// that fits all deltas of the block. The deltas are relative to the block's base term and docID.
+	TermEncodingPacked
+)
+
+var termEncodingNames = map[TermEncoding]string{
+	TermEncodingAuto:   "auto",
+	TermEncodingVarint: "varint",
+	TermEncodingPacked: "packed",
+}
+
+func (e TermEncoding) String() string {
+	name, exists := termEncodingNames[e]
+	if !exists {
+		return fmt.Sprintf("TermEncoding(%d)", int(e))
+	}
+	return name
+}
+
+// ParseTermEncoding returns the term encoding with the given name.
+func ParseTermEncoding(s string) (TermEncoding, error) {
+	for e, name := range termEncodingNames {
+		if name == s {
+			return e, nil
+		}
+	}
+	return TermEncodingAuto, errors.Errorf("unknown term encoding %q", s)
+}
+
+// SegmentOptions control how new segments are written.
+type SegmentOptions struct {
+	// Size of a data block in bytes. Smaller blocks make searches read less data, larger blocks
+	// compress better and make the block index smaller. DefaultBlockSize is used if zero.
+	BlockSize int
+
+	// TermEncoding selects how term deltas are stored in blocks.
+	TermEncoding TermEncoding
+}
+
+// Validate checks that the options can be used for writing segments.
+func (o *SegmentOptions) Validate() error {
+	if o.BlockSize != 0 && (o.BlockSize < MinBlockSize || o.BlockSize > MaxBlockSize) {
+		return errors.Errorf("block size must be between %v and %v", MinBlockSize, MaxBlockSize)
+	}
+	if _, exists := termEncodingNames[o.TermEncoding]; !exists {
+		return errors.Errorf("invalid term encoding %v", o.TermEncoding)
+	}
+	return nil
+}
+
 var (
 	ErrNoData             = errors.New("no data")
 	ErrInvalidBlockHeader = errors.New("invalid block header")
@@ -53,6 +118,9 @@ type Segment struct {
 	docs        *intset.SparseBitSet
 	deletedDocs *intset.SparseBitSet
 	dirty       bool
+
+	// termEncoding is only used while writing the segment.
+	termEncoding TermEncoding
 }
 
 // Size returns the estimated size of the segment file in bytes.  The actual file size might differ.
@@ -69,6 +137,7 @@ func (s *Segment) NumItems() int       { return s.Meta.NumItems }
 func (s *Segment) Clone() *Segment {
 	return &Segment{
 		ID:          s.ID,
+		UpdateID:    s.UpdateID,
 		Meta:        s.Meta,
 		blockIndex:  s.blockIndex,
 		reader:      s.reader,
@@ -78,15 +147,31 @@ func (s *Segment) Clone() *Segment {
 	}
 }
 
+// CreateSegment writes all items from input to a new segment with the default options.
 func CreateSegment(fs vfs.FileSystem, id uint32, input ItemReader) (*Segment, error) {
+	return CreateSegmentWithOptions(fs, id, input, nil)
+}
+
+// CreateSegmentWithOptions writes all items from input to a new segment. The default options are used if opts is nil.
+func CreateSegmentWithOptions(fs vfs.FileSystem, id uint32, input ItemReader, opts *SegmentOptions) (*Segment, error) {
+	if opts == nil {
+		opts = &SegmentOptions{}
+	}
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Segment{
 		ID: id,
 		Meta: SegmentMeta{
-			BlockSize: DefaultBlockSize,
+			BlockSize: opts.BlockSize,
 		},
+		termEncoding: opts.TermEncoding,
+	}
+	if s.Meta.BlockSize == 0 {
+		s.Meta.BlockSize = DefaultBlockSize
 	}
-
-	started := time.Now()
 
 	name := s.fileName()
 	file, err := fs.CreateAtomicFile(name)
@@ -105,9 +190,6 @@ func CreateSegment(fs vfs.FileSystem, id uint32, input ItemReader) (*Segment, er
 		return nil, errors.Wrap(err, "file commit failed")
 	}
 
-	log.Printf("created segment %v (docs=%v, items=%v, blocks=%v, checksum=%v, duration=%s)",
-		s.ID, s.Meta.NumDocs, s.Meta.NumItems, s.Meta.NumBlocks, s.Meta.Checksum, time.Since(started))
-
 	s.reader, err = fs.OpenFile(name)
 	if err != nil {
 		s.Remove(fs)
@@ -156,6 +238,29 @@ func (s *Segment) Open(fs vfs.FileSystem) error {
 	return nil
 }
 
+// Reopen initializes the segment by sharing already loaded data with another instance of the same segment.
+// Only deleted docs are loaded from the filesystem, and only if they were changed.
+func (s *Segment) Reopen(fs vfs.FileSystem, prev *Segment) error {
+	s.blockIndex = prev.blockIndex
+	s.reader = prev.reader
+	s.docs = prev.docs
+	if s.UpdateID == prev.UpdateID {
+		s.deletedDocs = prev.deletedDocs
+		return nil
+	}
+	return s.LoadUpdate(fs)
+}
+
+// Close closes the underlying segment file.
+func (s *Segment) Close() error {
+	if s.reader == nil {
+		return nil
+	}
+	err := s.reader.Close()
+	s.reader = nil
+	return err
+}
+
 func (s *Segment) fileName() string {
 	return fmt.Sprintf("segment-%d.dat", s.ID)
 }
@@ -164,7 +269,8 @@ func (s *Segment) updateFileName(updateID uint32) string {
 	return fmt.Sprintf("segment-%d-%d.del", s.ID, updateID)
 }
 
-func (s *Segment) fileNames() []string {
+// FileNames returns names of all files needed by the segment.
+func (s *Segment) FileNames() []string {
 	names := []string{s.fileName()}
 	if s.UpdateID != 0 {
 		names = append(names, s.updateFileName(s.UpdateID))
@@ -178,11 +284,46 @@ func (s *Segment) Remove(fs vfs.FileSystem) error {
 	if err := fs.Remove(name); err != nil {
 		return errors.Wrapf(err, "failed to remove segment file %v", name)
 	}
-	log.Printf("removed segment file %v", name)
 	return nil
 }
 
-func (s *Segment) writeBlock(writer *bufio.Writer, input []Item, buf1 []byte, buf2 []byte) (n int, err error) {
+// bitWidth returns the number of bits needed to store x.
+func bitWidth(x uint32) int {
+	if x == 0 {
+		return 0
+	}
+	return 1 + util.HighestSetBit32(x)
+}
+
+// packedSize returns the number of bytes packDeltas needs for n deltas with the given maximum.
+func packedSize(n int, max uint32) int {
+	return 1 + (n*bitWidth(max)+7)/8
+}
+
+// packDeltas writes the bit width of the deltas followed by the bit-packed deltas and returns the number of bytes written.
+func packDeltas(dst []byte, deltas []uint32) int {
+	var max uint32
+	for _, delta := range deltas {
+		max |= delta
+	}
+	bits := bitWidth(max)
+	dst[0] = byte(bits)
+	return 1 + util.PackBits(bits, dst[1:], deltas)
+}
+
+// unpackDeltas reads len(dst) deltas written by packDeltas and returns the number of bytes read.
+func unpackDeltas(dst []uint32, src []byte) (int, error) {
+	if len(src) == 0 {
+		return 0, ErrInvalidBlockData
+	}
+	bits := int(src[0])
+	if bits > 32 || 1+(len(dst)*bits+7)/8 > len(src) {
+		return 0, ErrInvalidBlockData
+	}
+	return 1 + util.UnpackBits(bits, dst, src[1:]), nil
+}
+
+func (s *Segment) writeBlock(writer *bufio.Writer, input []Item, buf1 []byte, buf2 []byte, deltas []uint32) (n int, err error) {
 	n = len(input)
 	if n == 0 {
 		err = ErrNoData
@@ -214,7 +355,30 @@ func (s *Segment) writeBlock(writer *bufio.Writer, input []Item, buf1 []byte, bu
 	}
 
 	var flags uint16
-	if termBits <= 8 {
+	if s.termEncoding == TermEncodingPacked {
+		flags |= BitPackedBlock
+		termDeltas, docDeltas := deltas[:n], deltas[n:2*n]
+		var maxTermDelta, maxDocDelta uint32
+		for i, it := range input {
+			if it.Term != lastTerm {
+				lastDocID = baseDocID
+			}
+			termDeltas[i] = it.Term - lastTerm
+			docDeltas[i] = it.DocID - lastDocID
+			maxTermDelta |= termDeltas[i]
+			maxDocDelta |= docDeltas[i]
+			if BlockHeaderSize+packedSize(i+1, maxTermDelta)+packedSize(i+1, maxDocDelta) > s.Meta.BlockSize {
+				n = i
+				break
+			}
+			lastTerm = it.Term
+			lastDocID = it.DocID
+			s.Meta.Checksum += it.Term + it.DocID
+			s.docs.Add(it.DocID)
+		}
+		ptr1 = packDeltas(buf1, termDeltas[:n])
+		ptr2 = packDeltas(buf2, docDeltas[:n])
+	} else if termBits <= 8 && s.termEncoding == TermEncodingAuto {
 		flags |= Fixed8BitTerms
 		for i, it := range input {
 			if it.Term != lastTerm {
@@ -308,6 +472,7 @@ func (s *Segment) writeData(file io.Writer, it ItemReader) error {
 	buf2 := make([]byte, s.Meta.BlockSize)
 
 	maxItemsPerBlock := (s.Meta.BlockSize - BlockHeaderSize) / 2
+	deltas := make([]uint32, 2*maxItemsPerBlock)
 	remaining := make([]Item, 0, maxItemsPerBlock)
 	for {
 		block, err := it.ReadBlock()
@@ -316,7 +481,7 @@ func (s *Segment) writeData(file io.Writer, it ItemReader) error {
 		}
 		if len(block) == 0 {
 			for len(remaining) > 0 {
-				n, err := s.writeBlock(writer, remaining, buf1, buf2)
+				n, err := s.writeBlock(writer, remaining, buf1, buf2, deltas)
 				if err != nil {
 					return err
 				}
@@ -327,7 +492,7 @@ func (s *Segment) writeData(file io.Writer, it ItemReader) error {
 		for len(remaining) > 0 && len(remaining)+len(block) >= maxItemsPerBlock {
 			m := len(remaining)
 			remaining = append(remaining, block[:maxItemsPerBlock-m:len(block)]...)
-			n, err := s.writeBlock(writer, remaining, buf1, buf2)
+			n, err := s.writeBlock(writer, remaining, buf1, buf2, deltas)
 			if err != nil {
 				return err
 			}
@@ -340,7 +505,7 @@ func (s *Segment) writeData(file io.Writer, it ItemReader) error {
 			}
 		}
 		for len(block) >= maxItemsPerBlock {
-			n, err := s.writeBlock(writer, block[:maxItemsPerBlock], buf1, buf2)
+			n, err := s.writeBlock(writer, block[:maxItemsPerBlock], buf1, buf2, deltas)
 			if err != nil {
 				return err
 			}
@@ -382,7 +547,7 @@ func (s *Segment) writeData(file io.Writer, it ItemReader) error {
 }
 
 func (s *Segment) Search(query []uint32, callback func(uint32)) error {
-	if len(query) == 0 && query[0] > s.Meta.MaxTerm {
+	if len(query) == 0 || len(s.blockIndex) == 0 || query[0] > s.Meta.MaxTerm {
 		return nil
 	}
 	var buf segmentBlockBuffers
@@ -424,8 +589,9 @@ func (s *Segment) Search(query []uint32, callback func(uint32)) error {
 }
 
 type segmentBlockBuffers struct {
-	data  []byte
-	items []Item
+	data   []byte
+	items  []Item
+	deltas []uint32
 }
 
 func (s *Segment) ReadBlock(i int, buf *segmentBlockBuffers) ([]Item, error) {
@@ -453,6 +619,10 @@ func (s *Segment) ReadBlock(i int, buf *segmentBlockBuffers) ([]Item, error) {
 	}
 	items := buf.items
 
+	if flags&BitPackedBlock != 0 {
+		return s.readBitPackedBlock(i, data, items, buf)
+	}
+
 	ptr := BlockHeaderSize
 
 	lastTerm := s.blockIndex[i]
@@ -494,6 +664,78 @@ func (s *Segment) ReadBlock(i int, buf *segmentBlockBuffers) ([]Item, error) {
 	return items, nil
 }
 
+func (s *Segment) readBitPackedBlock(i int, data []byte, items []Item, buf *segmentBlockBuffers) ([]Item, error) {
+	if cap(buf.deltas) >= len(items) {
+		buf.deltas = buf.deltas[:len(items)]
+	} else {
+		buf.deltas = make([]uint32, len(items), cap(buf.items))
+	}
+	deltas := buf.deltas
+
+	ptr := BlockHeaderSize + int(binary.LittleEndian.Uint16(data[2:]))
+	if ptr > len(data) {
+		return nil, ErrInvalidBlockData
+	}
+
+	_, err := unpackDeltas(deltas, data[BlockHeaderSize:ptr])
+	if err != nil {
+		return nil, err
+	}
+	lastTerm := s.blockIndex[i]
+	for i, delta := range deltas {
+		lastTerm += delta
+		items[i].Term = lastTerm
+	}
+
+	_, err = unpackDeltas(deltas, data[ptr:])
+	if err != nil {
+		return nil, err
+	}
+	baseDocID := binary.LittleEndian.Uint32(data[4:])
+	lastDocID := baseDocID
+	lastTerm = s.blockIndex[i]
+	for i, delta := range deltas {
+		if lastTerm != items[i].Term {
+			lastTerm = items[i].Term
+			lastDocID = baseDocID
+		}
+		lastDocID += delta
+		items[i].DocID = lastDocID
+	}
+
+	return items, nil
+}
+
+// Verify reads all data of the segment and checks that it matches the metadata.
+func (s *Segment) Verify() error {
+	if s.docs.Len() != s.Meta.NumDocs {
+		return errors.Errorf("expected %v docs, found %v", s.Meta.NumDocs, s.docs.Len())
+	}
+	if s.deletedDocs != nil && s.deletedDocs.Len() != s.Meta.NumDeletedDocs {
+		return errors.Errorf("expected %v deleted docs, found %v", s.Meta.NumDeletedDocs, s.deletedDocs.Len())
+	}
+	var buf segmentBlockBuffers
+	var checksum uint32
+	var numItems int
+	for i := 0; i < s.Meta.NumBlocks; i++ {
+		items, err := s.ReadBlock(i, &buf)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read block %v", i)
+		}
+		for _, item := range items {
+			checksum += item.Term + item.DocID
+		}
+		numItems += len(items)
+	}
+	if numItems != s.Meta.NumItems {
+		return errors.Errorf("expected %v items, found %v", s.Meta.NumItems, numItems)
+	}
+	if checksum != s.Meta.Checksum {
+		return errors.Errorf("checksum mismatch, expected %v, got %v", s.Meta.Checksum, checksum)
+	}
+	return nil
+}
+
 // Contains returns true if the segment contains the given docID.
 func (s *Segment) Contains(docID uint32) bool {
 	if docID < s.Meta.MinDocID || docID > s.Meta.MaxDocID {
@@ -541,6 +783,14 @@ func (s *Segment) DeleteMulti(docs *intset.SparseBitSet) bool {
 	return true
 }
 
+// DeleteRange deletes all docs between min and max, inclusive.
+func (s *Segment) DeleteRange(min, max uint32) bool {
+	if max < s.Meta.MinDocID || min > s.Meta.MaxDocID {
+		return false
+	}
+	return s.DeleteMulti(s.docs.Range(min, max))
+}
+
 func (s *Segment) SaveUpdate(fs vfs.FileSystem, updateID uint32) error {
 	if !s.dirty {
 		return nil
@@ -571,7 +821,6 @@ func (s *Segment) LoadUpdate(fs vfs.FileSystem) error {
 		return errors.Wrap(err, "read failed")
 	}
 
-	log.Printf("loaded update %v for segment %v with %v deleted docs", s.UpdateID, s.ID, s.Meta.NumDeletedDocs)
 	return nil
 }
 
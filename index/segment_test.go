@@ -4,8 +4,11 @@
 package index
 
 import (
+	"fmt"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
 	"testing"
 )
 
@@ -23,3 +26,155 @@ func TestSegment_Reader(t *testing.T) {
 		}
 	}
 }
+
+func TestSegment_Verify(t *testing.T) {
+	var buf ItemBuffer
+	buf.Add(1, []uint32{7, 8, 9})
+	buf.Add(2, []uint32{3, 4, 5})
+
+	segment, err := CreateSegment(vfs.CreateMemDir(), 0, buf.Reader())
+	if assert.NoError(t, err, "failed to create segment") {
+		assert.NoError(t, segment.Verify(), "segment should be valid")
+		segment.Meta.Checksum++
+		assert.Error(t, segment.Verify(), "segment with wrong checksum should be invalid")
+	}
+}
+
+func generateTestItems(numDocs, numTerms int, termRange uint32) *ItemBuffer {
+	r := rand.New(rand.NewSource(0))
+	var buf ItemBuffer
+	terms := make([]uint32, numTerms)
+	for docID := 1; docID <= numDocs; docID++ {
+		for i := range terms {
+			terms[i] = r.Uint32() % termRange
+		}
+		buf.Add(uint32(docID), terms)
+	}
+	return &buf
+}
+
+func TestSegment_Options(t *testing.T) {
+	for _, termRange := range []uint32{1000, 1 << 31} {
+		buf := generateTestItems(100, 100, termRange)
+		expected, err := ReadAllItems(buf.Reader())
+		require.NoError(t, err)
+
+		for _, blockSize := range []int{MinBlockSize, 512, DefaultBlockSize, 4096, MaxBlockSize} {
+			for _, encoding := range []TermEncoding{TermEncodingAuto, TermEncodingVarint, TermEncodingPacked} {
+				opts := &SegmentOptions{BlockSize: blockSize, TermEncoding: encoding}
+				name := fmt.Sprintf("range=%v/%v/%v", termRange, blockSize, encoding)
+
+				segment, err := CreateSegmentWithOptions(vfs.CreateMemDir(), 1, buf.Reader(), opts)
+				require.NoError(t, err, name)
+				assert.Equal(t, blockSize, segment.Meta.BlockSize, name)
+				assert.NoError(t, segment.Verify(), name)
+
+				items, err := ReadAllItems(segment.Reader())
+				require.NoError(t, err, name)
+				assert.Equal(t, expected, items, name)
+
+				var hits []uint32
+				require.NoError(t, segment.Search([]uint32{expected[0].Term}, func(docID uint32) { hits = append(hits, docID) }), name)
+				assert.Contains(t, hits, expected[0].DocID, name)
+			}
+		}
+	}
+}
+
+func TestSegment_InvalidOptions(t *testing.T) {
+	var buf ItemBuffer
+	buf.Add(1, []uint32{7, 8, 9})
+
+	for _, opts := range []*SegmentOptions{
+		{BlockSize: MinBlockSize - 1},
+		{BlockSize: MaxBlockSize + 1},
+		{TermEncoding: TermEncoding(100)},
+	} {
+		_, err := CreateSegmentWithOptions(vfs.CreateMemDir(), 1, buf.Reader(), opts)
+		assert.Error(t, err, "options %+v should be invalid", opts)
+	}
+}
+
+func TestParseTermEncoding(t *testing.T) {
+	for _, encoding := range []TermEncoding{TermEncodingAuto, TermEncodingVarint, TermEncodingPacked} {
+		parsed, err := ParseTermEncoding(encoding.String())
+		require.NoError(t, err)
+		assert.Equal(t, encoding, parsed)
+	}
+	_, err := ParseTermEncoding("foo")
+	assert.Error(t, err)
+}
+
+func benchmarkSegmentSearch(b *testing.B, opts *SegmentOptions) {
+	buf := generateTestItems(10000, 100, 1<<20)
+	segment, err := CreateSegmentWithOptions(vfs.CreateMemDir(), 1, buf.Reader(), opts)
+	require.NoError(b, err)
+
+	r := rand.New(rand.NewSource(1))
+	queries := make([][]uint32, 100)
+	for i := range queries {
+		var query ItemBuffer
+		terms := make([]uint32, 100)
+		for j := range terms {
+			terms[j] = r.Uint32() % (1 << 20)
+		}
+		query.Add(0, terms)
+		items, _ := ReadAllItems(query.Reader())
+		for _, item := range items {
+			queries[i] = append(queries[i], item.Term)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		segment.Search(queries[i%len(queries)], func(uint32) {})
+	}
+}
+
+func BenchmarkSegment_Search512(b *testing.B) {
+	benchmarkSegmentSearch(b, &SegmentOptions{BlockSize: 512})
+}
+
+func BenchmarkSegment_Search1024(b *testing.B) {
+	benchmarkSegmentSearch(b, &SegmentOptions{BlockSize: 1024})
+}
+
+func BenchmarkSegment_Search4096(b *testing.B) {
+	benchmarkSegmentSearch(b, &SegmentOptions{BlockSize: 4096})
+}
+
+func BenchmarkSegment_Search4096Varint(b *testing.B) {
+	benchmarkSegmentSearch(b, &SegmentOptions{BlockSize: 4096, TermEncoding: TermEncodingVarint})
+}
+
+func BenchmarkSegment_Search4096Packed(b *testing.B) {
+	benchmarkSegmentSearch(b, &SegmentOptions{BlockSize: 4096, TermEncoding: TermEncodingPacked})
+}
+
+func benchmarkSegmentReadBlock(b *testing.B, encoding TermEncoding) {
+	buf := generateTestItems(10000, 100, 1<<20)
+	segment, err := CreateSegmentWithOptions(vfs.CreateMemDir(), 1, buf.Reader(), &SegmentOptions{BlockSize: DefaultBlockSize, TermEncoding: encoding})
+	require.NoError(b, err)
+
+	var blockBuf segmentBlockBuffers
+	b.SetBytes(int64(segment.Meta.NumItems * 8 / segment.Meta.NumBlocks))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := segment.ReadBlock(i%segment.Meta.NumBlocks, &blockBuf)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSegment_ReadBlockAuto(b *testing.B) {
+	benchmarkSegmentReadBlock(b, TermEncodingAuto)
+}
+
+func BenchmarkSegment_ReadBlockVarint(b *testing.B) {
+	benchmarkSegmentReadBlock(b, TermEncodingVarint)
+}
+
+func BenchmarkSegment_ReadBlockPacked(b *testing.B) {
+	benchmarkSegmentReadBlock(b, TermEncodingPacked)
+}
@@ -0,0 +1,296 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxFinishedJobs = 100
+
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is a maintenance operation running in the background.
+type Job struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+	Started  time.Time   `json:"started"`
+	Finished *time.Time  `json:"finished,omitempty"`
+}
+
+// jobFunc runs a job and returns its result.
+type jobFunc func() (interface{}, error)
+
+// jobStarter validates a request and prepares a job.
+type jobStarter func(r *http.Request) (jobFunc, error)
+
+// errShuttingDown is returned when a tracked job is started after the job manager was shut down.
+var errShuttingDown = errors.New("server is shutting down")
+
+type jobManager struct {
+	mu       sync.Mutex
+	lastID   int
+	jobs     map[string]*Job
+	finished []string
+	closing  bool
+	tracked  sync.WaitGroup
+}
+
+func newJobManager() *jobManager {
+	return &jobManager{jobs: make(map[string]*Job)}
+}
+
+// start runs fn in a new goroutine and returns a copy of the new job. Tracked jobs are waited for
+// when shutting down and can't be started once the shutdown begins.
+func (m *jobManager) start(typ string, fn jobFunc, track bool) (Job, error) {
+	m.mu.Lock()
+	if track {
+		if m.closing {
+			m.mu.Unlock()
+			return Job{}, errShuttingDown
+		}
+		m.tracked.Add(1)
+	}
+	m.lastID++
+	job := &Job{ID: strconv.Itoa(m.lastID), Type: typ, Status: JobRunning, Started: time.Now()}
+	m.jobs[job.ID] = job
+	started := *job
+	m.mu.Unlock()
+
+	log.Printf("started %v job %v", typ, job.ID)
+	go func() {
+		if track {
+			defer m.tracked.Done()
+		}
+		result, err := fn()
+		m.finish(job, result, err)
+	}()
+	return started, nil
+}
+
+// shutdown rejects new tracked jobs and waits for the running ones to finish.
+func (m *jobManager) shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.tracked.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *jobManager) finish(job *Job, result interface{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	job.Finished = &now
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+		log.Printf("%v job %v failed: %v", job.Type, job.ID, err)
+	} else {
+		job.Status = JobDone
+		job.Result = result
+		log.Printf("%v job %v finished in %s", job.Type, job.ID, now.Sub(job.Started))
+	}
+
+	m.finished = append(m.finished, job.ID)
+	for len(m.finished) > maxFinishedJobs {
+		delete(m.jobs, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+}
+
+func (m *jobManager) get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, exists := m.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (m *jobManager) list() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// requireToken only passes requests with the given bearer token in the Authorization header.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(auth[len("Bearer "):]), []byte(token)) != 1 {
+			writeErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func registerAdminHandlers(r *mux.Router, db *index.DB, opts *Options, jobs *jobManager) {
+	admin := mux.NewRouter()
+	admin.Path("/admin/compact").Methods("POST").Handler(&AdminJobHandler{jobs: jobs, typ: "compact", run: compactJob(db)})
+	admin.Path("/admin/force-merge").Methods("POST").Handler(&AdminJobHandler{jobs: jobs, typ: "force-merge", run: forceMergeJob(db)})
+	admin.Path("/admin/expunge-deletes").Methods("POST").Handler(&AdminJobHandler{jobs: jobs, typ: "expunge-deletes", run: expungeDeletesJob(db)})
+	admin.Path("/admin/checkpoint").Methods("POST").Handler(&AdminJobHandler{jobs: jobs, typ: "checkpoint", run: checkpointJob(db, opts.CheckpointDir), track: true})
+	admin.Path("/admin/merge").Methods("GET").Handler(&MergeProgressHandler{db: db})
+	admin.Path("/admin/jobs").Methods("GET").Handler(&JobsHandler{jobs: jobs})
+	admin.Path("/admin/jobs/{id:[0-9]+}").Methods("GET").Handler(&JobHandler{jobs: jobs})
+	r.PathPrefix("/admin/").Handler(requireToken(opts.AdminToken, admin))
+}
+
+// AdminJobHandler starts a maintenance job and returns it with status 202 Accepted.
+// The job can be then polled using JobHandler. Tracked jobs are waited for when the server is shutting down,
+// which is needed for jobs that use the database without going through its own shutdown handling.
+type AdminJobHandler struct {
+	jobs  *jobManager
+	typ   string
+	run   jobStarter
+	track bool
+}
+
+func (h *AdminJobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fn, err := h.run(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job, err := h.jobs.start(h.typ, fn, h.track)
+	if err != nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeResponse(w, http.StatusAccepted, map[string]interface{}{"job": job})
+}
+
+func compactJob(db *index.DB) jobStarter {
+	return func(r *http.Request) (jobFunc, error) {
+		return func() (interface{}, error) { return nil, db.Compact() }, nil
+	}
+}
+
+func forceMergeJob(db *index.DB) jobStarter {
+	return func(r *http.Request) (jobFunc, error) {
+		var input struct {
+			MaxSegments int `json:"max_segments"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid body: %v", err)
+		}
+		if input.MaxSegments < 0 {
+			return nil, fmt.Errorf("invalid max_segments")
+		}
+		if input.MaxSegments == 0 {
+			input.MaxSegments = 1
+		}
+		return func() (interface{}, error) { return nil, db.ForceMerge(input.MaxSegments) }, nil
+	}
+}
+
+func expungeDeletesJob(db *index.DB) jobStarter {
+	return func(r *http.Request) (jobFunc, error) {
+		return func() (interface{}, error) { return nil, db.ExpungeDeletes() }, nil
+	}
+}
+
+func checkpointJob(db *index.DB, dir string) jobStarter {
+	return func(r *http.Request) (jobFunc, error) {
+		if dir == "" {
+			return nil, fmt.Errorf("checkpoints are not enabled")
+		}
+		path := filepath.Join(dir, "checkpoint-"+time.Now().UTC().Format("20060102T150405.000000000"))
+		return func() (interface{}, error) {
+			fs, err := vfs.OpenDir(path, true)
+			if err != nil {
+				return nil, err
+			}
+			defer fs.Close()
+			txid, err := db.Checkpoint(fs)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"txid": txid, "path": path}, nil
+		}, nil
+	}
+}
+
+type JobHandler struct {
+	jobs *jobManager
+}
+
+func (h *JobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	job, exists := h.jobs.get(mux.Vars(r)["id"])
+	if !exists {
+		writeErrorResponse(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]interface{}{"job": job})
+}
+
+type JobsHandler struct {
+	jobs *jobManager
+}
+
+func (h *JobsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jobs := h.jobs.list()
+	sortJobs(jobs)
+	writeResponse(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.Atoi(jobs[i].ID)
+		b, _ := strconv.Atoi(jobs[j].ID)
+		return a < b
+	})
+}
+
+// MergeProgressHandler returns the progress of the currently running merge.
+type MergeProgressHandler struct {
+	db *index.DB
+}
+
+func (h *MergeProgressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, map[string]interface{}{"merge": h.db.MergeProgress()})
+}
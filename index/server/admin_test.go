@@ -0,0 +1,161 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func adminRequest(t *testing.T, handler http.Handler, method, path string, body io.Reader) (int, map[string]json.RawMessage) {
+	req := httptest.NewRequest(method, "http://example.com"+path, body)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	var response map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "invalid response %q", w.Body.String())
+	return w.Code, response
+}
+
+func waitForJob(t *testing.T, handler http.Handler, id string) Job {
+	for i := 0; i < 100; i++ {
+		code, response := adminRequest(t, handler, "GET", "/admin/jobs/"+id, nil)
+		require.Equal(t, http.StatusOK, code)
+		var job Job
+		require.NoError(t, json.Unmarshal(response["job"], &job))
+		if job.Status != JobRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %v did not finish", id)
+	return Job{}
+}
+
+func TestAdmin_Unauthorized(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	handler := NewHandler(db, &Options{AdminToken: "secret"})
+
+	req := httptest.NewRequest("POST", "http://example.com/admin/compact", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest("POST", "http://example.com/admin/compact", nil)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code, "admin endpoints should be disabled without a token")
+}
+
+func TestAdmin_ForceMerge(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100})
+	db.Add(2, []uint32{100})
+	db.Add(3, []uint32{100})
+	db.Delete(2)
+
+	handler := NewHandler(db, &Options{AdminToken: "secret"})
+
+	code, response := adminRequest(t, handler, "POST", "/admin/force-merge", strings.NewReader(`{"max_segments": 1}`))
+	require.Equal(t, http.StatusAccepted, code)
+	var job Job
+	require.NoError(t, json.Unmarshal(response["job"], &job))
+	require.Equal(t, "force-merge", job.Type)
+
+	job = waitForJob(t, handler, job.ID)
+	require.Equal(t, JobDone, job.Status, job.Error)
+	require.Equal(t, 1, db.NumSegments())
+
+	code, response = adminRequest(t, handler, "POST", "/admin/expunge-deletes", nil)
+	require.Equal(t, http.StatusAccepted, code)
+	require.NoError(t, json.Unmarshal(response["job"], &job))
+	job = waitForJob(t, handler, job.ID)
+	require.Equal(t, JobDone, job.Status, job.Error)
+	require.Equal(t, 0, db.NumDeletedDocs())
+
+	code, response = adminRequest(t, handler, "GET", "/admin/jobs", nil)
+	require.Equal(t, http.StatusOK, code)
+	var jobs []Job
+	require.NoError(t, json.Unmarshal(response["jobs"], &jobs))
+	require.Len(t, jobs, 2)
+	require.Equal(t, "expunge-deletes", jobs[1].Type)
+
+	code, response = adminRequest(t, handler, "GET", "/admin/merge", nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "null", string(response["merge"]))
+
+	code, _ = adminRequest(t, handler, "GET", "/admin/jobs/1000", nil)
+	require.Equal(t, http.StatusNotFound, code)
+}
+
+func TestAdmin_Checkpoint(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100})
+
+	code, _ := adminRequest(t, NewHandler(db, &Options{AdminToken: "secret"}), "POST", "/admin/checkpoint", nil)
+	require.Equal(t, http.StatusBadRequest, code, "checkpoints should be disabled without a directory")
+
+	dir, err := ioutil.TempDir("", "checkpoints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	handler := NewHandler(db, &Options{AdminToken: "secret", CheckpointDir: dir})
+	code, response := adminRequest(t, handler, "POST", "/admin/checkpoint", nil)
+	require.Equal(t, http.StatusAccepted, code)
+	var job Job
+	require.NoError(t, json.Unmarshal(response["job"], &job))
+	job = waitForJob(t, handler, job.ID)
+	require.Equal(t, JobDone, job.Status, job.Error)
+
+	path := job.Result.(map[string]interface{})["path"].(string)
+	fs, err := vfs.OpenDir(path, false)
+	require.NoError(t, err)
+	db2, err := index.Open(fs, false, nil)
+	require.NoError(t, err)
+	defer db2.Close()
+	require.True(t, db2.Contains(1))
+}
+
+func TestJobManager_Shutdown(t *testing.T) {
+	jobs := newJobManager()
+	release := make(chan struct{})
+	_, err := jobs.start("checkpoint", func() (interface{}, error) {
+		<-release
+		return nil, nil
+	}, true)
+	require.NoError(t, err, "failed to start job")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, jobs.shutdown(ctx), "shutdown should wait for the tracked job")
+
+	_, err = jobs.start("checkpoint", func() (interface{}, error) { return nil, nil }, true)
+	require.Equal(t, errShuttingDown, err, "tracked jobs should be rejected after shutdown")
+	_, err = jobs.start("compact", func() (interface{}, error) { return nil, nil }, false)
+	require.NoError(t, err, "untracked jobs should be still allowed")
+
+	close(release)
+	require.NoError(t, jobs.shutdown(context.Background()))
+}
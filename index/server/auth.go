@@ -0,0 +1,185 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type accessScope int
+
+const (
+	scopeRead accessScope = iota
+	scopeWrite
+)
+
+// accessControl authenticates API requests and limits their rate.
+type accessControl struct {
+	readKeys  []string
+	writeKeys []string
+	limiter   *rateLimiter
+}
+
+func newAccessControl(opts *Options) *accessControl {
+	a := &accessControl{
+		readKeys:  append(append([]string(nil), opts.ReadKeys...), opts.WriteKeys...),
+		writeKeys: opts.WriteKeys,
+	}
+	if opts.RateLimit > 0 {
+		a.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	return a
+}
+
+func (a *accessControl) authEnabled() bool {
+	return len(a.readKeys) > 0
+}
+
+// wrap returns a handler that only passes requests with an API key valid for the scope and within the rate limit.
+func (a *accessControl) wrap(scope accessScope, next http.Handler) http.Handler {
+	if !a.authEnabled() && a.limiter == nil {
+		return next
+	}
+	keys := a.readKeys
+	if scope == scopeWrite {
+		keys = a.writeKeys
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests which fail authentication are limited by the client address, so that keys can't be guessed
+		// faster than the rate limit allows.
+		client := "addr:" + remoteHost(r)
+		status, message := 0, ""
+		if a.authEnabled() {
+			key := bearerToken(r)
+			if key == "" || !containsKey(a.readKeys, key) {
+				status, message = http.StatusUnauthorized, "unauthorized"
+			} else {
+				client = "key:" + key
+				if !containsKey(keys, key) {
+					status, message = http.StatusForbidden, "forbidden"
+				}
+			}
+		}
+		if a.limiter != nil {
+			ok, wait := a.limiter.allow(client)
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeErrorResponse(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+		}
+		if status != 0 {
+			writeErrorResponse(w, status, message)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return auth[len("Bearer "):]
+}
+
+// containsKey checks all keys in constant time, so that the response time does not reveal partial matches.
+func containsKey(keys []string, key string) bool {
+	found := 0
+	for _, k := range keys {
+		found |= subtle.ConstantTimeCompare([]byte(k), []byte(key))
+	}
+	return found == 1
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitPruneInterval is how often idle clients are removed from the rate limiter.
+const rateLimitPruneInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter implements a token bucket for each client. Buckets are refilled with rate tokens
+// per second, up to burst tokens, and every request takes one token.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the client's bucket. If the bucket is empty, it returns false
+// and the time after which a token will be available.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.prune(now)
+		l.lastPrune = now
+	}
+
+	b, exists := l.buckets[client]
+	if !exists {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+}
+
+// prune removes full buckets, they are recreated in the same state when needed.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
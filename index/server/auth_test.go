@@ -0,0 +1,102 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func authRequest(handler http.Handler, key, method, path, body string) int {
+	req := httptest.NewRequest(method, "http://example.com"+path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestHandler_Auth(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	handler := NewHandler(db, &Options{ReadKeys: []string{"reader"}, WriteKeys: []string{"writer"}})
+
+	assert.Equal(t, http.StatusUnauthorized, authRequest(handler, "", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusUnauthorized, authRequest(handler, "foo", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "reader", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "writer", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "reader", "POST", "/search", `{"terms":[1,2,3]}`))
+
+	assert.Equal(t, http.StatusUnauthorized, authRequest(handler, "", "DELETE", "/index", ""))
+	assert.Equal(t, http.StatusForbidden, authRequest(handler, "reader", "DELETE", "/index", ""))
+	assert.Equal(t, http.StatusForbidden, authRequest(handler, "reader", "PUT", "/index/1", `{"terms":[1,2,3]}`))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "writer", "PUT", "/index/1", `{"terms":[1,2,3]}`))
+	assert.Equal(t, 1, db.NumDocs())
+
+	assert.Equal(t, http.StatusOK, authRequest(handler, "", "GET", "/health", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "", "GET", "/ready", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "", "GET", "/metrics", ""))
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	handler := NewHandler(db, &Options{ReadKeys: []string{"a", "b"}, RateLimit: 0.001, RateBurst: 2})
+
+	assert.Equal(t, http.StatusOK, authRequest(handler, "a", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "a", "GET", "/attributes", ""))
+	assert.Equal(t, http.StatusTooManyRequests, authRequest(handler, "a", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusOK, authRequest(handler, "b", "GET", "/stats", ""), "keys should have separate limits")
+}
+
+func TestHandler_RateLimitUnauthorized(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	handler := NewHandler(db, &Options{ReadKeys: []string{"a"}, RateLimit: 0.001, RateBurst: 2})
+
+	assert.Equal(t, http.StatusUnauthorized, authRequest(handler, "x", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusUnauthorized, authRequest(handler, "y", "GET", "/stats", ""))
+	assert.Equal(t, http.StatusTooManyRequests, authRequest(handler, "z", "GET", "/stats", ""), "invalid keys should be limited by address")
+	assert.Equal(t, http.StatusTooManyRequests, authRequest(handler, "", "GET", "/stats", ""), "missing keys should be limited by address")
+	assert.Equal(t, http.StatusOK, authRequest(handler, "a", "GET", "/stats", ""), "valid keys should have separate limits")
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("x")
+		require.True(t, ok, "request %v should be allowed by the burst", i)
+	}
+	ok, wait := l.allow("x")
+	require.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.allow("x")
+	assert.True(t, ok, "one token should be refilled")
+	ok, _ = l.allow("x")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	ok, _ = l.allow("y")
+	assert.True(t, ok)
+	assert.Len(t, l.buckets, 1, "idle clients should be pruned")
+}
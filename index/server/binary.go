@@ -0,0 +1,189 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/index/proto"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"io"
+	"log"
+	"net"
+)
+
+// ServeBinary accepts connections on l and handles them using the binary protocol from the proto package.
+// Each connection has its own transaction, which is aborted if the connection is closed without committing.
+func ServeBinary(l net.Listener, db *index.DB) error {
+	return serveBinary(l, db, nil)
+}
+
+func serveBinary(l net.Listener, db *index.DB, conns *connTracker) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go handleBinaryConn(conn, db, conns)
+	}
+}
+
+func ListenAndServeBinary(addr string, db *index.DB) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return ServeBinary(l, db)
+}
+
+type binaryConn struct {
+	db    *index.DB
+	txn   index.Batch
+	terms []uint32
+	resp  proto.Encoder
+}
+
+func handleBinaryConn(conn net.Conn, db *index.DB, conns *connTracker) {
+	defer conn.Close()
+
+	if !conns.add(conn) {
+		return
+	}
+	defer conns.remove(conn)
+
+	c := &binaryConn{db: db}
+	defer c.abort()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	var buf []byte
+	for {
+		if !conns.next(conn, c.txn != nil) {
+			writer.Flush()
+			return
+		}
+		payload, err := proto.ReadFrame(reader, buf)
+		if err != nil {
+			if err != io.EOF && !isTimeout(err) {
+				log.Printf("failed to read request from %v: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		buf = payload[:0]
+
+		c.handle(proto.NewDecoder(payload))
+
+		err = proto.WriteFrame(writer, c.resp.Bytes())
+		if err == nil && reader.Buffered() == 0 {
+			// Only flush when there are no more pipelined requests waiting.
+			err = writer.Flush()
+		}
+		if err != nil {
+			log.Printf("failed to send response to %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+func (c *binaryConn) handle(req *proto.Decoder) {
+	var err error
+	switch op := req.Code(); op {
+	case proto.OpAdd:
+		docID := req.Uint32()
+		c.terms = req.Uint32s(c.terms[:0])
+		if err = req.Err(); err != nil {
+			break
+		}
+		err = c.withTransaction(func(txn index.Batch) error { return txn.Add(docID, c.terms) })
+	case proto.OpDelete:
+		docID := req.Uint32()
+		if err = req.Err(); err != nil {
+			break
+		}
+		err = c.withTransaction(func(txn index.Batch) error { return txn.Delete(docID) })
+	case proto.OpDeleteMany:
+		c.terms = req.Uint32s(c.terms[:0])
+		if err = req.Err(); err != nil {
+			break
+		}
+		docs := intset.NewSparseBitSet(0)
+		for _, docID := range c.terms {
+			docs.Add(docID)
+		}
+		err = c.withTransaction(func(txn index.Batch) error { return txn.DeleteMany(docs) })
+	case proto.OpDeleteRange:
+		min, max := req.Uint32(), req.Uint32()
+		if err = req.Err(); err != nil {
+			break
+		}
+		err = c.withTransaction(func(txn index.Batch) error { return txn.DeleteRange(min, max) })
+	case proto.OpSearch:
+		c.terms = req.Uint32s(c.terms[:0])
+		if err = req.Err(); err != nil {
+			break
+		}
+		var hits map[uint32]int
+		hits, err = c.db.Search(c.terms)
+		if err != nil {
+			log.Printf("search failed: %v", err)
+			break
+		}
+		c.resp.Reset(proto.StatusOK)
+		c.resp.PutUint32(uint32(len(hits)))
+		for docID, count := range hits {
+			c.resp.PutUint32(docID)
+			c.resp.PutUint32(uint32(count))
+		}
+		return
+	case proto.OpCommit:
+		if err = req.Err(); err != nil {
+			break
+		}
+		err = c.commit()
+	default:
+		err = fmt.Errorf("unknown operation %v", op)
+	}
+
+	if err != nil {
+		c.resp.Reset(proto.StatusError)
+		c.resp.PutString(err.Error())
+		return
+	}
+	c.resp.Reset(proto.StatusOK)
+}
+
+func (c *binaryConn) withTransaction(fn func(txn index.Batch) error) error {
+	if c.txn == nil {
+		txn, err := c.db.Transaction()
+		if err != nil {
+			return err
+		}
+		c.txn = txn
+	}
+	return fn(c.txn)
+}
+
+func (c *binaryConn) commit() error {
+	if c.txn == nil {
+		return nil
+	}
+	txn := c.txn
+	c.txn = nil
+	defer txn.Close()
+	err := txn.Commit()
+	if err != nil {
+		log.Printf("commit failed: %v", err)
+	}
+	return err
+}
+
+func (c *binaryConn) abort() {
+	if c.txn != nil {
+		c.txn.Close()
+		c.txn = nil
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"encoding/json"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/pkg/errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Follower maintains a local copy of an index served by another server.
+type Follower struct {
+	// URL is the base URL of the leader server.
+	URL string
+
+	// Client is the HTTP client used for talking to the leader server.
+	Client *http.Client
+
+	// APIKey is sent to the leader server in the Authorization header, if not empty.
+	APIKey string
+
+	fs vfs.FileSystem
+}
+
+// NewFollower creates a new Follower that will replicate the index from url into fs.
+func NewFollower(url string, fs vfs.FileSystem) *Follower {
+	return &Follower{
+		URL:    strings.TrimSuffix(url, "/"),
+		Client: http.DefaultClient,
+		fs:     fs,
+	}
+}
+
+// Sync downloads the current manifest from the leader together with all files that are missing locally
+// and atomically installs the manifest. Returns true if a new manifest was installed.
+func (f *Follower) Sync() (bool, error) {
+	var current index.Manifest
+	err := current.Load(f.fs, false)
+	if err != nil && !vfs.IsNotExist(errors.Cause(err)) {
+		return false, errors.Wrap(err, "failed to load the local manifest")
+	}
+
+	manifest, err := f.fetchManifest()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to fetch the manifest")
+	}
+
+	if manifest.ID <= current.ID {
+		return false, nil
+	}
+
+	for _, segment := range manifest.Segments {
+		err = f.syncSegment(segment)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to sync segment %v", segment.ID)
+		}
+	}
+
+	err = manifest.Save(f.fs)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to save the manifest")
+	}
+
+	log.Printf("installed manifest %d from %v (docs=%v, items=%v, segments=%v, checksum=%d)",
+		manifest.ID, f.URL, manifest.NumDocs-manifest.NumDeletedDocs, manifest.NumItems, len(manifest.Segments), manifest.Checksum)
+
+	f.removeUnusedFiles(manifest)
+	return true, nil
+}
+
+// Run periodically syncs the local copy of the index and refreshes db, until stop is closed.
+// The db must be opened in read-only mode on the same filesystem as the follower.
+func (f *Follower) Run(db *index.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			updated, err := f.Sync()
+			if err != nil {
+				log.Printf("sync with %v failed: %v", f.URL, err)
+				continue
+			}
+			if updated {
+				err = db.Refresh()
+				if err != nil {
+					log.Printf("refresh failed: %v", err)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (f *Follower) fetchManifest() (*index.Manifest, error) {
+	resp, err := f.get("/manifest")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %v", resp.Status)
+	}
+
+	var manifest index.Manifest
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode failed")
+	}
+	return &manifest, nil
+}
+
+func (f *Follower) syncSegment(segment *index.Segment) error {
+	var downloaded []string
+	for _, name := range segment.FileNames() {
+		file, err := f.fs.OpenFile(name)
+		if err == nil {
+			file.Close()
+			continue
+		}
+		if !vfs.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to open file %q", name)
+		}
+		err = f.download(name)
+		if err != nil {
+			f.removeFiles(downloaded)
+			return errors.Wrapf(err, "failed to download file %q", name)
+		}
+		downloaded = append(downloaded, name)
+	}
+
+	if len(downloaded) == 0 {
+		return nil
+	}
+
+	s := segment.Clone()
+	err := s.Open(f.fs)
+	if err == nil {
+		err = s.Verify()
+		s.Close()
+	}
+	if err != nil {
+		f.removeFiles(downloaded)
+		return errors.Wrap(err, "verification failed")
+	}
+
+	return nil
+}
+
+func (f *Follower) download(name string) error {
+	resp, err := f.get("/files/" + name)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %v", resp.Status)
+	}
+
+	file, err := f.fs.CreateAtomicFile(name)
+	if err != nil {
+		return errors.Wrap(err, "create failed")
+	}
+	defer file.Close()
+
+	n, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return errors.Errorf("expected %v bytes, got %v", resp.ContentLength, n)
+	}
+
+	err = file.Commit()
+	if err != nil {
+		return errors.Wrap(err, "commit failed")
+	}
+
+	log.Printf("downloaded file %q (size=%v)", name, n)
+	return nil
+}
+
+func (f *Follower) get(path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", f.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.APIKey)
+	}
+	return f.Client.Do(req)
+}
+
+func (f *Follower) removeFiles(names []string) {
+	for _, name := range names {
+		err := f.fs.Remove(name)
+		if err != nil {
+			log.Printf("[ERROR] failed to delete file %q: %v", name, err)
+		}
+	}
+}
+
+// removeUnusedFiles deletes segment files that are not referenced by the manifest anymore.
+func (f *Follower) removeUnusedFiles(manifest *index.Manifest) {
+	used := make(map[string]struct{})
+	for _, segment := range manifest.Segments {
+		for _, name := range segment.FileNames() {
+			used[name] = struct{}{}
+		}
+	}
+
+	entries, err := f.fs.ReadDir()
+	if err != nil {
+		log.Printf("[ERROR] failed to list files: %v", err)
+		return
+	}
+
+	var unused []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "segment-") {
+			continue
+		}
+		if _, exists := used[name]; !exists {
+			unused = append(unused, name)
+		}
+	}
+	f.removeFiles(unused)
+}
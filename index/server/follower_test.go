@@ -0,0 +1,110 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFollower(t *testing.T) {
+	leader, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create leader db")
+	defer leader.Close()
+
+	leaderServer := httptest.NewServer(Handler(leader))
+	defer leaderServer.Close()
+
+	require.NoError(t, leader.Add(1, []uint32{7, 8, 9}), "add failed")
+	require.NoError(t, leader.Add(2, []uint32{3, 4, 5}), "add failed")
+
+	fs := vfs.CreateMemDir()
+	follower := NewFollower(leaderServer.URL, fs)
+
+	updated, err := follower.Sync()
+	require.NoError(t, err, "initial sync failed")
+	require.True(t, updated, "initial sync should install a manifest")
+
+	opts := *index.DefaultOptions
+	opts.ReadOnly = true
+	db, err := index.Open(fs, false, &opts)
+	require.NoError(t, err, "failed to open follower db")
+	defer db.Close()
+
+	followerServer := httptest.NewServer(Handler(db))
+	defer followerServer.Close()
+
+	hits, err := db.Search([]uint32{3, 4, 5, 7, 8, 9})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 3, 2: 3}, hits)
+
+	updated, err = follower.Sync()
+	require.NoError(t, err, "sync failed")
+	require.False(t, updated, "nothing should be installed if the leader did not change")
+
+	require.NoError(t, leader.Delete(1), "delete failed")
+	require.NoError(t, leader.Add(3, []uint32{1, 2}), "add failed")
+
+	updated, err = follower.Sync()
+	require.NoError(t, err, "sync failed")
+	require.True(t, updated, "sync should install the new manifest")
+	require.NoError(t, db.Refresh(), "refresh failed")
+
+	hits, err = db.Search([]uint32{1, 2, 3, 4, 5, 7, 8, 9})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{2: 3, 3: 2}, hits)
+
+	follower2 := NewFollower(followerServer.URL, vfs.CreateMemDir())
+	updated, err = follower2.Sync()
+	require.NoError(t, err, "sync from follower failed")
+	require.True(t, updated, "sync from follower should install a manifest")
+}
+
+func TestFileHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100})
+
+	for _, segment := range db.Manifest().Segments {
+		req := httptest.NewRequest("GET", "http://example.com/files/"+segment.FileNames()[0], nil)
+		w := httptest.NewRecorder()
+		Handler(db).ServeHTTP(w, req)
+		require.Equal(t, 200, w.Code, "status code should be 200 OK")
+		require.NotEmpty(t, w.Body.Bytes())
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com/files/segment-999.dat", nil)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 404, w.Code, "status code should be 404 Not Found")
+
+	req = httptest.NewRequest("GET", "http://example.com/files/manifest.json", nil)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 404, w.Code, "status code should be 404 Not Found")
+}
+
+func TestFollower_APIKey(t *testing.T) {
+	leader, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create leader db")
+	defer leader.Close()
+	require.NoError(t, leader.Add(1, []uint32{7, 8, 9}), "add failed")
+
+	leaderServer := httptest.NewServer(NewHandler(leader, &Options{ReadKeys: []string{"secret"}}))
+	defer leaderServer.Close()
+
+	follower := NewFollower(leaderServer.URL, vfs.CreateMemDir())
+	_, err = follower.Sync()
+	require.Error(t, err, "sync without the API key should fail")
+
+	follower.APIKey = "secret"
+	updated, err := follower.Sync()
+	require.NoError(t, err, "sync failed")
+	require.True(t, updated)
+}
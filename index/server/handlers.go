@@ -4,10 +4,15 @@
 package server
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/acoustid/go-acoustid/util/metrics"
+	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 	"io"
 	"log"
 	"net/http"
@@ -98,12 +103,9 @@ func (h *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer bulk.Close()
 
-	var input struct {
-		DocID uint32   `json:"id"`
-		Terms []uint32 `json:"terms"`
-	}
 	decoder := json.NewDecoder(r.Body)
 	for {
+		var input addOp
 		err = decoder.Decode(&input)
 		if err == io.EOF {
 			break
@@ -112,6 +114,56 @@ func (h *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			writeErrorResponse(w, 400, fmt.Sprintf("invalid body: %v", err))
 			return
 		}
+		if len(input.Items) > 0 {
+			reader := &importReader{decoder: decoder, items: input.Items}
+			err = bulk.Import(reader)
+			if reader.err != nil {
+				writeErrorResponse(w, 400, fmt.Sprintf("invalid body: %v", reader.err))
+				return
+			}
+			if err != nil {
+				log.Printf("import failed: %v", err)
+				writeErrorResponse(w, 500, fmt.Sprintf("import failed: %v", err))
+				return
+			}
+			continue
+		}
+		if input.Items != nil {
+			continue
+		}
+		if input.Attributes != nil {
+			for name, value := range input.Attributes {
+				err = bulk.(*index.Transaction).SetAttribute(name, value)
+				if err != nil {
+					writeErrorResponse(w, 400, fmt.Sprintf("invalid attribute: %v", err))
+					return
+				}
+			}
+			continue
+		}
+		if input.DeleteIDs != nil || input.DeleteRanges != nil {
+			err = validateRanges(input.DeleteRanges)
+			if err != nil {
+				writeErrorResponse(w, 400, err.Error())
+				return
+			}
+			err = deleteDocs(bulk, input.DeleteIDs, input.DeleteRanges)
+			if err != nil {
+				log.Printf("delete failed: %v", err)
+				writeErrorResponse(w, 500, fmt.Sprintf("delete failed: %v", err))
+				return
+			}
+			continue
+		}
+		if input.Delete {
+			err = bulk.Delete(input.DocID)
+			if err != nil {
+				log.Printf("delete failed: %v", err)
+				writeErrorResponse(w, 500, fmt.Sprintf("delete failed: %v", err))
+				return
+			}
+			continue
+		}
 		err = bulk.Add(input.DocID, input.Terms)
 		if err != nil {
 			log.Printf("add failed: %v", err)
@@ -131,6 +183,129 @@ func (h *AddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, http.StatusOK, Response{})
 }
 
+// addOp is one line of the NDJSON body of AddHandler.
+type addOp struct {
+	DocID        uint32            `json:"id"`
+	Terms        []uint32          `json:"terms"`
+	Delete       bool              `json:"delete"`
+	DeleteIDs    []uint32          `json:"delete_ids"`
+	DeleteRanges []docIDRange      `json:"delete_ranges"`
+	Attributes   map[string]string `json:"attributes"`
+	Items        [][2]uint32       `json:"items"`
+}
+
+// importReader reads blocks of (term, docID) pairs sent as consecutive items lines in the body of AddHandler.
+// The import ends with a line containing an empty items block. The items must be sorted by term and docID,
+// across all blocks, otherwise the import fails.
+type importReader struct {
+	decoder *json.Decoder
+	items   [][2]uint32
+	block   []index.Item
+	last    index.Item
+	started bool
+	err     error
+}
+
+func (r *importReader) ReadBlock() ([]index.Item, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.items == nil {
+		var input addOp
+		err := r.decoder.Decode(&input)
+		if err == io.EOF || (err == nil && input.Items == nil) {
+			err = errors.New("unterminated import")
+		}
+		if err != nil {
+			r.err = err
+			return nil, err
+		}
+		if len(input.Items) == 0 {
+			return nil, io.EOF
+		}
+		r.items = input.Items
+	}
+	r.block = r.block[:0]
+	for _, pair := range r.items {
+		item := index.Item{Term: pair[0], DocID: pair[1]}
+		if r.started && (item.Term < r.last.Term || (item.Term == r.last.Term && item.DocID < r.last.DocID)) {
+			r.err = errors.Errorf("item %v %v is out of order", item.Term, item.DocID)
+			return nil, r.err
+		}
+		r.block = append(r.block, item)
+		r.last = item
+		r.started = true
+	}
+	r.items = nil
+	return r.block, nil
+}
+
+type docIDRange struct {
+	Min uint32 `json:"min"`
+	Max uint32 `json:"max"`
+}
+
+// validateRanges checks that all ranges have the lower bound not greater than the upper bound.
+func validateRanges(ranges []docIDRange) error {
+	for _, r := range ranges {
+		if r.Min > r.Max {
+			return errors.Errorf("invalid range %v-%v", r.Min, r.Max)
+		}
+	}
+	return nil
+}
+
+func deleteDocs(bulk index.Batch, ids []uint32, ranges []docIDRange) error {
+	if len(ids) > 0 {
+		docs := intset.NewSparseBitSet(0)
+		for _, docID := range ids {
+			docs.Add(docID)
+		}
+		err := bulk.DeleteMany(docs)
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range ranges {
+		err := bulk.DeleteRange(r.Min, r.Max)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type DeleteManyHandler struct {
+	db *index.DB
+}
+
+func (h *DeleteManyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		IDs    []uint32     `json:"ids"`
+		Ranges []docIDRange `json:"ranges"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		writeErrorResponse(w, 400, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+	err = validateRanges(input.Ranges)
+	if err != nil {
+		writeErrorResponse(w, 400, err.Error())
+		return
+	}
+
+	err = h.db.RunInTransaction(func(txn index.Batch) error { return deleteDocs(txn, input.IDs, input.Ranges) })
+	if err != nil {
+		log.Printf("delete failed: %v", err)
+		writeErrorResponse(w, 500, "internal error")
+		return
+	}
+
+	type Response struct{}
+	writeResponse(w, http.StatusOK, Response{})
+}
+
 type StatsHandler struct {
 	db *index.DB
 }
@@ -148,3 +323,188 @@ func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	writeResponse(w, http.StatusOK, response)
 }
+
+// SegmentStatsHandler returns detailed stats of the database, including all segments.
+type SegmentStatsHandler struct {
+	db *index.DB
+}
+
+func (h *SegmentStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, h.db.Stats())
+}
+
+// MetricsHandler exports metrics of the database in the Prometheus text format.
+type MetricsHandler struct {
+	registry *metrics.Registry
+}
+
+func NewMetricsHandler(db *index.DB) *MetricsHandler {
+	registry := metrics.NewRegistry()
+	db.RegisterMetrics(registry)
+	return &MetricsHandler{registry: registry}
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err := h.registry.WriteText(w)
+	if err != nil {
+		log.Printf("failed to write metrics: %v", err)
+	}
+}
+
+type SearchHandler struct {
+	db *index.DB
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Terms []uint32 `json:"terms"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		writeErrorResponse(w, 400, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+
+	hits, err := h.db.Search(input.Terms)
+	if err != nil {
+		log.Printf("search failed: %v", err)
+		writeErrorResponse(w, 500, "internal error")
+		return
+	}
+
+	type Response struct {
+		Hits map[uint32]int `json:"hits"`
+	}
+	writeResponse(w, http.StatusOK, Response{Hits: hits})
+}
+
+type ItemsHandler struct {
+	db *index.DB
+}
+
+func (h *ItemsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := h.db.Snapshot()
+	defer snapshot.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writer := bufio.NewWriter(w)
+	reader := snapshot.Reader()
+	for reader != nil {
+		items, err := reader.ReadBlock()
+		for _, item := range items {
+			fmt.Fprintf(writer, "%d %d\n", item.Term, item.DocID)
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("failed to read items: %v", err)
+			}
+			break
+		}
+	}
+	err := writer.Flush()
+	if err != nil {
+		log.Printf("failed to send items: %v", err)
+	}
+}
+
+type AttributesHandler struct {
+	db *index.DB
+}
+
+func (h *AttributesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	attrs := h.db.Manifest().Attributes
+	if attrs == nil {
+		attrs = map[string]string{}
+	}
+	type Response struct {
+		Attributes map[string]string `json:"attributes"`
+	}
+	writeResponse(w, http.StatusOK, Response{Attributes: attrs})
+}
+
+type SetAttributeHandler struct {
+	db *index.DB
+}
+
+func (h *SetAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var input struct {
+		Value string `json:"value"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		writeErrorResponse(w, 400, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+
+	err = h.db.SetAttribute(name, input.Value)
+	if err != nil {
+		log.Printf("failed to set attribute %q: %v", name, err)
+		writeErrorResponse(w, 500, "internal error")
+		return
+	}
+
+	type Response struct{}
+	writeResponse(w, http.StatusOK, Response{})
+}
+
+type ManifestHandler struct {
+	db *index.DB
+}
+
+func (h *ManifestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, h.db.Manifest())
+}
+
+type FileHandler struct {
+	db *index.DB
+}
+
+func (h *FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	file, err := h.db.OpenFile(name)
+	if err != nil {
+		if vfs.IsNotExist(err) {
+			writeErrorResponse(w, http.StatusNotFound, "file not found")
+			return
+		}
+		log.Printf("failed to open file %q: %v", name, err)
+		writeErrorResponse(w, 500, "internal error")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size(), 10))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(w, file)
+	if err != nil {
+		log.Printf("failed to send file %q: %v", name, err)
+	}
+}
+
+// HealthHandler reports that the server is running.
+type HealthHandler struct{}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadyHandler reports whether the server is ready to handle requests. It is not ready while shutting down.
+type ReadyHandler struct {
+	ready func() bool
+}
+
+func (h *ReadyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.ready != nil && !h.ready() {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	writeResponse(w, http.StatusOK, map[string]string{"status": "ready"})
+}
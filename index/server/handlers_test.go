@@ -5,11 +5,13 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/acoustid/go-acoustid/index"
 	"github.com/acoustid/go-acoustid/util/vfs"
 	"github.com/stretchr/testify/require"
 	"log"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -104,6 +106,59 @@ func TestAddHandler(t *testing.T) {
 	require.Equal(t, 0, db.NumDeletedDocs())
 }
 
+func TestAddHandler_Import(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	var body bytes.Buffer
+	body.WriteString(`{"items": [[1,1],[2,1],[2,2]]}`)
+	body.WriteString(`{"items": [[3,2]]}`)
+	body.WriteString(`{"items": []}`)
+	body.WriteString(`{"id": 3, "terms": [1]}`)
+
+	req := httptest.NewRequest("POST", "http://example.com/index", &body)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.Equal(t, 3, db.NumDocs())
+	hits, err := db.Search([]uint32{1, 2, 3})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 2, 2: 2, 3: 1}, hits)
+
+	body.Reset()
+	body.WriteString(`{"items": [[1,4]]}`)
+	body.WriteString(`{"id": 5, "terms": [1]}`)
+
+	req = httptest.NewRequest("POST", "http://example.com/index", &body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 400, w.Code, "unterminated import should be rejected")
+	require.Equal(t, 3, db.NumDocs())
+}
+
+func TestAddHandler_ImportUnsorted(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	for _, items := range []string{
+		`{"items": [[2,1],[1,1]]}{"items": []}`,
+		`{"items": [[1,2],[1,1]]}{"items": []}`,
+		`{"items": [[1,1],[2,2]]}{"items": [[2,1]]}{"items": []}`,
+	} {
+		req := httptest.NewRequest("POST", "http://example.com/index", strings.NewReader(items))
+		w := httptest.NewRecorder()
+		Handler(db).ServeHTTP(w, req)
+
+		require.Equal(t, 400, w.Code, "out of order items %v should be rejected", items)
+		require.Equal(t, 0, db.NumDocs())
+		require.Equal(t, 0, db.NumSegments())
+	}
+}
+
 func TestStatsHandler(t *testing.T) {
 	db, err := index.Open(vfs.CreateMemDir(), true, nil)
 	require.NoError(t, err, "failed to create test db")
@@ -122,3 +177,176 @@ func TestStatsHandler(t *testing.T) {
 	require.Equal(t, 200, w.Code, "status code should be 200 OK")
 	require.JSONEq(t, expected, w.Body.String(), "unexpected response")
 }
+
+func TestAddHandler_Delete(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100})
+
+	var body bytes.Buffer
+	body.WriteString(`{"id": 2, "terms": [1,2,3]}`)
+	body.WriteString(`{"id": 1, "delete": true}`)
+
+	req := httptest.NewRequest("POST", "http://example.com/index", &body)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{}`, w.Body.String(), "unexpected response")
+	require.Equal(t, 2, db.NumDocs())
+	require.Equal(t, 1, db.NumDeletedDocs())
+}
+
+func TestSearchHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100, 200})
+	db.Add(2, []uint32{100, 300})
+
+	body := bytes.NewBufferString(`{"terms": [100, 200]}`)
+	req := httptest.NewRequest("POST", "http://example.com/search", body)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{"hits": {"1": 2, "2": 1}}`, w.Body.String(), "unexpected response")
+
+	body = bytes.NewBufferString(`{"terms": []}`)
+	req = httptest.NewRequest("POST", "http://example.com/search", body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{"hits": {}}`, w.Body.String(), "unexpected response")
+}
+
+func TestItemsHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.RunInTransaction(func(txn index.Batch) error {
+		txn.Add(1, []uint32{100, 200})
+		return txn.Add(2, []uint32{100})
+	})
+
+	req := httptest.NewRequest("GET", "http://example.com/items", nil)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.Equal(t, "100 1\n100 2\n200 1\n", w.Body.String(), "unexpected response")
+}
+
+func TestAttributesHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	req := httptest.NewRequest("GET", "http://example.com/attributes", nil)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{"attributes": {}}`, w.Body.String(), "unexpected response")
+
+	body := bytes.NewBufferString(`{"value": "100"}`)
+	req = httptest.NewRequest("PUT", "http://example.com/attributes/max_document_id", body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+
+	body = bytes.NewBufferString(`{"id": 1, "terms": [1]}` + "\n" + `{"attributes": {"last_id": "1"}}`)
+	req = httptest.NewRequest("POST", "http://example.com/index", body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+
+	req = httptest.NewRequest("GET", "http://example.com/attributes", nil)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{"attributes": {"max_document_id": "100", "last_id": "1"}}`, w.Body.String(), "unexpected response")
+}
+
+func TestDeleteManyHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	for docID := uint32(1); docID <= 10; docID++ {
+		db.Add(docID, []uint32{100})
+	}
+
+	body := bytes.NewBufferString(`{"ids": [1, 2], "ranges": [{"min": 5, "max": 7}, {"min": 10, "max": 20}]}`)
+	req := httptest.NewRequest("POST", "http://example.com/index/_delete", body)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.JSONEq(t, `{}`, w.Body.String(), "unexpected response")
+
+	hits, err := db.Search([]uint32{100})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{3: 1, 4: 1, 8: 1, 9: 1}, hits)
+
+	body = bytes.NewBufferString(`{"ranges": [{"min": 5, "max": 1}]}`)
+	req = httptest.NewRequest("POST", "http://example.com/index/_delete", body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 400, w.Code, "status code should be 400 Bad Request")
+
+	body = bytes.NewBufferString(`{"delete_ranges": [{"min": 5, "max": 1}]}`)
+	req = httptest.NewRequest("POST", "http://example.com/index", body)
+	w = httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+	require.Equal(t, 400, w.Code, "status code should be 400 Bad Request")
+}
+
+func TestMetricsHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100})
+	db.Add(2, []uint32{100})
+	db.Search([]uint32{100})
+
+	req := httptest.NewRequest("GET", "http://example.com/metrics", nil)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+	require.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+	require.Contains(t, body, "# TYPE aindex_searches_total counter\naindex_searches_total 1\n")
+	require.Contains(t, body, "aindex_search_duration_seconds_count 1\n")
+	require.Contains(t, body, "aindex_commits_total 2\n")
+	require.Contains(t, body, "aindex_segments 2\n")
+	require.Contains(t, body, "aindex_open_transactions 0\n")
+	require.Contains(t, body, `aindex_segment_size_bytes{segment="1"} `)
+}
+
+func TestSegmentStatsHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	db.Add(1, []uint32{100, 200})
+
+	req := httptest.NewRequest("GET", "http://example.com/stats/segments", nil)
+	w := httptest.NewRecorder()
+	Handler(db).ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code, "status code should be 200 OK")
+
+	var stats index.Stats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.Equal(t, 1, stats.NumDocs)
+	require.Len(t, stats.Segments, 1)
+	require.Equal(t, 2, stats.Segments[0].Meta.NumItems)
+	require.Equal(t, uint32(200), stats.Segments[0].Meta.MaxTerm)
+}
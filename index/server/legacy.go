@@ -0,0 +1,328 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The legacy protocol is the line-based protocol of the original AcoustID index server.
+// Each request is one line with a command and space-separated arguments, lists of terms
+// are separated by commas. Each response is one line, either "OK <result>" or "ERR <message>".
+
+const maxLegacyLineSize = 1024 * 1024
+
+const (
+	defaultMaxResults      = 500
+	defaultTopScorePercent = 10
+)
+
+var errQuit = errors.New("quit")
+
+// ServeLegacy accepts connections on l and handles them using the legacy text protocol.
+func ServeLegacy(l net.Listener, db *index.DB) error {
+	return serveLegacy(l, db, nil)
+}
+
+func serveLegacy(l net.Listener, db *index.DB, conns *connTracker) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go handleLegacyConn(conn, db, conns)
+	}
+}
+
+func ListenAndServeLegacy(addr string, db *index.DB) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return ServeLegacy(l, db)
+}
+
+type legacySession struct {
+	db              *index.DB
+	txn             index.Batch
+	maxResults      int
+	topScorePercent int
+	timeout         int
+}
+
+func handleLegacyConn(conn net.Conn, db *index.DB, conns *connTracker) {
+	defer conn.Close()
+
+	if !conns.add(conn) {
+		return
+	}
+	defer conns.remove(conn)
+
+	s := &legacySession{
+		db:              db,
+		maxResults:      defaultMaxResults,
+		topScorePercent: defaultTopScorePercent,
+	}
+	defer s.rollback()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxLegacyLineSize)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		if s.timeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(time.Duration(s.timeout) * time.Millisecond))
+		} else {
+			conn.SetReadDeadline(time.Time{})
+		}
+		if !conns.next(conn, s.txn != nil) {
+			return
+		}
+		if !scanner.Scan() {
+			err := scanner.Err()
+			if err != nil {
+				log.Printf("failed to read request from %v: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		result, err := s.handle(strings.TrimSpace(scanner.Text()))
+		if err == errQuit {
+			return
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintf(writer, "ERR %v\n", err)
+		case result != "":
+			fmt.Fprintf(writer, "OK %v\n", result)
+		default:
+			fmt.Fprint(writer, "OK\n")
+		}
+		err = writer.Flush()
+		if err != nil {
+			log.Printf("failed to send response to %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+func (s *legacySession) handle(line string) (string, error) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return "", errors.New("missing command")
+	}
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "quit":
+		return "", errQuit
+	case "echo":
+		return strings.Join(args, " "), nil
+	case "get":
+		if len(args) == 2 && args[0] == "attribute" {
+			return s.getAttribute(args[1])
+		}
+		if len(args) == 1 {
+			return s.getAttribute(args[0])
+		}
+		return "", errors.New("expected 1 argument")
+	case "set":
+		if len(args) == 3 && args[0] == "attribute" {
+			return "", s.setAttribute(args[1], args[2])
+		}
+		if len(args) == 2 {
+			return "", s.setAttribute(args[0], args[1])
+		}
+		return "", errors.New("expected 2 arguments")
+	case "begin":
+		return "", s.begin()
+	case "commit":
+		return "", s.commit()
+	case "rollback":
+		if s.txn == nil {
+			return "", errors.New("not in transaction")
+		}
+		s.rollback()
+		return "", nil
+	case "insert":
+		if len(args) != 2 {
+			return "", errors.New("expected 2 arguments")
+		}
+		return "", s.insert(args[0], args[1])
+	case "search":
+		if len(args) != 1 {
+			return "", errors.New("expected 1 argument")
+		}
+		return s.search(args[0])
+	case "optimize":
+		return "", s.db.Compact()
+	case "cleanup":
+		// Unused files are deleted automatically as soon as they are not needed anymore.
+		return "", nil
+	}
+	return "", errors.New("unknown command")
+}
+
+func (s *legacySession) getAttribute(name string) (string, error) {
+	switch name {
+	case "max_results":
+		return strconv.Itoa(s.maxResults), nil
+	case "top_score_percent":
+		return strconv.Itoa(s.topScorePercent), nil
+	case "timeout":
+		return strconv.Itoa(s.timeout), nil
+	}
+	if s.txn != nil {
+		return s.txn.(*index.Transaction).Attribute(name), nil
+	}
+	return s.db.Attribute(name), nil
+}
+
+func (s *legacySession) setAttribute(name, value string) error {
+	var target *int
+	switch name {
+	case "max_results":
+		target = &s.maxResults
+	case "top_score_percent":
+		target = &s.topScorePercent
+	case "timeout":
+		target = &s.timeout
+	default:
+		if s.txn == nil {
+			return errors.New("not in transaction")
+		}
+		return s.txn.(*index.Transaction).SetAttribute(name, value)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return errors.Errorf("invalid value for %v", name)
+	}
+	*target = n
+	return nil
+}
+
+func (s *legacySession) begin() error {
+	if s.txn != nil {
+		return errors.New("already in transaction")
+	}
+	txn, err := s.db.Transaction()
+	if err != nil {
+		return err
+	}
+	s.txn = txn
+	return nil
+}
+
+func (s *legacySession) commit() error {
+	if s.txn == nil {
+		return errors.New("not in transaction")
+	}
+	txn := s.txn
+	s.txn = nil
+	defer txn.Close()
+	err := txn.Commit()
+	if err != nil {
+		log.Printf("commit failed: %v", err)
+	}
+	return err
+}
+
+func (s *legacySession) rollback() {
+	if s.txn != nil {
+		s.txn.Close()
+		s.txn = nil
+	}
+}
+
+func (s *legacySession) insert(id, hashes string) error {
+	if s.txn == nil {
+		return errors.New("not in transaction")
+	}
+	docID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return errors.New("invalid document ID")
+	}
+	terms, err := parseLegacyTerms(hashes)
+	if err != nil {
+		return err
+	}
+	return s.txn.Add(uint32(docID), terms)
+}
+
+func (s *legacySession) search(hashes string) (string, error) {
+	terms, err := parseLegacyTerms(hashes)
+	if err != nil {
+		return "", err
+	}
+	hits, err := s.db.Search(terms)
+	if err != nil {
+		log.Printf("search failed: %v", err)
+		return "", err
+	}
+
+	type result struct {
+		docID uint32
+		score int
+	}
+	results := make([]result, 0, len(hits))
+	for docID, score := range hits {
+		results = append(results, result{docID: docID, score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].score > results[j].score || (results[i].score == results[j].score && results[i].docID < results[j].docID)
+	})
+
+	if len(results) > 0 {
+		minScore := results[0].score * s.topScorePercent / 100
+		for i, r := range results {
+			if r.score < minScore || (s.maxResults > 0 && i >= s.maxResults) {
+				results = results[:i]
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, r := range results {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(&buf, "%d:%d", r.docID, r.score)
+	}
+	return buf.String(), nil
+}
+
+// parseLegacyTerms parses a comma-separated list of terms. The original server used signed 32-bit
+// integers, so negative values are accepted and converted to their unsigned representation.
+func parseLegacyTerms(s string) ([]uint32, error) {
+	parts := strings.Split(s, ",")
+	terms := make([]uint32, len(parts))
+	for i, part := range parts {
+		if strings.HasPrefix(part, "-") {
+			term, err := strconv.ParseInt(part, 10, 32)
+			if err != nil {
+				return nil, errors.New("invalid term")
+			}
+			terms[i] = uint32(int32(term))
+			continue
+		}
+		term, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid term")
+		}
+		terms[i] = uint32(term)
+	}
+	return terms, nil
+}
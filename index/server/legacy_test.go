@@ -0,0 +1,91 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+type legacyTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *legacyTestClient) call(request string) string {
+	_, err := fmt.Fprintf(c.conn, "%s\n", request)
+	require.NoError(c.t, err, "failed to send request")
+	line, err := c.reader.ReadString('\n')
+	require.NoError(c.t, err, "failed to read response")
+	return line[:len(line)-1]
+}
+
+func TestLegacyProtocol(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	go handleLegacyConn(serverConn, db, nil)
+	defer clientConn.Close()
+
+	c := &legacyTestClient{t: t, conn: clientConn, reader: bufio.NewReader(clientConn)}
+
+	require.Equal(t, "OK hello world", c.call("echo hello world"))
+	require.Equal(t, "ERR unknown command", c.call("foo"))
+
+	require.Equal(t, "ERR not in transaction", c.call("insert 1 1,2,3"))
+	require.Equal(t, "ERR not in transaction", c.call("commit"))
+	require.Equal(t, "OK", c.call("begin"))
+	require.Equal(t, "ERR already in transaction", c.call("begin"))
+	require.Equal(t, "OK", c.call("insert 1 1,2,3,4,5,6,7,8,9,10"))
+	require.Equal(t, "OK", c.call("insert 2 1,2,3,-4"))
+	require.Equal(t, "OK", c.call("insert 3 1"))
+	require.Equal(t, "ERR invalid document ID", c.call("insert x 1"))
+	require.Equal(t, "ERR invalid term", c.call("insert 4 1,x"))
+	require.Equal(t, "OK", c.call("search 1,2,3"), "uncommitted changes should not be visible")
+	require.Equal(t, "OK", c.call("commit"))
+
+	require.Equal(t, "OK 1:10 2:3 3:1", c.call("search 1,2,3,4,5,6,7,8,9,10"))
+	require.Equal(t, "OK 2:4 1:3 3:1", c.call("search 1,2,3,4294967292"))
+
+	require.Equal(t, "OK 500", c.call("get max_results"))
+	require.Equal(t, "OK", c.call("set attribute max_results 1"))
+	require.Equal(t, "OK 1", c.call("get attribute max_results"))
+	require.Equal(t, "OK 1:10", c.call("search 1,2,3,4,5,6,7,8,9,10"))
+	require.Equal(t, "OK", c.call("set max_results 10"))
+	require.Equal(t, "OK", c.call("set top_score_percent 30"))
+	require.Equal(t, "OK 1:10 2:3", c.call("search 1,2,3,4,5,6,7,8,9,10"))
+	require.Equal(t, "ERR invalid value for max_results", c.call("set max_results x"))
+
+	require.Equal(t, "OK", c.call("begin"))
+	require.Equal(t, "OK", c.call("insert 4 1"))
+	require.Equal(t, "OK", c.call("rollback"))
+	require.Equal(t, "ERR not in transaction", c.call("rollback"))
+	require.Equal(t, "OK 1:1 2:1 3:1", c.call("search 1"))
+
+	require.Equal(t, "OK", c.call("get attribute max_document_id"))
+	require.Equal(t, "ERR not in transaction", c.call("set attribute max_document_id 3"))
+	require.Equal(t, "OK", c.call("begin"))
+	require.Equal(t, "OK", c.call("set attribute max_document_id 3"))
+	require.Equal(t, "OK 3", c.call("get attribute max_document_id"))
+	require.Equal(t, "OK", c.call("commit"))
+	require.Equal(t, "OK 3", c.call("get max_document_id"))
+	require.Equal(t, "3", db.Attribute("max_document_id"))
+
+	require.Equal(t, "OK", c.call("cleanup"))
+	require.Equal(t, "OK", c.call("optimize"))
+	require.Equal(t, "OK 1:1 2:1 3:1", c.call("search 1"))
+
+	_, err = fmt.Fprintf(clientConn, "quit\n")
+	require.NoError(t, err)
+	_, err = c.reader.ReadString('\n')
+	require.Error(t, err, "connection should be closed after quit")
+}
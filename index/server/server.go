@@ -31,16 +31,76 @@ func writeErrorResponse(w http.ResponseWriter, status int, message string) {
 	writeResponse(w, status, response)
 }
 
+// Options configure the HTTP handler.
+type Options struct {
+	// AdminToken enables the admin endpoints under /admin/. Requests to them must include
+	// the token in the Authorization header as "Bearer <token>".
+	AdminToken string
+
+	// CheckpointDir is the directory in which checkpoints requested by admins are created.
+	// Checkpoints are disabled if empty.
+	CheckpointDir string
+
+	// ReadKeys and WriteKeys enable API key authentication. Requests must include a key in the Authorization
+	// header as "Bearer <key>". Read keys give access to searching and reading the index, write keys
+	// can be also used for changing it. The /health, /ready and /metrics endpoints are always public.
+	ReadKeys  []string
+	WriteKeys []string
+
+	// RateLimit is the number of requests per second allowed for each API key, or for each client address
+	// if authentication is disabled. Rate limiting is disabled if zero.
+	RateLimit float64
+
+	// RateBurst is the number of requests a client can make at once before being limited. Defaults to RateLimit.
+	RateBurst int
+}
+
+// Handler returns the HTTP handler with the default options.
 func Handler(db *index.DB) http.Handler {
+	return NewHandler(db, nil)
+}
+
+func NewHandler(db *index.DB, opts *Options) http.Handler {
+	return newHandler(db, opts, nil, newJobManager())
+}
+
+// newHandler creates the HTTP handler. The ready function is used by the /ready endpoint, if nil the server is always ready.
+// Admin jobs are started using the job manager.
+func newHandler(db *index.DB, opts *Options, ready func() bool, jobs *jobManager) http.Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+	access := newAccessControl(opts)
+	read := func(h http.Handler) http.Handler { return access.wrap(scopeRead, h) }
+	write := func(h http.Handler) http.Handler { return access.wrap(scopeWrite, h) }
 	r := mux.NewRouter()
-	r.Path("/index").Methods("POST").Handler(&AddHandler{db: db})
-	r.Path("/index").Methods("DELETE").Handler(&DeleteAllHandler{db: db})
-	r.Path("/index/{id:[0-9]+}").Methods("PUT").Handler(&UpdateHandler{db: db})
-	r.Path("/index/{id:[0-9]+}").Methods("DELETE").Handler(&DeleteHandler{db: db})
-	r.Path("/stats").Methods("GET").Handler(&StatsHandler{db: db})
+	r.Path("/index").Methods("POST").Handler(write(&AddHandler{db: db}))
+	r.Path("/index/_delete").Methods("POST").Handler(write(&DeleteManyHandler{db: db}))
+	r.Path("/index").Methods("DELETE").Handler(write(&DeleteAllHandler{db: db}))
+	r.Path("/index/{id:[0-9]+}").Methods("PUT").Handler(write(&UpdateHandler{db: db}))
+	r.Path("/index/{id:[0-9]+}").Methods("DELETE").Handler(write(&DeleteHandler{db: db}))
+	r.Path("/attributes").Methods("GET").Handler(read(&AttributesHandler{db: db}))
+	r.Path("/attributes/{name}").Methods("PUT").Handler(write(&SetAttributeHandler{db: db}))
+	r.Path("/items").Methods("GET").Handler(read(&ItemsHandler{db: db}))
+	r.Path("/search").Methods("POST").Handler(read(&SearchHandler{db: db}))
+	r.Path("/stats").Methods("GET").Handler(read(&StatsHandler{db: db}))
+	r.Path("/stats/segments").Methods("GET").Handler(read(&SegmentStatsHandler{db: db}))
+	r.Path("/health").Methods("GET").Handler(&HealthHandler{})
+	r.Path("/ready").Methods("GET").Handler(&ReadyHandler{ready: ready})
+	r.Path("/metrics").Methods("GET").Handler(NewMetricsHandler(db))
+	r.Path("/manifest").Methods("GET").Handler(read(&ManifestHandler{db: db}))
+	r.Path("/files/{name:segment-[0-9]+(?:-[0-9]+\\.del|\\.dat)}").Methods("GET").Handler(read(&FileHandler{db: db}))
+	if opts.AdminToken != "" {
+		registerAdminHandlers(r, db, opts, jobs)
+	}
 	return r
 }
 
 func ListenAndServe(addr string, db *index.DB) error {
-	return http.ListenAndServe(addr, Handler(db))
+	return ListenAndServeWithOptions(addr, db, nil)
+}
+
+// ListenAndServeWithOptions serves the HTTP API configured by opts. The default options are used if opts is nil.
+func ListenAndServeWithOptions(addr string, db *index.DB, opts *Options) error {
+	return http.ListenAndServe(addr, NewHandler(db, opts))
 }
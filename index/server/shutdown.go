@@ -0,0 +1,223 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"context"
+	"github.com/acoustid/go-acoustid/index"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server serves the HTTP API and optionally the binary and legacy protocols, and supports graceful shutdown.
+type Server struct {
+	db      *index.DB
+	http    *http.Server
+	conns   *connTracker
+	jobs    *jobManager
+	closing int32
+
+	mu        sync.Mutex
+	listeners []net.Listener
+}
+
+// NewServer creates a new server for the database. Listeners are started separately with the ListenAndServe* methods.
+func NewServer(db *index.DB, opts *Options) *Server {
+	s := &Server{db: db, conns: newConnTracker(), jobs: newJobManager()}
+	s.http = &http.Server{Handler: newHandler(db, opts, s.ready, s.jobs)}
+	return s
+}
+
+func (s *Server) ready() bool {
+	return atomic.LoadInt32(&s.closing) == 0
+}
+
+// ListenAndServe listens on addr and serves the HTTP API. It returns nil after the server was shut down.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	err = s.http.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// ListenAndServeBinary listens on addr and serves the binary protocol. It returns nil after the server was shut down.
+func (s *Server) ListenAndServeBinary(addr string) error {
+	l, err := s.listen(addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(serveBinary(l, s.db, s.conns))
+}
+
+// ListenAndServeLegacy listens on addr and serves the legacy protocol. It returns nil after the server was shut down.
+func (s *Server) ListenAndServeLegacy(addr string) error {
+	l, err := s.listen(addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(serveLegacy(l, s.db, s.conns))
+}
+
+func (s *Server) listen(addr string) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready() {
+		l.Close()
+		return nil, http.ErrServerClosed
+	}
+	s.listeners = append(s.listeners, l)
+	return l, nil
+}
+
+func (s *Server) serve(err error) error {
+	if !s.ready() {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server. The server stops accepting new connections and reports itself
+// as not ready, waits for HTTP requests and running checkpoints to finish and for open transactions on protocol
+// connections to be committed or rolled back. Then it waits for running merges and closes the database. Connections that
+// are still open when the context is done are closed forcefully.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	atomic.StoreInt32(&s.closing, 1)
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	s.listeners = nil
+	s.mu.Unlock()
+
+	err := s.http.Shutdown(ctx)
+	if err != nil {
+		log.Printf("failed to wait for HTTP requests: %v", err)
+	}
+
+	err2 := s.jobs.shutdown(ctx)
+	if err2 != nil {
+		log.Printf("failed to wait for admin jobs: %v", err2)
+		if err == nil {
+			err = err2
+		}
+	}
+
+	err2 = s.conns.shutdown(ctx)
+	if err2 != nil {
+		log.Printf("closed connections with open transactions: %v", err2)
+		if err == nil {
+			err = err2
+		}
+	}
+
+	err2 = s.db.Shutdown(ctx)
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+func isTimeout(err error) bool {
+	e, ok := err.(net.Error)
+	return ok && e.Timeout()
+}
+
+// connTracker keeps track of open protocol connections and whether they have an open transaction.
+// Methods on a nil tracker do nothing, which is used when serving without graceful shutdown.
+type connTracker struct {
+	mu      sync.Mutex
+	conns   map[net.Conn]bool
+	closing bool
+	wg      sync.WaitGroup
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[net.Conn]bool)}
+}
+
+// add starts tracking a new connection. It returns false if the tracker is shutting down.
+func (t *connTracker) add(conn net.Conn) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return false
+	}
+	t.conns[conn] = false
+	t.wg.Add(1)
+	return true
+}
+
+func (t *connTracker) remove(conn net.Conn) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, conn)
+	t.wg.Done()
+}
+
+// next must be called before reading the next request from the connection. It returns false
+// if the connection should be closed, because the tracker is shutting down and there is no open transaction.
+func (t *connTracker) next(conn net.Conn, inTransaction bool) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing && !inTransaction {
+		return false
+	}
+	t.conns[conn] = inTransaction
+	return true
+}
+
+// shutdown interrupts idle connections and waits for the remaining ones to finish their transactions.
+func (t *connTracker) shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.closing = true
+	for conn, inTransaction := range t.conns {
+		if !inTransaction {
+			// Unblocks the pending read, the connection is then closed by its handler.
+			conn.SetReadDeadline(time.Now())
+		}
+	}
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	for conn := range t.conns {
+		conn.Close()
+	}
+	t.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
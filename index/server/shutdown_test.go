@@ -0,0 +1,86 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	s := NewServer(db, nil)
+	l, err := s.listen("127.0.0.1:0")
+	require.NoError(t, err, "failed to listen")
+	served := make(chan error, 1)
+	go func() { served <- s.serve(serveLegacy(l, db, s.conns)) }()
+
+	dial := func() *legacyTestClient {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err, "failed to connect")
+		return &legacyTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	}
+	idle := dial()
+	defer idle.conn.Close()
+	busy := dial()
+	defer busy.conn.Close()
+
+	require.Equal(t, "OK hello", idle.call("echo hello"))
+	require.Equal(t, "OK", busy.call("begin"))
+	require.Equal(t, "OK", busy.call("insert 1 1,2,3"))
+
+	ready := func() int {
+		w := httptest.NewRecorder()
+		s.http.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/ready", nil))
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, ready())
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+
+	require.NoError(t, <-served, "listener should stop without an error")
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+
+	_, err = idle.reader.ReadString('\n')
+	assert.Equal(t, io.EOF, err, "idle connection should be closed")
+
+	select {
+	case <-done:
+		t.Fatal("shutdown should wait for the open transaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.Equal(t, "OK", busy.call("commit"))
+	_, err = busy.reader.ReadString('\n')
+	assert.Equal(t, io.EOF, err, "connection should be closed after commit")
+
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, db.NumDocs())
+}
+
+func TestHealthHandler(t *testing.T) {
+	db, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create test db")
+	defer db.Close()
+
+	for _, path := range []string{"/health", "/ready"} {
+		w := httptest.NewRecorder()
+		Handler(db).ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
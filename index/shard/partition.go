@@ -0,0 +1,61 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package shard
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// Partitioner decides which shard is responsible for a given docID.
+type Partitioner interface {
+	// NumShards returns the number of shards the docIDs are partitioned into.
+	NumShards() int
+
+	// Shard returns the index of the shard that contains docID.
+	Shard(docID uint32) int
+}
+
+// HashPartitioner distributes docIDs evenly between a fixed number of shards.
+type HashPartitioner int
+
+func (p HashPartitioner) NumShards() int { return int(p) }
+
+func (p HashPartitioner) Shard(docID uint32) int {
+	// Fibonacci hashing, so that sequences of docIDs are not mapped to the shards in a round-robin fashion.
+	return int((docID * 2654435761) % uint32(p))
+}
+
+// RangePartitioner assigns continuous ranges of docIDs to shards. It contains the exclusive upper bound
+// of docIDs for each shard except the last one, so the first shard contains docIDs in the range [0, p[0]),
+// the second one [p[0], p[1]) and the last one [p[len(p)-1], MaxUint32].
+type RangePartitioner []uint32
+
+func (p RangePartitioner) NumShards() int { return len(p) + 1 }
+
+func (p RangePartitioner) Shard(docID uint32) int {
+	return sort.Search(len(p), func(i int) bool { return docID < p[i] })
+}
+
+// Split returns a new RangePartitioner with shard i split into two at docID. The docID must lie inside
+// the range of shard i and must not be its lower bound, otherwise one of the new shards would be empty.
+func (p RangePartitioner) Split(i int, docID uint32) (RangePartitioner, error) {
+	if i < 0 || i > len(p) {
+		return nil, errors.Errorf("shard %v does not exist", i)
+	}
+	if i > 0 && docID <= p[i-1] {
+		return nil, errors.Errorf("docID %v is not above the lower bound %v of shard %v", docID, p[i-1], i)
+	}
+	if i == 0 && docID == 0 {
+		return nil, errors.New("docID 0 is the lower bound of shard 0")
+	}
+	if i < len(p) && docID >= p[i] {
+		return nil, errors.Errorf("docID %v is not below the upper bound %v of shard %v", docID, p[i], i)
+	}
+	p2 := make(RangePartitioner, 0, len(p)+1)
+	p2 = append(p2, p[:i]...)
+	p2 = append(p2, docID)
+	p2 = append(p2, p[i:]...)
+	return p2, nil
+}
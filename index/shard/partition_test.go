@@ -0,0 +1,51 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package shard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHashPartitioner(t *testing.T) {
+	p := HashPartitioner(4)
+	assert.Equal(t, 4, p.NumShards())
+	counts := make([]int, p.NumShards())
+	for docID := uint32(0); docID < 1000; docID++ {
+		i := p.Shard(docID)
+		if assert.True(t, i >= 0 && i < p.NumShards(), "shard %v out of range", i) {
+			counts[i]++
+		}
+	}
+	for i, n := range counts {
+		assert.InDelta(t, 250, n, 50, "shard %v is not balanced", i)
+	}
+}
+
+func TestRangePartitioner(t *testing.T) {
+	p := RangePartitioner{100, 200}
+	assert.Equal(t, 3, p.NumShards())
+	assert.Equal(t, 0, p.Shard(0))
+	assert.Equal(t, 0, p.Shard(99))
+	assert.Equal(t, 1, p.Shard(100))
+	assert.Equal(t, 1, p.Shard(199))
+	assert.Equal(t, 2, p.Shard(200))
+	assert.Equal(t, 2, p.Shard(0xffffffff))
+
+	p2, err := p.Split(1, 150)
+	require.NoError(t, err)
+	assert.Equal(t, RangePartitioner{100, 150, 200}, p2)
+	assert.Equal(t, RangePartitioner{100, 200}, p, "original partitioner should not be modified")
+	assert.Equal(t, 1, p2.Shard(149))
+	assert.Equal(t, 2, p2.Shard(150))
+
+	for _, split := range []struct {
+		shard int
+		docID uint32
+	}{{0, 0}, {0, 100}, {0, 150}, {1, 100}, {1, 50}, {1, 200}, {2, 200}, {2, 150}, {3, 300}, {-1, 50}} {
+		_, err := p.Split(split.shard, split.docID)
+		assert.Error(t, err, "splitting shard %v at %v should fail", split.shard, split.docID)
+	}
+}
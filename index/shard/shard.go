@@ -0,0 +1,343 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package shard provides an index partitioned by docID into multiple independent databases.
+package shard
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/pkg/errors"
+	"go4.org/syncutil"
+	"io"
+)
+
+// Index combines multiple databases into one logical index. Each document is stored in exactly
+// one of the databases, chosen by the partitioner.
+type Index struct {
+	shards      []*index.DB
+	partitioner Partitioner
+}
+
+// New creates a new Index over the given databases. The databases are not owned by the index
+// and need to be closed by the caller.
+func New(shards []*index.DB, partitioner Partitioner) (*Index, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("no shards")
+	}
+	if len(shards) != partitioner.NumShards() {
+		return nil, errors.Errorf("partitioner expects %v shards, got %v", partitioner.NumShards(), len(shards))
+	}
+	return &Index{shards: shards, partitioner: partitioner}, nil
+}
+
+func (idx *Index) NumShards() int            { return len(idx.shards) }
+func (idx *Index) Shard(i int) *index.DB     { return idx.shards[i] }
+func (idx *Index) Partitioner() Partitioner  { return idx.partitioner }
+func (idx *Index) ShardFor(docID uint32) int { return idx.partitioner.Shard(docID) }
+
+// NumDocs returns the total number of docs in all shards.
+func (idx *Index) NumDocs() int {
+	var n int
+	for _, db := range idx.shards {
+		n += db.NumDocs()
+	}
+	return n
+}
+
+// NumDeletedDocs returns the total number of deleted docs in all shards.
+func (idx *Index) NumDeletedDocs() int {
+	var n int
+	for _, db := range idx.shards {
+		n += db.NumDeletedDocs()
+	}
+	return n
+}
+
+// Snapshot creates a read-only view of all shards. Each shard is consistent on its own,
+// but commits to different shards are not synchronized.
+func (idx *Index) Snapshot() index.Searcher {
+	s := &Snapshot{snapshots: make([]index.Searcher, len(idx.shards))}
+	for i, db := range idx.shards {
+		s.snapshots[i] = db.Snapshot()
+	}
+	return s
+}
+
+func (idx *Index) Search(query []uint32) (map[uint32]int, error) {
+	snapshot := idx.Snapshot()
+	defer snapshot.Close()
+	return snapshot.Search(query)
+}
+
+// Transaction starts a new write transaction over all shards. Transactions on the individual shards
+// are only started once they are needed.
+func (idx *Index) Transaction() (index.Batch, error) {
+	return &Batch{idx: idx, txns: make([]index.Batch, len(idx.shards))}, nil
+}
+
+// RunInTransaction executes the given function in a transaction. If the function does not return an error,
+// the transaction will be automatically committed.
+func (idx *Index) RunInTransaction(fn func(txn index.Batch) error) error {
+	txn, err := idx.Transaction()
+	if err != nil {
+		return err
+	}
+	defer txn.Close()
+
+	err = fn(txn)
+	if err != nil {
+		return err
+	}
+
+	return txn.Commit()
+}
+
+func (idx *Index) Add(docID uint32, terms []uint32) error {
+	return idx.RunInTransaction(func(txn index.Batch) error { return txn.Add(docID, terms) })
+}
+
+func (idx *Index) Delete(docID uint32) error {
+	return idx.RunInTransaction(func(txn index.Batch) error { return txn.Delete(docID) })
+}
+
+// Snapshot is a read-only view of a sharded index.
+type Snapshot struct {
+	snapshots []index.Searcher
+	close     syncutil.Once
+}
+
+// Search searches all shards in parallel and combines the results.
+func (s *Snapshot) Search(query []uint32) (map[uint32]int, error) {
+	type result struct {
+		hits map[uint32]int
+		err  error
+	}
+	results := make(chan result, len(s.snapshots))
+
+	for i, snapshot := range s.snapshots {
+		i, snapshot := i, snapshot
+		go func() {
+			// Each shard sorts the query in place, so it needs its own copy.
+			q := make([]uint32, len(query))
+			copy(q, query)
+			hits, err := snapshot.Search(q)
+			if err != nil {
+				err = errors.Wrapf(err, "search in shard %v failed", i)
+			}
+			results <- result{hits: hits, err: err}
+		}()
+	}
+
+	var hits map[uint32]int
+	var err error
+	for range s.snapshots {
+		res := <-results
+		if res.err != nil {
+			if err == nil {
+				err = res.err
+			}
+			continue
+		}
+		if hits == nil {
+			hits = res.hits
+			continue
+		}
+		for docID, count := range res.hits {
+			hits[docID] += count
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// Reader creates an ItemReader that iterates over all items in all shards.
+func (s *Snapshot) Reader() index.ItemReader {
+	readers := make([]index.ItemReader, len(s.snapshots))
+	for i, snapshot := range s.snapshots {
+		readers[i] = snapshot.Reader()
+	}
+	return index.MergeItemReaders(readers...)
+}
+
+func (s *Snapshot) Close() error {
+	return s.close.Do(func() error {
+		var firstErr error
+		for _, snapshot := range s.snapshots {
+			err := snapshot.Close()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// Batch is a write transaction over a sharded index. Each shard is committed atomically,
+// but there is no atomicity across shards.
+type Batch struct {
+	idx  *Index
+	txns []index.Batch
+}
+
+func (b *Batch) txn(i int) (index.Batch, error) {
+	if b.txns[i] == nil {
+		txn, err := b.idx.shards[i].Transaction()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to start transaction in shard %v", i)
+		}
+		b.txns[i] = txn
+	}
+	return b.txns[i], nil
+}
+
+func (b *Batch) Add(docID uint32, terms []uint32) error {
+	txn, err := b.txn(b.idx.partitioner.Shard(docID))
+	if err != nil {
+		return err
+	}
+	return txn.Add(docID, terms)
+}
+
+func (b *Batch) Delete(docID uint32) error {
+	txn, err := b.txn(b.idx.partitioner.Shard(docID))
+	if err != nil {
+		return err
+	}
+	return txn.Delete(docID)
+}
+
+// DeleteMany deletes the docs from all shards.
+func (b *Batch) DeleteMany(docs *intset.SparseBitSet) error {
+	return b.forEachShard(func(txn index.Batch) error { return txn.DeleteMany(docs) })
+}
+
+// DeleteRange deletes the docs between min and max, inclusive, from all shards.
+func (b *Batch) DeleteRange(min, max uint32) error {
+	return b.forEachShard(func(txn index.Batch) error { return txn.DeleteRange(min, max) })
+}
+
+func (b *Batch) forEachShard(fn func(txn index.Batch) error) error {
+	for i := range b.txns {
+		txn, err := b.txn(i)
+		if err != nil {
+			return err
+		}
+		err = fn(txn)
+		if err != nil {
+			return errors.Wrapf(err, "operation in shard %v failed", i)
+		}
+	}
+	return nil
+}
+
+// Import splits a pre-sorted stream of items between the shards and imports them in parallel.
+func (b *Batch) Import(input index.ItemReader) error {
+	chans := make([]chan []index.Item, len(b.txns))
+	var importers syncutil.Group
+
+	startImport := func(i int) error {
+		txn, err := b.txn(i)
+		if err != nil {
+			return err
+		}
+		ch := make(chan []index.Item, 1)
+		chans[i] = ch
+		importers.Go(func() error {
+			err := txn.Import(&channelReader{ch: ch})
+			for range ch {
+				// Drain the channel in case the import failed before reading everything.
+			}
+			if err != nil {
+				return errors.Wrapf(err, "import into shard %v failed", i)
+			}
+			return nil
+		})
+		return nil
+	}
+
+	var err error
+loop:
+	for {
+		block, readErr := input.ReadBlock()
+		if len(block) > 0 {
+			parts := make([][]index.Item, len(b.txns))
+			for _, item := range block {
+				i := b.idx.partitioner.Shard(item.DocID)
+				parts[i] = append(parts[i], item)
+			}
+			for i, part := range parts {
+				if len(part) == 0 {
+					continue
+				}
+				if chans[i] == nil {
+					err = startImport(i)
+					if err != nil {
+						break loop
+					}
+				}
+				chans[i] <- part
+			}
+		}
+		if readErr != nil {
+			if readErr != io.EOF {
+				err = errors.Wrap(readErr, "read failed")
+			}
+			break
+		}
+	}
+
+	for _, ch := range chans {
+		if ch != nil {
+			close(ch)
+		}
+	}
+
+	if err != nil {
+		importers.Wait()
+		return err
+	}
+	return importers.Err()
+}
+
+// Commit commits all started transactions, one shard after another.
+func (b *Batch) Commit() error {
+	for i, txn := range b.txns {
+		if txn == nil {
+			continue
+		}
+		err := txn.Commit()
+		if err != nil {
+			return errors.Wrapf(err, "commit in shard %v failed", i)
+		}
+	}
+	return nil
+}
+
+func (b *Batch) Close() error {
+	var firstErr error
+	for _, txn := range b.txns {
+		if txn == nil {
+			continue
+		}
+		err := txn.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type channelReader struct {
+	ch <-chan []index.Item
+}
+
+func (r *channelReader) ReadBlock() ([]index.Item, error) {
+	block, ok := <-r.ch
+	if !ok {
+		return nil, io.EOF
+	}
+	return block, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package shard
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/acoustid/go-acoustid/util/intset"
+	"github.com/acoustid/go-acoustid/util/vfs"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func openTestShards(t *testing.T, n int) []*index.DB {
+	shards := make([]*index.DB, n)
+	for i := range shards {
+		db, err := index.Open(vfs.CreateMemDir(), true, nil)
+		require.NoError(t, err, "failed to create shard %v", i)
+		shards[i] = db
+	}
+	return shards
+}
+
+func closeTestShards(shards []*index.DB) {
+	for _, db := range shards {
+		db.Close()
+	}
+}
+
+func TestIndex(t *testing.T) {
+	shards := openTestShards(t, 2)
+	defer closeTestShards(shards)
+
+	idx, err := New(shards, RangePartitioner{100})
+	require.NoError(t, err)
+
+	require.NoError(t, idx.Add(1, []uint32{7, 8, 9}), "add failed")
+	require.NoError(t, idx.Add(101, []uint32{8, 9, 10}), "add failed")
+	require.Equal(t, 1, shards[0].NumDocs())
+	require.Equal(t, 1, shards[1].NumDocs())
+	require.Equal(t, 2, idx.NumDocs())
+
+	hits, err := idx.Search([]uint32{7, 8, 9, 10})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 3, 101: 3}, hits)
+
+	require.NoError(t, idx.Delete(101), "delete failed")
+	require.Equal(t, 1, idx.NumDeletedDocs())
+
+	hits, err = idx.Search([]uint32{7, 8, 9, 10})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{1: 3}, hits)
+}
+
+func TestIndex_Mismatch(t *testing.T) {
+	shards := openTestShards(t, 2)
+	defer closeTestShards(shards)
+
+	_, err := New(shards, HashPartitioner(3))
+	require.Error(t, err, "number of shards should match the partitioner")
+}
+
+func TestBatch_Import(t *testing.T) {
+	shards := openTestShards(t, 3)
+	defer closeTestShards(shards)
+
+	idx, err := New(shards, HashPartitioner(3))
+	require.NoError(t, err)
+
+	var buf index.ItemBuffer
+	expected := make(map[uint32]int)
+	for docID := uint32(1); docID <= 30; docID++ {
+		buf.Add(docID, []uint32{docID, 1000 + docID})
+		expected[docID] = 2
+	}
+
+	txn, err := idx.Transaction()
+	require.NoError(t, err)
+	defer txn.Close()
+	require.NoError(t, txn.Import(buf.Reader()), "import failed")
+	require.NoError(t, txn.Commit(), "commit failed")
+
+	for i, db := range shards {
+		require.NotZero(t, db.NumDocs(), "shard %v should not be empty", i)
+	}
+
+	query := make([]uint32, 0, 60)
+	for docID := uint32(1); docID <= 30; docID++ {
+		query = append(query, docID, 1000+docID)
+	}
+	hits, err := idx.Search(query)
+	require.NoError(t, err, "search failed")
+	require.Equal(t, expected, hits)
+
+	items, err := index.ReadAllItems(idx.Snapshot().Reader())
+	require.NoError(t, err, "read failed")
+	require.Len(t, items, 60)
+}
+
+func TestSplit(t *testing.T) {
+	src, err := index.Open(vfs.CreateMemDir(), true, nil)
+	require.NoError(t, err, "failed to create source db")
+	defer src.Close()
+
+	for docID := uint32(1); docID <= 10; docID++ {
+		require.NoError(t, src.Add(docID, []uint32{docID, 100}), "add failed")
+	}
+
+	dsts := openTestShards(t, 2)
+	defer closeTestShards(dsts)
+
+	snapshot := src.Snapshot()
+	defer snapshot.Close()
+	require.NoError(t, Split(snapshot, dsts, RangePartitioner{6}), "split failed")
+
+	require.Equal(t, 5, dsts[0].NumDocs())
+	require.Equal(t, 5, dsts[1].NumDocs())
+
+	hits, err := dsts[1].Search([]uint32{100})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{6: 1, 7: 1, 8: 1, 9: 1, 10: 1}, hits)
+}
+
+func TestBatch_DeleteRange(t *testing.T) {
+	shards := openTestShards(t, 2)
+	defer closeTestShards(shards)
+
+	idx, err := New(shards, RangePartitioner{100})
+	require.NoError(t, err)
+
+	for _, docID := range []uint32{1, 50, 99, 100, 150} {
+		require.NoError(t, idx.Add(docID, []uint32{7}), "add failed")
+	}
+
+	err = idx.RunInTransaction(func(txn index.Batch) error {
+		docs := intset.NewSparseBitSet(0)
+		docs.Add(1)
+		docs.Add(150)
+		err := txn.DeleteMany(docs)
+		if err != nil {
+			return err
+		}
+		return txn.DeleteRange(60, 100)
+	})
+	require.NoError(t, err, "delete failed")
+
+	hits, err := idx.Search([]uint32{7})
+	require.NoError(t, err, "search failed")
+	require.Equal(t, map[uint32]int{50: 1}, hits)
+}
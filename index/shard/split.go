@@ -0,0 +1,23 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package shard
+
+import (
+	"github.com/acoustid/go-acoustid/index"
+	"github.com/pkg/errors"
+)
+
+// Split copies all documents from src into dsts, using the partitioner to decide where each document goes.
+// It can be used for splitting one shard into multiple smaller ones. The source is not modified.
+func Split(src index.Searcher, dsts []*index.DB, partitioner Partitioner) error {
+	idx, err := New(dsts, partitioner)
+	if err != nil {
+		return err
+	}
+	err = idx.RunInTransaction(func(txn index.Batch) error { return txn.Import(src.Reader()) })
+	if err != nil {
+		return errors.Wrap(err, "import failed")
+	}
+	return nil
+}
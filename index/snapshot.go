@@ -57,6 +57,20 @@ func (s *Snapshot) Reader() ItemReader {
 	return MergeItemReaders(readers...)
 }
 
+// Attribute returns the value of an index attribute, or an empty string if the attribute is not set.
+func (s *Snapshot) Attribute(name string) string {
+	return s.manifest.Attribute(name)
+}
+
+// Attributes returns a copy of all index attributes.
+func (s *Snapshot) Attributes() map[string]string {
+	attrs := make(map[string]string, len(s.manifest.Attributes))
+	for name, value := range s.manifest.Attributes {
+		attrs[name] = value
+	}
+	return attrs
+}
+
 func (s *Snapshot) Close() error {
 	return s.close.Do(func() error { return s.closeFn(s) })
 }
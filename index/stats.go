@@ -0,0 +1,66 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package index
+
+import (
+	"go4.org/sort"
+	"sync/atomic"
+)
+
+// SegmentStats contains information about one segment.
+type SegmentStats struct {
+	ID       uint32      `json:"id"`
+	UpdateID uint32      `json:"update_id"`
+	Size     int         `json:"size"`
+	Meta     SegmentMeta `json:"meta"`
+}
+
+// Stats contains information about the committed state of the database and its runtime state.
+type Stats struct {
+	TxID            uint32         `json:"txid"`
+	NumDocs         int            `json:"num_docs"`
+	NumDeletedDocs  int            `json:"num_deleted_docs"`
+	NumItems        int            `json:"num_items"`
+	NumSegments     int            `json:"num_segments"`
+	Size            int            `json:"size"`
+	NumSnapshots    int64          `json:"num_open_snapshots"`
+	NumTransactions int64          `json:"num_open_transactions"`
+	MergeInProgress bool           `json:"merge_in_progress"`
+	MergingSegments []uint32       `json:"merging_segments,omitempty"`
+	Segments        []SegmentStats `json:"segments"`
+}
+
+// Stats returns information about the database and all its segments. Segments are sorted by ID.
+func (db *DB) Stats() *Stats {
+	manifest := db.manifest.Load().(*Manifest)
+
+	stats := &Stats{
+		TxID:            manifest.ID,
+		NumDocs:         manifest.NumDocs,
+		NumDeletedDocs:  manifest.NumDeletedDocs,
+		NumItems:        manifest.NumItems,
+		NumSegments:     len(manifest.Segments),
+		NumSnapshots:    atomic.LoadInt64(&db.numSnapshots),
+		NumTransactions: atomic.LoadInt64(&db.numTransactions),
+		Segments:        make([]SegmentStats, 0, len(manifest.Segments)),
+	}
+
+	if progress := db.MergeProgress(); progress != nil {
+		stats.MergeInProgress = true
+		stats.MergingSegments = progress.Segments
+	}
+
+	for _, segment := range manifest.Segments {
+		stats.Segments = append(stats.Segments, SegmentStats{
+			ID:       segment.ID,
+			UpdateID: segment.UpdateID,
+			Size:     segment.Size(),
+			Meta:     segment.Meta,
+		})
+		stats.Size += segment.Size()
+	}
+	sort.Slice(stats.Segments, func(i, j int) bool { return stats.Segments[i].ID < stats.Segments[j].ID })
+
+	return stats
+}
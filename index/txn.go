@@ -4,6 +4,7 @@
 package index
 
 import (
+	"github.com/acoustid/go-acoustid/util/intset"
 	"github.com/pkg/errors"
 	"go4.org/syncutil"
 )
@@ -19,6 +20,7 @@ type Transaction struct {
 	createdSegments chan *Segment
 }
 
+// MaxBufferedItems is the default number of items a transaction keeps in memory, see Options.MaxBufferedItems.
 const MaxBufferedItems = 10 * 1024 * 1024
 
 var ErrCommitted = errors.New("transaction is already committed")
@@ -38,11 +40,11 @@ func (txn *Transaction) Add(docID uint32, terms []uint32) error {
 	}
 
 	if txn.buffer.Delete(docID) {
-		debugLog.Printf("deleted doc %v from the transaction buffer", docID)
+		txn.db.logger.Log(LogDebug, "deleted doc from the transaction buffer", Field{"doc", docID})
 	}
 
 	txn.buffer.Add(docID, terms)
-	debugLog.Printf("added doc %v to the transaction buffer", docID)
+	txn.db.logger.Log(LogDebug, "added doc to the transaction buffer", Field{"doc", docID})
 
 	txn.flush(false)
 
@@ -55,15 +57,63 @@ func (txn *Transaction) Delete(docID uint32) error {
 	}
 
 	if txn.buffer.Delete(docID) {
-		debugLog.Printf("deleted doc %v from the transaction buffer", docID)
+		txn.db.logger.Log(LogDebug, "deleted doc from the transaction buffer", Field{"doc", docID})
 	}
 
-	txn.manifest.Delete(docID)
+	if txn.manifest.Delete(docID) {
+		txn.db.logger.Log(LogDebug, "deleted doc from the index", Field{"doc", docID})
+	}
 	return nil
 }
 
+// DeleteMany deletes all docs in the set from the index.
+func (txn *Transaction) DeleteMany(docs *intset.SparseBitSet) error {
+	if txn.Committed() {
+		return ErrCommitted
+	}
+
+	if txn.buffer.DeleteMulti(docs) {
+		txn.db.logger.Log(LogDebug, "deleted docs from the transaction buffer")
+	}
+
+	txn.manifest.DeleteMulti(docs)
+	return nil
+}
+
+// DeleteRange deletes all docs between min and max, inclusive, from the index.
+func (txn *Transaction) DeleteRange(min, max uint32) error {
+	if txn.Committed() {
+		return ErrCommitted
+	}
+
+	if txn.buffer.DeleteRange(min, max) {
+		txn.db.logger.Log(LogDebug, "deleted docs from the transaction buffer", Field{"min_doc", min}, Field{"max_doc", max})
+	}
+
+	txn.manifest.DeleteRange(min, max)
+	return nil
+}
+
+// SetAttribute sets an index attribute. The change is committed atomically with the rest of the transaction.
+// Setting an empty value removes the attribute.
+func (txn *Transaction) SetAttribute(name, value string) error {
+	if txn.Committed() {
+		return ErrCommitted
+	}
+	if name == "" {
+		return errors.New("empty attribute name")
+	}
+	txn.manifest.SetAttribute(name, value)
+	return nil
+}
+
+// Attribute returns the value of an index attribute, including uncommitted changes made in the transaction.
+func (txn *Transaction) Attribute(name string) string {
+	return txn.manifest.Attribute(name)
+}
+
 func (txn *Transaction) Import(input ItemReader) error {
-	segment, err := txn.db.createSegment(input)
+	segment, err := txn.db.createSegment(input, nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to create a new segment")
 	}
@@ -78,14 +128,14 @@ func (txn *Transaction) createSegmentAsync(buffer *ItemBuffer) {
 		select {
 		case segment := <-txn.createdSegments:
 			txn.manifest.AddSegment(segment)
-			debugLog.Printf("added asynchronously created segment %v", segment.ID)
+			txn.db.logger.Log(LogDebug, "added asynchronously created segment", Field{"segment", segment.ID})
 		default:
 			done = true
 		}
 	}
 
 	txn.writers.Go(func() error {
-		segment, err := txn.db.createSegment(buffer.Reader())
+		segment, err := txn.db.createSegment(buffer.Reader(), nil)
 		if err != nil {
 			return errors.Wrap(err, "failed to create a new segment")
 		}
@@ -95,7 +145,10 @@ func (txn *Transaction) createSegmentAsync(buffer *ItemBuffer) {
 }
 
 func (txn *Transaction) flush(force bool) bool {
-	n := MaxBufferedItems
+	n := txn.db.opts.MaxBufferedItems
+	if n <= 0 {
+		n = MaxBufferedItems
+	}
 	if force {
 		n = 0
 	}
@@ -115,7 +168,7 @@ func (txn *Transaction) waitForWriters() error {
 		select {
 		case segment := <-txn.createdSegments:
 			txn.manifest.AddSegment(segment)
-			debugLog.Printf("added asynchronously created segment %v", segment.ID)
+			txn.db.logger.Log(LogDebug, "added asynchronously created segment", Field{"segment", segment.ID})
 		case err := <-done:
 			return err
 		}
@@ -0,0 +1,42 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package util
+
+// BitReader reads a sequence of bit-packed values from a byte slice, in the same layout as
+// written by the PackUint*Slice functions, without unpacking the whole slice at once.
+// The zero value reads from an empty slice.
+type BitReader struct {
+	data []byte
+	buf  uint64
+	n    uint
+}
+
+// NewBitReader creates a new reader for the data.
+func NewBitReader(data []byte) *BitReader {
+	return &BitReader{data: data}
+}
+
+// Reset makes the reader read from the beginning of new data.
+func (r *BitReader) Reset(data []byte) {
+	r.data = data
+	r.buf = 0
+	r.n = 0
+}
+
+// Read returns the next value with the given number of bits, which must be between 1 and 32.
+// It returns false if there is not enough data left.
+func (r *BitReader) Read(bits uint) (uint32, bool) {
+	for r.n < bits {
+		if len(r.data) == 0 {
+			return 0, false
+		}
+		r.buf |= uint64(r.data[0]) << r.n
+		r.data = r.data[1:]
+		r.n += 8
+	}
+	value := uint32(r.buf & (1<<bits - 1))
+	r.buf >>= bits
+	r.n -= bits
+	return value, true
+}
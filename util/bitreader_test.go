@@ -0,0 +1,46 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestBitReader(t *testing.T) {
+	r := rand.New(rand.NewSource(1234))
+	for _, bits := range []uint{1, 3, 5, 8, 13, 32} {
+		values := make([]uint32, 100)
+		for i := range values {
+			values[i] = r.Uint32() & uint32(1<<bits-1)
+		}
+		data := make([]byte, (len(values)*int(bits)+7)/8)
+		PackBits(int(bits), data, values)
+
+		reader := NewBitReader(data)
+		for i, expected := range values {
+			value, ok := reader.Read(bits)
+			require.True(t, ok, "bits=%d i=%d", bits, i)
+			require.Equal(t, expected, value, "bits=%d i=%d", bits, i)
+		}
+		padding := len(data)*8 - len(values)*int(bits)
+		if padding < int(bits) {
+			_, ok := reader.Read(bits)
+			assert.False(t, ok, "bits=%d", bits)
+		}
+
+		reader.Reset(data)
+		value, ok := reader.Read(bits)
+		assert.True(t, ok)
+		assert.Equal(t, values[0], value)
+	}
+}
+
+func TestBitReader_Empty(t *testing.T) {
+	var reader BitReader
+	_, ok := reader.Read(1)
+	assert.False(t, ok)
+}
@@ -7,7 +7,7 @@ package main
 
 import (
 	"fmt"
-	"io"
+	"go/format"
 	"log"
 	"os"
 	"strings"
@@ -59,6 +59,12 @@ func genUnpackIntArray(bits int) string {
 	lines = append(lines, fmt.Sprintf("// UnpackUint%dSlice converts a bit-packed uint%d slice to an uint8 slice.", bits, bits))
 	lines = append(lines, fmt.Sprintf("func UnpackUint%dSlice(src []byte) []uint8 {", bits))
 	lines = append(lines, fmt.Sprintf("\tdst := make([]uint8, (len(src)*8)/%d)", bits))
+	lines = append(lines, fmt.Sprintf("\tUnpackUint%dSliceTo(dst, src)", bits))
+	lines = append(lines, "\treturn dst", "}")
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("// UnpackUint%dSliceTo converts a bit-packed uint%d slice to an uint8 slice without allocating memory.", bits, bits))
+	lines = append(lines, fmt.Sprintf("// The dst slice must have room for (len(src)*8)/%d values. It returns the number of values written.", bits))
+	lines = append(lines, fmt.Sprintf("func UnpackUint%dSliceTo(dst []uint8, src []byte) int {", bits))
 	lines = append(lines, fmt.Sprintf("\tn := 0"))
 	if sblock == 1 {
 		lines = append(lines, fmt.Sprintf("\tfor _, val := range src {"))
@@ -77,7 +83,7 @@ func genUnpackIntArray(bits int) string {
 		}
 		lines = append(lines, "\t}")
 	}
-	lines = append(lines, "\treturn dst", "}")
+	lines = append(lines, "\treturn n", "}")
 	return strings.Join(lines, "\n")
 }
 
@@ -153,21 +159,164 @@ func genPackIntArray(bits int) string {
 	return strings.Join(lines, "\n")
 }
 
-func main() {
-	file, err := os.Create("pack.go")
+func genPackUint32Group(bits int, lines []string, src, dst string) []string {
+	for j := 0; j < bits; j++ {
+		var terms []string
+		for i := 0; i < 8; i++ {
+			if bits*i >= 8*j+8 || bits*i+bits <= 8*j {
+				continue
+			}
+			shift := bits*i - 8*j
+			switch {
+			case shift > 0:
+				terms = append(terms, fmt.Sprintf("uint8(%s[%d]<<%d)", src, i, shift))
+			case shift < 0:
+				terms = append(terms, fmt.Sprintf("uint8(%s[%d]>>%d)", src, i, -shift))
+			default:
+				terms = append(terms, fmt.Sprintf("uint8(%s[%d])", src, i))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("\t\t%s[%d] = %s", dst, j, strings.Join(terms, " | ")))
+	}
+	return lines
+}
+
+func genUnpackUint32Group(bits int, lines []string, src, dst string) []string {
+	for i := 0; i < 8; i++ {
+		var terms []string
+		for j := 0; j < bits; j++ {
+			if bits*i >= 8*j+8 || bits*i+bits <= 8*j {
+				continue
+			}
+			shift := 8*j - bits*i
+			switch {
+			case shift > 0:
+				terms = append(terms, fmt.Sprintf("uint32(%s[%d])<<%d", src, j, shift))
+			case shift < 0:
+				terms = append(terms, fmt.Sprintf("uint32(%s[%d])>>%d", src, j, -shift))
+			default:
+				terms = append(terms, fmt.Sprintf("uint32(%s[%d])", src, j))
+			}
+		}
+		expr := strings.Join(terms, " | ")
+		if bits < 32 {
+			if len(terms) > 1 {
+				expr = "(" + expr + ")"
+			}
+			expr = fmt.Sprintf("%s & %d", expr, (uint64(1)<<uint(bits))-1)
+		}
+		lines = append(lines, fmt.Sprintf("\t\t%s[%d] = %s", dst, i, expr))
+	}
+	return lines
+}
+
+func genPackUint32Slice(bits int) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("// PackUint%dUint32Slice packs the lowest %d bits of each value of src into dst and returns the number of bytes written.", bits, bits))
+	lines = append(lines, fmt.Sprintf("// The dst slice must have room for (len(src)*%d+7)/8 bytes and the values must fit in %d bits.", bits, bits))
+	lines = append(lines, fmt.Sprintf("func PackUint%dUint32Slice(dst []byte, src []uint32) int {", bits))
+	lines = append(lines, "\tn := 0")
+	lines = append(lines, "\tfor len(src) >= 8 {")
+	lines = append(lines, "\t\ts := src[:8:len(src)]")
+	lines = append(lines, fmt.Sprintf("\t\td := dst[n : n+%d : len(dst)]", bits))
+	lines = genPackUint32Group(bits, lines, "s", "d")
+	lines = append(lines, fmt.Sprintf("\t\tn += %d", bits))
+	lines = append(lines, "\t\tsrc = src[8:]")
+	lines = append(lines, "\t}")
+	lines = append(lines, "\tif len(src) > 0 {")
+	lines = append(lines, "\t\tvar s [8]uint32")
+	lines = append(lines, fmt.Sprintf("\t\tvar d [%d]byte", bits))
+	lines = append(lines, "\t\tcopy(s[:], src)")
+	lines = genPackUint32Group(bits, lines, "s", "d")
+	lines = append(lines, fmt.Sprintf("\t\tn += copy(dst[n:], d[:(len(src)*%d+7)/8])", bits))
+	lines = append(lines, "\t}")
+	lines = append(lines, "\treturn n", "}")
+	return strings.Join(lines, "\n")
+}
+
+func genUnpackUint32Slice(bits int) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("// UnpackUint%dUint32Slice unpacks len(dst) %d-bit values from src and returns the number of bytes read.", bits, bits))
+	lines = append(lines, fmt.Sprintf("// The src slice must contain at least (len(dst)*%d+7)/8 bytes.", bits))
+	lines = append(lines, fmt.Sprintf("func UnpackUint%dUint32Slice(dst []uint32, src []byte) int {", bits))
+	lines = append(lines, "\tn := 0")
+	lines = append(lines, "\tfor len(dst) >= 8 {")
+	lines = append(lines, fmt.Sprintf("\t\ts := src[n : n+%d : len(src)]", bits))
+	lines = append(lines, "\t\td := dst[:8:len(dst)]")
+	lines = genUnpackUint32Group(bits, lines, "s", "d")
+	lines = append(lines, fmt.Sprintf("\t\tn += %d", bits))
+	lines = append(lines, "\t\tdst = dst[8:]")
+	lines = append(lines, "\t}")
+	lines = append(lines, "\tif len(dst) > 0 {")
+	lines = append(lines, fmt.Sprintf("\t\tvar s [%d]byte", bits))
+	lines = append(lines, "\t\tvar d [8]uint32")
+	lines = append(lines, fmt.Sprintf("\t\tm := copy(s[:], src[n:n+(len(dst)*%d+7)/8])", bits))
+	lines = genUnpackUint32Group(bits, lines, "s", "d")
+	lines = append(lines, "\t\tcopy(dst, d[:])")
+	lines = append(lines, "\t\tn += m")
+	lines = append(lines, "\t}")
+	lines = append(lines, "\treturn n", "}")
+	return strings.Join(lines, "\n")
+}
+
+func genPackBits(maxBits int) string {
+	var lines []string
+	lines = append(lines, "// PackBits packs the lowest bits of each value of src into dst using PackUint<bits>Uint32Slice.")
+	lines = append(lines, "// Nothing is written if bits is zero.")
+	lines = append(lines, "func PackBits(bits int, dst []byte, src []uint32) int {")
+	lines = append(lines, "\tswitch bits {")
+	for i := 1; i <= maxBits; i++ {
+		lines = append(lines, fmt.Sprintf("\tcase %d:", i))
+		lines = append(lines, fmt.Sprintf("\t\treturn PackUint%dUint32Slice(dst, src)", i))
+	}
+	lines = append(lines, "\t}")
+	lines = append(lines, "\treturn 0", "}")
+	lines = append(lines, "")
+	lines = append(lines, "// UnpackBits unpacks len(dst) values from src using UnpackUint<bits>Uint32Slice.")
+	lines = append(lines, "// All values are zero if bits is zero.")
+	lines = append(lines, "func UnpackBits(bits int, dst []uint32, src []byte) int {")
+	lines = append(lines, "\tswitch bits {")
+	for i := 1; i <= maxBits; i++ {
+		lines = append(lines, fmt.Sprintf("\tcase %d:", i))
+		lines = append(lines, fmt.Sprintf("\t\treturn UnpackUint%dUint32Slice(dst, src)", i))
+	}
+	lines = append(lines, "\t}")
+	lines = append(lines, "\tfor i := range dst {", "\t\tdst[i] = 0", "\t}")
+	lines = append(lines, "\treturn 0", "}")
+	return strings.Join(lines, "\n")
+}
+
+const header = "// Copyright (C) 2016  Lukas Lalinsky\n" +
+	"// Distributed under the MIT license, see the LICENSE file for details.\n\n" +
+	"// THIS FILE WAS AUTOMATICALLY GENERATED, DO NOT EDIT\n\n" +
+	"package util"
+
+func writeFile(name string, sections []string) {
+	file, err := os.Create(name)
 	if err != nil {
 		log.Fatalf("failed to create output file: %v", err)
 	}
 	defer file.Close()
-	sections := []string{
-		"// Copyright (C) 2016  Lukas Lalinsky\n" +
-			"// Distributed under the MIT license, see the LICENSE file for details.\n\n" +
-			"// THIS FILE WAS AUTOMATICALLY GENERATED, DO NOT EDIT\n\n" +
-			"package util",
+	source, err := format.Source([]byte(strings.Join(sections, "\n\n")))
+	if err != nil {
+		log.Fatalf("failed to format the generated code: %v", err)
 	}
+	file.Write(source)
+}
+
+func main() {
+	sections := []string{header}
 	for i := 1; i < 8; i++ {
 		sections = append(sections, genPackIntArray(i))
 		sections = append(sections, genUnpackIntArray(i))
 	}
-	io.WriteString(file, strings.Join(sections, "\n\n"))
+	writeFile("pack.go", sections)
+
+	sections = []string{header}
+	for i := 1; i <= 32; i++ {
+		sections = append(sections, genPackUint32Slice(i))
+		sections = append(sections, genUnpackUint32Slice(i))
+	}
+	sections = append(sections, genPackBits(32))
+	writeFile("pack32.go", sections)
 }
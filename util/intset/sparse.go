@@ -124,6 +124,54 @@ func (s *SparseBitSet) Intersection(s2 *SparseBitSet) (*SparseBitSet, int) {
 	return s3, n
 }
 
+// Range returns a new set with all elements of s that are between min and max, inclusive.
+func (s *SparseBitSet) Range(min, max uint32) *SparseBitSet {
+	s2 := NewSparseBitSet(0)
+	if min > max {
+		return s2
+	}
+	for i, block := range s.blocks {
+		if i < min/blockBits || i > max/blockBits {
+			continue
+		}
+		block2 := make([]uint64, blockWords)
+		for j, word := range block {
+			lo := uint64(i)*blockBits + uint64(j)*wordBits
+			hi := lo + wordBits - 1
+			if hi < uint64(min) || lo > uint64(max) {
+				continue
+			}
+			if lo < uint64(min) {
+				word &= ^uint64(0) << (uint64(min) - lo)
+			}
+			if hi > uint64(max) {
+				word &= ^uint64(0) >> (hi - uint64(max))
+			}
+			block2[j] = word
+		}
+		if util.PopCount64Slice(block2) != 0 {
+			s2.blocks[i] = block2
+		}
+	}
+	return s2
+}
+
+// ForEach calls fn for each element of the set, in no particular order.
+func (s *SparseBitSet) ForEach(fn func(x uint32)) {
+	for i, block := range s.blocks {
+		for j, word := range block {
+			if word == 0 {
+				continue
+			}
+			for k := 0; k < wordBits; k++ {
+				if word&(uint64(1)<<uint(k)) != 0 {
+					fn(i*blockBits + uint32(j)*wordBits + uint32(k))
+				}
+			}
+		}
+	}
+}
+
 // Len computes the number of elements in the set. It executes in time proportional to the number of elements.
 func (s *SparseBitSet) Len() int {
 	var n int
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -105,3 +106,36 @@ func BenchmarkSparseBitSet_Add(b *testing.B) {
 		}
 	}
 }
+
+func TestSparseBitSet_Range(t *testing.T) {
+	s := NewSparseBitSet(0)
+	data := []uint32{0, 1, 63, 64, 65, 8191, 8192, 10000, 1 << 31, math.MaxUint32}
+	for _, x := range data {
+		s.Add(x)
+	}
+	ranges := [][2]uint32{{0, 0}, {1, 64}, {64, 8192}, {2, 62}, {8192, math.MaxUint32}, {0, math.MaxUint32}, {10, 5}}
+	for _, r := range ranges {
+		s2 := s.Range(r[0], r[1])
+		n := 0
+		for _, x := range data {
+			if x >= r[0] && x <= r[1] {
+				require.True(t, s2.Contains(x), "range %v should contain %v", r, x)
+				n++
+			}
+		}
+		require.Equal(t, n, s2.Len(), "range %v has unexpected size", r)
+	}
+}
+
+func TestSparseBitSet_ForEach(t *testing.T) {
+	s := NewSparseBitSet(0)
+	expected := make(map[uint32]bool)
+	for i := 0; i < 1024; i++ {
+		x := rand.Uint32()
+		s.Add(x)
+		expected[x] = true
+	}
+	actual := make(map[uint32]bool)
+	s.ForEach(func(x uint32) { actual[x] = true })
+	require.Equal(t, expected, actual)
+}
@@ -0,0 +1,284 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package metrics provides a minimal set of metric types that can be exported in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"go4.org/sort"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are histogram buckets suitable for measuring latencies in seconds.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer, name string)
+}
+
+type entry struct {
+	name   string
+	help   string
+	kind   string
+	metric metric
+}
+
+// Registry is a collection of named metrics.
+type Registry struct {
+	mu      sync.Mutex
+	entries []entry
+	names   map[string]struct{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+func (r *Registry) register(name, help, kind string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.names[name]; exists {
+		panic(fmt.Sprintf("metric %q is already registered", name))
+	}
+	r.names[name] = struct{}{}
+	r.entries = append(r.entries, entry{name: name, help: help, kind: kind, metric: m})
+}
+
+// Register adds existing metrics to the registry. It is used to export metrics that are owned by another object.
+func (r *Registry) Register(name, help string, m interface{}) {
+	switch m := m.(type) {
+	case *Counter:
+		r.register(name, help, "counter", m)
+	case *Gauge:
+		r.register(name, help, "gauge", m)
+	case *Histogram:
+		r.register(name, help, "histogram", m)
+	default:
+		panic(fmt.Sprintf("unsupported metric type %T", m))
+	}
+}
+
+// Counter creates and registers a new counter.
+func (r *Registry) Counter(name, help string) *Counter {
+	c := new(Counter)
+	r.register(name, help, "counter", c)
+	return c
+}
+
+// Gauge creates and registers a new gauge.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	g := new(Gauge)
+	r.register(name, help, "gauge", g)
+	return g
+}
+
+// GaugeFunc registers a gauge whose value is computed by fn every time the metrics are exported.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, help, "gauge", gaugeFunc(fn))
+}
+
+// GaugeVecFunc registers a gauge with one label, whose values are computed by fn every time the metrics are exported.
+// The keys of the returned map are the label values.
+func (r *Registry) GaugeVecFunc(name, help, label string, fn func() map[string]float64) {
+	r.register(name, help, "gauge", &gaugeVecFunc{label: label, fn: fn})
+}
+
+// Histogram creates and registers a new histogram with the given upper bounds of buckets.
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	h := NewHistogram(buckets)
+	r.register(name, help, "histogram", h)
+	return h
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	entries := make([]entry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	writer := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(writer, "# HELP %s %s\n", e.name, escapeHelp(e.help))
+		fmt.Fprintf(writer, "# TYPE %s %s\n", e.name, e.kind)
+		e.metric.write(writer, e.name)
+	}
+	err := writer.Flush()
+	if err != nil {
+		return errors.Wrap(err, "write failed")
+	}
+	return nil
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	bits uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increases the counter by v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&c.bits)
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&c.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+func (c *Counter) write(w *bufio.Writer, name string) {
+	writeSample(w, name, "", c.Value())
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits uint64
+}
+
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *Gauge) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&g.bits, old, next) {
+			return
+		}
+	}
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func (g *Gauge) write(w *bufio.Writer, name string) {
+	writeSample(w, name, "", g.Value())
+}
+
+type gaugeFunc func() float64
+
+func (fn gaugeFunc) write(w *bufio.Writer, name string) {
+	writeSample(w, name, "", fn())
+}
+
+type gaugeVecFunc struct {
+	label string
+	fn    func() map[string]float64
+}
+
+func (g *gaugeVecFunc) write(w *bufio.Writer, name string) {
+	values := g.fn()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeSample(w, name, formatLabel(g.label, key), values[key])
+	}
+}
+
+// Histogram counts observed values in configurable buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// NewHistogram creates a histogram that is not registered in any registry.
+func NewHistogram(buckets []float64) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// Count returns the number of observed values.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w *bufio.Writer, name string) {
+	h.mu.Lock()
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		writeSample(w, name+"_bucket", formatLabel("le", formatValue(upper)), float64(counts[i]))
+	}
+	writeSample(w, name+"_bucket", formatLabel("le", "+Inf"), float64(count))
+	writeSample(w, name+"_sum", "", sum)
+	writeSample(w, name+"_count", "", float64(count))
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+func formatLabel(name, value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return name + `="` + value + `"`
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.Counter("requests_total", "Total number of requests.")
+	c.Inc()
+	c.Add(2)
+
+	g := r.Gauge("temperature", "Current temperature.")
+	g.Set(21.5)
+	g.Add(-1)
+
+	r.GaugeFunc("answer", "The answer.", func() float64 { return 42 })
+	r.GaugeVecFunc("size_bytes", "Size of \"things\".", "name", func() map[string]float64 {
+		return map[string]float64{"b": 2, "a\"": 1}
+	})
+
+	h := r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+
+	expected := `# HELP requests_total Total number of requests.
+# TYPE requests_total counter
+requests_total 3
+# HELP temperature Current temperature.
+# TYPE temperature gauge
+temperature 20.5
+# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+# HELP size_bytes Size of "things".
+# TYPE size_bytes gauge
+size_bytes{name="a\""} 1
+size_bytes{name="b"} 2
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_Duplicate(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "")
+	assert.Panics(t, func() { r.Counter("requests_total", "") })
+}
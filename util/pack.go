@@ -50,6 +50,13 @@ func PackUint1Slice(dst []byte, src []uint8) int {
 // UnpackUint1Slice converts a bit-packed uint1 slice to an uint8 slice.
 func UnpackUint1Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/1)
+	UnpackUint1SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint1SliceTo converts a bit-packed uint1 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/1 values. It returns the number of values written.
+func UnpackUint1SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for _, val := range src {
 		d := dst[n : n+8 : len(dst)]
@@ -63,7 +70,7 @@ func UnpackUint1Slice(src []byte) []uint8 {
 		d[7] = uint8((val >> 7) & 1)
 		n += 8
 	}
-	return dst
+	return n
 }
 
 // PackUint2Slice converts an uint8 slice into a bit-packed uint2 slice.
@@ -95,6 +102,13 @@ func PackUint2Slice(dst []byte, src []uint8) int {
 // UnpackUint2Slice converts a bit-packed uint2 slice to an uint8 slice.
 func UnpackUint2Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/2)
+	UnpackUint2SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint2SliceTo converts a bit-packed uint2 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/2 values. It returns the number of values written.
+func UnpackUint2SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for _, val := range src {
 		d := dst[n : n+4 : len(dst)]
@@ -104,7 +118,7 @@ func UnpackUint2Slice(src []byte) []uint8 {
 		d[3] = uint8((val >> 6) & 3)
 		n += 4
 	}
-	return dst
+	return n
 }
 
 // PackUint3Slice converts an uint8 slice into a bit-packed uint3 slice.
@@ -167,6 +181,13 @@ func PackUint3Slice(dst []byte, src []uint8) int {
 // UnpackUint3Slice converts a bit-packed uint3 slice to an uint8 slice.
 func UnpackUint3Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/3)
+	UnpackUint3SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint3SliceTo converts a bit-packed uint3 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/3 values. It returns the number of values written.
+func UnpackUint3SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for len(src) >= 3 {
 		val := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16
@@ -199,7 +220,7 @@ func UnpackUint3Slice(src []byte) []uint8 {
 		d[1] = uint8((val >> 3) & 7)
 		n += 2
 	}
-	return dst
+	return n
 }
 
 // PackUint4Slice converts an uint8 slice into a bit-packed uint4 slice.
@@ -223,6 +244,13 @@ func PackUint4Slice(dst []byte, src []uint8) int {
 // UnpackUint4Slice converts a bit-packed uint4 slice to an uint8 slice.
 func UnpackUint4Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/4)
+	UnpackUint4SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint4SliceTo converts a bit-packed uint4 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/4 values. It returns the number of values written.
+func UnpackUint4SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for _, val := range src {
 		d := dst[n : n+2 : len(dst)]
@@ -230,7 +258,7 @@ func UnpackUint4Slice(src []byte) []uint8 {
 		d[1] = uint8((val >> 4) & 15)
 		n += 2
 	}
-	return dst
+	return n
 }
 
 // PackUint5Slice converts an uint8 slice into a bit-packed uint5 slice.
@@ -303,6 +331,13 @@ func PackUint5Slice(dst []byte, src []uint8) int {
 // UnpackUint5Slice converts a bit-packed uint5 slice to an uint8 slice.
 func UnpackUint5Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/5)
+	UnpackUint5SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint5SliceTo converts a bit-packed uint5 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/5 values. It returns the number of values written.
+func UnpackUint5SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for len(src) >= 5 {
 		val := uint64(src[0]) | uint64(src[1])<<8 | uint64(src[2])<<16 | uint64(src[3])<<24 | uint64(src[4])<<32
@@ -350,7 +385,7 @@ func UnpackUint5Slice(src []byte) []uint8 {
 		d[0] = uint8((val >> 0) & 31)
 		n += 1
 	}
-	return dst
+	return n
 }
 
 // PackUint6Slice converts an uint8 slice into a bit-packed uint6 slice.
@@ -390,6 +425,13 @@ func PackUint6Slice(dst []byte, src []uint8) int {
 // UnpackUint6Slice converts a bit-packed uint6 slice to an uint8 slice.
 func UnpackUint6Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/6)
+	UnpackUint6SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint6SliceTo converts a bit-packed uint6 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/6 values. It returns the number of values written.
+func UnpackUint6SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for len(src) >= 3 {
 		val := uint32(src[0]) | uint32(src[1])<<8 | uint32(src[2])<<16
@@ -414,7 +456,7 @@ func UnpackUint6Slice(src []byte) []uint8 {
 		d[0] = uint8((val >> 0) & 63)
 		n += 1
 	}
-	return dst
+	return n
 }
 
 // PackUint7Slice converts an uint8 slice into a bit-packed uint7 slice.
@@ -496,6 +538,13 @@ func PackUint7Slice(dst []byte, src []uint8) int {
 // UnpackUint7Slice converts a bit-packed uint7 slice to an uint8 slice.
 func UnpackUint7Slice(src []byte) []uint8 {
 	dst := make([]uint8, (len(src)*8)/7)
+	UnpackUint7SliceTo(dst, src)
+	return dst
+}
+
+// UnpackUint7SliceTo converts a bit-packed uint7 slice to an uint8 slice without allocating memory.
+// The dst slice must have room for (len(src)*8)/7 values. It returns the number of values written.
+func UnpackUint7SliceTo(dst []uint8, src []byte) int {
 	n := 0
 	for len(src) >= 7 {
 		val := uint64(src[0]) | uint64(src[1])<<8 | uint64(src[2])<<16 | uint64(src[3])<<24 | uint64(src[4])<<32 | uint64(src[5])<<40 | uint64(src[6])<<48
@@ -558,5 +607,5 @@ func UnpackUint7Slice(src []byte) []uint8 {
 		d[0] = uint8((val >> 0) & 127)
 		n += 1
 	}
-	return dst
+	return n
 }
@@ -0,0 +1,2969 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// THIS FILE WAS AUTOMATICALLY GENERATED, DO NOT EDIT
+
+package util
+
+// PackUint1Uint32Slice packs the lowest 1 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*1+7)/8 bytes and the values must fit in 1 bits.
+func PackUint1Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+1 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<1) | uint8(s[2]<<2) | uint8(s[3]<<3) | uint8(s[4]<<4) | uint8(s[5]<<5) | uint8(s[6]<<6) | uint8(s[7]<<7)
+		n += 1
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [1]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<1) | uint8(s[2]<<2) | uint8(s[3]<<3) | uint8(s[4]<<4) | uint8(s[5]<<5) | uint8(s[6]<<6) | uint8(s[7]<<7)
+		n += copy(dst[n:], d[:(len(src)*1+7)/8])
+	}
+	return n
+}
+
+// UnpackUint1Uint32Slice unpacks len(dst) 1-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*1+7)/8 bytes.
+func UnpackUint1Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+1 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 1
+		d[1] = uint32(s[0]) >> 1 & 1
+		d[2] = uint32(s[0]) >> 2 & 1
+		d[3] = uint32(s[0]) >> 3 & 1
+		d[4] = uint32(s[0]) >> 4 & 1
+		d[5] = uint32(s[0]) >> 5 & 1
+		d[6] = uint32(s[0]) >> 6 & 1
+		d[7] = uint32(s[0]) >> 7 & 1
+		n += 1
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [1]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*1+7)/8])
+		d[0] = uint32(s[0]) & 1
+		d[1] = uint32(s[0]) >> 1 & 1
+		d[2] = uint32(s[0]) >> 2 & 1
+		d[3] = uint32(s[0]) >> 3 & 1
+		d[4] = uint32(s[0]) >> 4 & 1
+		d[5] = uint32(s[0]) >> 5 & 1
+		d[6] = uint32(s[0]) >> 6 & 1
+		d[7] = uint32(s[0]) >> 7 & 1
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint2Uint32Slice packs the lowest 2 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*2+7)/8 bytes and the values must fit in 2 bits.
+func PackUint2Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+2 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<2) | uint8(s[2]<<4) | uint8(s[3]<<6)
+		d[1] = uint8(s[4]) | uint8(s[5]<<2) | uint8(s[6]<<4) | uint8(s[7]<<6)
+		n += 2
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [2]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<2) | uint8(s[2]<<4) | uint8(s[3]<<6)
+		d[1] = uint8(s[4]) | uint8(s[5]<<2) | uint8(s[6]<<4) | uint8(s[7]<<6)
+		n += copy(dst[n:], d[:(len(src)*2+7)/8])
+	}
+	return n
+}
+
+// UnpackUint2Uint32Slice unpacks len(dst) 2-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*2+7)/8 bytes.
+func UnpackUint2Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+2 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 3
+		d[1] = uint32(s[0]) >> 2 & 3
+		d[2] = uint32(s[0]) >> 4 & 3
+		d[3] = uint32(s[0]) >> 6 & 3
+		d[4] = uint32(s[1]) & 3
+		d[5] = uint32(s[1]) >> 2 & 3
+		d[6] = uint32(s[1]) >> 4 & 3
+		d[7] = uint32(s[1]) >> 6 & 3
+		n += 2
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [2]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*2+7)/8])
+		d[0] = uint32(s[0]) & 3
+		d[1] = uint32(s[0]) >> 2 & 3
+		d[2] = uint32(s[0]) >> 4 & 3
+		d[3] = uint32(s[0]) >> 6 & 3
+		d[4] = uint32(s[1]) & 3
+		d[5] = uint32(s[1]) >> 2 & 3
+		d[6] = uint32(s[1]) >> 4 & 3
+		d[7] = uint32(s[1]) >> 6 & 3
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint3Uint32Slice packs the lowest 3 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*3+7)/8 bytes and the values must fit in 3 bits.
+func PackUint3Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+3 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<3) | uint8(s[2]<<6)
+		d[1] = uint8(s[2]>>2) | uint8(s[3]<<1) | uint8(s[4]<<4) | uint8(s[5]<<7)
+		d[2] = uint8(s[5]>>1) | uint8(s[6]<<2) | uint8(s[7]<<5)
+		n += 3
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [3]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<3) | uint8(s[2]<<6)
+		d[1] = uint8(s[2]>>2) | uint8(s[3]<<1) | uint8(s[4]<<4) | uint8(s[5]<<7)
+		d[2] = uint8(s[5]>>1) | uint8(s[6]<<2) | uint8(s[7]<<5)
+		n += copy(dst[n:], d[:(len(src)*3+7)/8])
+	}
+	return n
+}
+
+// UnpackUint3Uint32Slice unpacks len(dst) 3-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*3+7)/8 bytes.
+func UnpackUint3Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+3 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 7
+		d[1] = uint32(s[0]) >> 3 & 7
+		d[2] = (uint32(s[0])>>6 | uint32(s[1])<<2) & 7
+		d[3] = uint32(s[1]) >> 1 & 7
+		d[4] = uint32(s[1]) >> 4 & 7
+		d[5] = (uint32(s[1])>>7 | uint32(s[2])<<1) & 7
+		d[6] = uint32(s[2]) >> 2 & 7
+		d[7] = uint32(s[2]) >> 5 & 7
+		n += 3
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [3]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*3+7)/8])
+		d[0] = uint32(s[0]) & 7
+		d[1] = uint32(s[0]) >> 3 & 7
+		d[2] = (uint32(s[0])>>6 | uint32(s[1])<<2) & 7
+		d[3] = uint32(s[1]) >> 1 & 7
+		d[4] = uint32(s[1]) >> 4 & 7
+		d[5] = (uint32(s[1])>>7 | uint32(s[2])<<1) & 7
+		d[6] = uint32(s[2]) >> 2 & 7
+		d[7] = uint32(s[2]) >> 5 & 7
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint4Uint32Slice packs the lowest 4 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*4+7)/8 bytes and the values must fit in 4 bits.
+func PackUint4Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+4 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<4)
+		d[1] = uint8(s[2]) | uint8(s[3]<<4)
+		d[2] = uint8(s[4]) | uint8(s[5]<<4)
+		d[3] = uint8(s[6]) | uint8(s[7]<<4)
+		n += 4
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [4]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<4)
+		d[1] = uint8(s[2]) | uint8(s[3]<<4)
+		d[2] = uint8(s[4]) | uint8(s[5]<<4)
+		d[3] = uint8(s[6]) | uint8(s[7]<<4)
+		n += copy(dst[n:], d[:(len(src)*4+7)/8])
+	}
+	return n
+}
+
+// UnpackUint4Uint32Slice unpacks len(dst) 4-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*4+7)/8 bytes.
+func UnpackUint4Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+4 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 15
+		d[1] = uint32(s[0]) >> 4 & 15
+		d[2] = uint32(s[1]) & 15
+		d[3] = uint32(s[1]) >> 4 & 15
+		d[4] = uint32(s[2]) & 15
+		d[5] = uint32(s[2]) >> 4 & 15
+		d[6] = uint32(s[3]) & 15
+		d[7] = uint32(s[3]) >> 4 & 15
+		n += 4
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [4]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*4+7)/8])
+		d[0] = uint32(s[0]) & 15
+		d[1] = uint32(s[0]) >> 4 & 15
+		d[2] = uint32(s[1]) & 15
+		d[3] = uint32(s[1]) >> 4 & 15
+		d[4] = uint32(s[2]) & 15
+		d[5] = uint32(s[2]) >> 4 & 15
+		d[6] = uint32(s[3]) & 15
+		d[7] = uint32(s[3]) >> 4 & 15
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint5Uint32Slice packs the lowest 5 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*5+7)/8 bytes and the values must fit in 5 bits.
+func PackUint5Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+5 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<5)
+		d[1] = uint8(s[1]>>3) | uint8(s[2]<<2) | uint8(s[3]<<7)
+		d[2] = uint8(s[3]>>1) | uint8(s[4]<<4)
+		d[3] = uint8(s[4]>>4) | uint8(s[5]<<1) | uint8(s[6]<<6)
+		d[4] = uint8(s[6]>>2) | uint8(s[7]<<3)
+		n += 5
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [5]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<5)
+		d[1] = uint8(s[1]>>3) | uint8(s[2]<<2) | uint8(s[3]<<7)
+		d[2] = uint8(s[3]>>1) | uint8(s[4]<<4)
+		d[3] = uint8(s[4]>>4) | uint8(s[5]<<1) | uint8(s[6]<<6)
+		d[4] = uint8(s[6]>>2) | uint8(s[7]<<3)
+		n += copy(dst[n:], d[:(len(src)*5+7)/8])
+	}
+	return n
+}
+
+// UnpackUint5Uint32Slice unpacks len(dst) 5-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*5+7)/8 bytes.
+func UnpackUint5Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+5 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 31
+		d[1] = (uint32(s[0])>>5 | uint32(s[1])<<3) & 31
+		d[2] = uint32(s[1]) >> 2 & 31
+		d[3] = (uint32(s[1])>>7 | uint32(s[2])<<1) & 31
+		d[4] = (uint32(s[2])>>4 | uint32(s[3])<<4) & 31
+		d[5] = uint32(s[3]) >> 1 & 31
+		d[6] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 31
+		d[7] = uint32(s[4]) >> 3 & 31
+		n += 5
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [5]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*5+7)/8])
+		d[0] = uint32(s[0]) & 31
+		d[1] = (uint32(s[0])>>5 | uint32(s[1])<<3) & 31
+		d[2] = uint32(s[1]) >> 2 & 31
+		d[3] = (uint32(s[1])>>7 | uint32(s[2])<<1) & 31
+		d[4] = (uint32(s[2])>>4 | uint32(s[3])<<4) & 31
+		d[5] = uint32(s[3]) >> 1 & 31
+		d[6] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 31
+		d[7] = uint32(s[4]) >> 3 & 31
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint6Uint32Slice packs the lowest 6 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*6+7)/8 bytes and the values must fit in 6 bits.
+func PackUint6Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+6 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<6)
+		d[1] = uint8(s[1]>>2) | uint8(s[2]<<4)
+		d[2] = uint8(s[2]>>4) | uint8(s[3]<<2)
+		d[3] = uint8(s[4]) | uint8(s[5]<<6)
+		d[4] = uint8(s[5]>>2) | uint8(s[6]<<4)
+		d[5] = uint8(s[6]>>4) | uint8(s[7]<<2)
+		n += 6
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [6]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<6)
+		d[1] = uint8(s[1]>>2) | uint8(s[2]<<4)
+		d[2] = uint8(s[2]>>4) | uint8(s[3]<<2)
+		d[3] = uint8(s[4]) | uint8(s[5]<<6)
+		d[4] = uint8(s[5]>>2) | uint8(s[6]<<4)
+		d[5] = uint8(s[6]>>4) | uint8(s[7]<<2)
+		n += copy(dst[n:], d[:(len(src)*6+7)/8])
+	}
+	return n
+}
+
+// UnpackUint6Uint32Slice unpacks len(dst) 6-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*6+7)/8 bytes.
+func UnpackUint6Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+6 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 63
+		d[1] = (uint32(s[0])>>6 | uint32(s[1])<<2) & 63
+		d[2] = (uint32(s[1])>>4 | uint32(s[2])<<4) & 63
+		d[3] = uint32(s[2]) >> 2 & 63
+		d[4] = uint32(s[3]) & 63
+		d[5] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 63
+		d[6] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 63
+		d[7] = uint32(s[5]) >> 2 & 63
+		n += 6
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [6]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*6+7)/8])
+		d[0] = uint32(s[0]) & 63
+		d[1] = (uint32(s[0])>>6 | uint32(s[1])<<2) & 63
+		d[2] = (uint32(s[1])>>4 | uint32(s[2])<<4) & 63
+		d[3] = uint32(s[2]) >> 2 & 63
+		d[4] = uint32(s[3]) & 63
+		d[5] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 63
+		d[6] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 63
+		d[7] = uint32(s[5]) >> 2 & 63
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint7Uint32Slice packs the lowest 7 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*7+7)/8 bytes and the values must fit in 7 bits.
+func PackUint7Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+7 : len(dst)]
+		d[0] = uint8(s[0]) | uint8(s[1]<<7)
+		d[1] = uint8(s[1]>>1) | uint8(s[2]<<6)
+		d[2] = uint8(s[2]>>2) | uint8(s[3]<<5)
+		d[3] = uint8(s[3]>>3) | uint8(s[4]<<4)
+		d[4] = uint8(s[4]>>4) | uint8(s[5]<<3)
+		d[5] = uint8(s[5]>>5) | uint8(s[6]<<2)
+		d[6] = uint8(s[6]>>6) | uint8(s[7]<<1)
+		n += 7
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [7]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0]) | uint8(s[1]<<7)
+		d[1] = uint8(s[1]>>1) | uint8(s[2]<<6)
+		d[2] = uint8(s[2]>>2) | uint8(s[3]<<5)
+		d[3] = uint8(s[3]>>3) | uint8(s[4]<<4)
+		d[4] = uint8(s[4]>>4) | uint8(s[5]<<3)
+		d[5] = uint8(s[5]>>5) | uint8(s[6]<<2)
+		d[6] = uint8(s[6]>>6) | uint8(s[7]<<1)
+		n += copy(dst[n:], d[:(len(src)*7+7)/8])
+	}
+	return n
+}
+
+// UnpackUint7Uint32Slice unpacks len(dst) 7-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*7+7)/8 bytes.
+func UnpackUint7Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+7 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 127
+		d[1] = (uint32(s[0])>>7 | uint32(s[1])<<1) & 127
+		d[2] = (uint32(s[1])>>6 | uint32(s[2])<<2) & 127
+		d[3] = (uint32(s[2])>>5 | uint32(s[3])<<3) & 127
+		d[4] = (uint32(s[3])>>4 | uint32(s[4])<<4) & 127
+		d[5] = (uint32(s[4])>>3 | uint32(s[5])<<5) & 127
+		d[6] = (uint32(s[5])>>2 | uint32(s[6])<<6) & 127
+		d[7] = uint32(s[6]) >> 1 & 127
+		n += 7
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [7]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*7+7)/8])
+		d[0] = uint32(s[0]) & 127
+		d[1] = (uint32(s[0])>>7 | uint32(s[1])<<1) & 127
+		d[2] = (uint32(s[1])>>6 | uint32(s[2])<<2) & 127
+		d[3] = (uint32(s[2])>>5 | uint32(s[3])<<3) & 127
+		d[4] = (uint32(s[3])>>4 | uint32(s[4])<<4) & 127
+		d[5] = (uint32(s[4])>>3 | uint32(s[5])<<5) & 127
+		d[6] = (uint32(s[5])>>2 | uint32(s[6])<<6) & 127
+		d[7] = uint32(s[6]) >> 1 & 127
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint8Uint32Slice packs the lowest 8 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*8+7)/8 bytes and the values must fit in 8 bits.
+func PackUint8Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+8 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[1])
+		d[2] = uint8(s[2])
+		d[3] = uint8(s[3])
+		d[4] = uint8(s[4])
+		d[5] = uint8(s[5])
+		d[6] = uint8(s[6])
+		d[7] = uint8(s[7])
+		n += 8
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [8]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[1])
+		d[2] = uint8(s[2])
+		d[3] = uint8(s[3])
+		d[4] = uint8(s[4])
+		d[5] = uint8(s[5])
+		d[6] = uint8(s[6])
+		d[7] = uint8(s[7])
+		n += copy(dst[n:], d[:(len(src)*8+7)/8])
+	}
+	return n
+}
+
+// UnpackUint8Uint32Slice unpacks len(dst) 8-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*8+7)/8 bytes.
+func UnpackUint8Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+8 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) & 255
+		d[1] = uint32(s[1]) & 255
+		d[2] = uint32(s[2]) & 255
+		d[3] = uint32(s[3]) & 255
+		d[4] = uint32(s[4]) & 255
+		d[5] = uint32(s[5]) & 255
+		d[6] = uint32(s[6]) & 255
+		d[7] = uint32(s[7]) & 255
+		n += 8
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [8]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*8+7)/8])
+		d[0] = uint32(s[0]) & 255
+		d[1] = uint32(s[1]) & 255
+		d[2] = uint32(s[2]) & 255
+		d[3] = uint32(s[3]) & 255
+		d[4] = uint32(s[4]) & 255
+		d[5] = uint32(s[5]) & 255
+		d[6] = uint32(s[6]) & 255
+		d[7] = uint32(s[7]) & 255
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint9Uint32Slice packs the lowest 9 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*9+7)/8 bytes and the values must fit in 9 bits.
+func PackUint9Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+9 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<1)
+		d[2] = uint8(s[1]>>7) | uint8(s[2]<<2)
+		d[3] = uint8(s[2]>>6) | uint8(s[3]<<3)
+		d[4] = uint8(s[3]>>5) | uint8(s[4]<<4)
+		d[5] = uint8(s[4]>>4) | uint8(s[5]<<5)
+		d[6] = uint8(s[5]>>3) | uint8(s[6]<<6)
+		d[7] = uint8(s[6]>>2) | uint8(s[7]<<7)
+		d[8] = uint8(s[7] >> 1)
+		n += 9
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [9]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<1)
+		d[2] = uint8(s[1]>>7) | uint8(s[2]<<2)
+		d[3] = uint8(s[2]>>6) | uint8(s[3]<<3)
+		d[4] = uint8(s[3]>>5) | uint8(s[4]<<4)
+		d[5] = uint8(s[4]>>4) | uint8(s[5]<<5)
+		d[6] = uint8(s[5]>>3) | uint8(s[6]<<6)
+		d[7] = uint8(s[6]>>2) | uint8(s[7]<<7)
+		d[8] = uint8(s[7] >> 1)
+		n += copy(dst[n:], d[:(len(src)*9+7)/8])
+	}
+	return n
+}
+
+// UnpackUint9Uint32Slice unpacks len(dst) 9-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*9+7)/8 bytes.
+func UnpackUint9Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+9 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 511
+		d[1] = (uint32(s[1])>>1 | uint32(s[2])<<7) & 511
+		d[2] = (uint32(s[2])>>2 | uint32(s[3])<<6) & 511
+		d[3] = (uint32(s[3])>>3 | uint32(s[4])<<5) & 511
+		d[4] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 511
+		d[5] = (uint32(s[5])>>5 | uint32(s[6])<<3) & 511
+		d[6] = (uint32(s[6])>>6 | uint32(s[7])<<2) & 511
+		d[7] = (uint32(s[7])>>7 | uint32(s[8])<<1) & 511
+		n += 9
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [9]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*9+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 511
+		d[1] = (uint32(s[1])>>1 | uint32(s[2])<<7) & 511
+		d[2] = (uint32(s[2])>>2 | uint32(s[3])<<6) & 511
+		d[3] = (uint32(s[3])>>3 | uint32(s[4])<<5) & 511
+		d[4] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 511
+		d[5] = (uint32(s[5])>>5 | uint32(s[6])<<3) & 511
+		d[6] = (uint32(s[6])>>6 | uint32(s[7])<<2) & 511
+		d[7] = (uint32(s[7])>>7 | uint32(s[8])<<1) & 511
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint10Uint32Slice packs the lowest 10 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*10+7)/8 bytes and the values must fit in 10 bits.
+func PackUint10Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+10 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<2)
+		d[2] = uint8(s[1]>>6) | uint8(s[2]<<4)
+		d[3] = uint8(s[2]>>4) | uint8(s[3]<<6)
+		d[4] = uint8(s[3] >> 2)
+		d[5] = uint8(s[4])
+		d[6] = uint8(s[4]>>8) | uint8(s[5]<<2)
+		d[7] = uint8(s[5]>>6) | uint8(s[6]<<4)
+		d[8] = uint8(s[6]>>4) | uint8(s[7]<<6)
+		d[9] = uint8(s[7] >> 2)
+		n += 10
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [10]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<2)
+		d[2] = uint8(s[1]>>6) | uint8(s[2]<<4)
+		d[3] = uint8(s[2]>>4) | uint8(s[3]<<6)
+		d[4] = uint8(s[3] >> 2)
+		d[5] = uint8(s[4])
+		d[6] = uint8(s[4]>>8) | uint8(s[5]<<2)
+		d[7] = uint8(s[5]>>6) | uint8(s[6]<<4)
+		d[8] = uint8(s[6]>>4) | uint8(s[7]<<6)
+		d[9] = uint8(s[7] >> 2)
+		n += copy(dst[n:], d[:(len(src)*10+7)/8])
+	}
+	return n
+}
+
+// UnpackUint10Uint32Slice unpacks len(dst) 10-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*10+7)/8 bytes.
+func UnpackUint10Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+10 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 1023
+		d[1] = (uint32(s[1])>>2 | uint32(s[2])<<6) & 1023
+		d[2] = (uint32(s[2])>>4 | uint32(s[3])<<4) & 1023
+		d[3] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 1023
+		d[4] = (uint32(s[5]) | uint32(s[6])<<8) & 1023
+		d[5] = (uint32(s[6])>>2 | uint32(s[7])<<6) & 1023
+		d[6] = (uint32(s[7])>>4 | uint32(s[8])<<4) & 1023
+		d[7] = (uint32(s[8])>>6 | uint32(s[9])<<2) & 1023
+		n += 10
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [10]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*10+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 1023
+		d[1] = (uint32(s[1])>>2 | uint32(s[2])<<6) & 1023
+		d[2] = (uint32(s[2])>>4 | uint32(s[3])<<4) & 1023
+		d[3] = (uint32(s[3])>>6 | uint32(s[4])<<2) & 1023
+		d[4] = (uint32(s[5]) | uint32(s[6])<<8) & 1023
+		d[5] = (uint32(s[6])>>2 | uint32(s[7])<<6) & 1023
+		d[6] = (uint32(s[7])>>4 | uint32(s[8])<<4) & 1023
+		d[7] = (uint32(s[8])>>6 | uint32(s[9])<<2) & 1023
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint11Uint32Slice packs the lowest 11 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*11+7)/8 bytes and the values must fit in 11 bits.
+func PackUint11Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+11 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<3)
+		d[2] = uint8(s[1]>>5) | uint8(s[2]<<6)
+		d[3] = uint8(s[2] >> 2)
+		d[4] = uint8(s[2]>>10) | uint8(s[3]<<1)
+		d[5] = uint8(s[3]>>7) | uint8(s[4]<<4)
+		d[6] = uint8(s[4]>>4) | uint8(s[5]<<7)
+		d[7] = uint8(s[5] >> 1)
+		d[8] = uint8(s[5]>>9) | uint8(s[6]<<2)
+		d[9] = uint8(s[6]>>6) | uint8(s[7]<<5)
+		d[10] = uint8(s[7] >> 3)
+		n += 11
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [11]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<3)
+		d[2] = uint8(s[1]>>5) | uint8(s[2]<<6)
+		d[3] = uint8(s[2] >> 2)
+		d[4] = uint8(s[2]>>10) | uint8(s[3]<<1)
+		d[5] = uint8(s[3]>>7) | uint8(s[4]<<4)
+		d[6] = uint8(s[4]>>4) | uint8(s[5]<<7)
+		d[7] = uint8(s[5] >> 1)
+		d[8] = uint8(s[5]>>9) | uint8(s[6]<<2)
+		d[9] = uint8(s[6]>>6) | uint8(s[7]<<5)
+		d[10] = uint8(s[7] >> 3)
+		n += copy(dst[n:], d[:(len(src)*11+7)/8])
+	}
+	return n
+}
+
+// UnpackUint11Uint32Slice unpacks len(dst) 11-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*11+7)/8 bytes.
+func UnpackUint11Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+11 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 2047
+		d[1] = (uint32(s[1])>>3 | uint32(s[2])<<5) & 2047
+		d[2] = (uint32(s[2])>>6 | uint32(s[3])<<2 | uint32(s[4])<<10) & 2047
+		d[3] = (uint32(s[4])>>1 | uint32(s[5])<<7) & 2047
+		d[4] = (uint32(s[5])>>4 | uint32(s[6])<<4) & 2047
+		d[5] = (uint32(s[6])>>7 | uint32(s[7])<<1 | uint32(s[8])<<9) & 2047
+		d[6] = (uint32(s[8])>>2 | uint32(s[9])<<6) & 2047
+		d[7] = (uint32(s[9])>>5 | uint32(s[10])<<3) & 2047
+		n += 11
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [11]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*11+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 2047
+		d[1] = (uint32(s[1])>>3 | uint32(s[2])<<5) & 2047
+		d[2] = (uint32(s[2])>>6 | uint32(s[3])<<2 | uint32(s[4])<<10) & 2047
+		d[3] = (uint32(s[4])>>1 | uint32(s[5])<<7) & 2047
+		d[4] = (uint32(s[5])>>4 | uint32(s[6])<<4) & 2047
+		d[5] = (uint32(s[6])>>7 | uint32(s[7])<<1 | uint32(s[8])<<9) & 2047
+		d[6] = (uint32(s[8])>>2 | uint32(s[9])<<6) & 2047
+		d[7] = (uint32(s[9])>>5 | uint32(s[10])<<3) & 2047
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint12Uint32Slice packs the lowest 12 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*12+7)/8 bytes and the values must fit in 12 bits.
+func PackUint12Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+12 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<4)
+		d[2] = uint8(s[1] >> 4)
+		d[3] = uint8(s[2])
+		d[4] = uint8(s[2]>>8) | uint8(s[3]<<4)
+		d[5] = uint8(s[3] >> 4)
+		d[6] = uint8(s[4])
+		d[7] = uint8(s[4]>>8) | uint8(s[5]<<4)
+		d[8] = uint8(s[5] >> 4)
+		d[9] = uint8(s[6])
+		d[10] = uint8(s[6]>>8) | uint8(s[7]<<4)
+		d[11] = uint8(s[7] >> 4)
+		n += 12
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [12]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<4)
+		d[2] = uint8(s[1] >> 4)
+		d[3] = uint8(s[2])
+		d[4] = uint8(s[2]>>8) | uint8(s[3]<<4)
+		d[5] = uint8(s[3] >> 4)
+		d[6] = uint8(s[4])
+		d[7] = uint8(s[4]>>8) | uint8(s[5]<<4)
+		d[8] = uint8(s[5] >> 4)
+		d[9] = uint8(s[6])
+		d[10] = uint8(s[6]>>8) | uint8(s[7]<<4)
+		d[11] = uint8(s[7] >> 4)
+		n += copy(dst[n:], d[:(len(src)*12+7)/8])
+	}
+	return n
+}
+
+// UnpackUint12Uint32Slice unpacks len(dst) 12-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*12+7)/8 bytes.
+func UnpackUint12Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+12 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 4095
+		d[1] = (uint32(s[1])>>4 | uint32(s[2])<<4) & 4095
+		d[2] = (uint32(s[3]) | uint32(s[4])<<8) & 4095
+		d[3] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 4095
+		d[4] = (uint32(s[6]) | uint32(s[7])<<8) & 4095
+		d[5] = (uint32(s[7])>>4 | uint32(s[8])<<4) & 4095
+		d[6] = (uint32(s[9]) | uint32(s[10])<<8) & 4095
+		d[7] = (uint32(s[10])>>4 | uint32(s[11])<<4) & 4095
+		n += 12
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [12]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*12+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 4095
+		d[1] = (uint32(s[1])>>4 | uint32(s[2])<<4) & 4095
+		d[2] = (uint32(s[3]) | uint32(s[4])<<8) & 4095
+		d[3] = (uint32(s[4])>>4 | uint32(s[5])<<4) & 4095
+		d[4] = (uint32(s[6]) | uint32(s[7])<<8) & 4095
+		d[5] = (uint32(s[7])>>4 | uint32(s[8])<<4) & 4095
+		d[6] = (uint32(s[9]) | uint32(s[10])<<8) & 4095
+		d[7] = (uint32(s[10])>>4 | uint32(s[11])<<4) & 4095
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint13Uint32Slice packs the lowest 13 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*13+7)/8 bytes and the values must fit in 13 bits.
+func PackUint13Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+13 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<5)
+		d[2] = uint8(s[1] >> 3)
+		d[3] = uint8(s[1]>>11) | uint8(s[2]<<2)
+		d[4] = uint8(s[2]>>6) | uint8(s[3]<<7)
+		d[5] = uint8(s[3] >> 1)
+		d[6] = uint8(s[3]>>9) | uint8(s[4]<<4)
+		d[7] = uint8(s[4] >> 4)
+		d[8] = uint8(s[4]>>12) | uint8(s[5]<<1)
+		d[9] = uint8(s[5]>>7) | uint8(s[6]<<6)
+		d[10] = uint8(s[6] >> 2)
+		d[11] = uint8(s[6]>>10) | uint8(s[7]<<3)
+		d[12] = uint8(s[7] >> 5)
+		n += 13
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [13]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<5)
+		d[2] = uint8(s[1] >> 3)
+		d[3] = uint8(s[1]>>11) | uint8(s[2]<<2)
+		d[4] = uint8(s[2]>>6) | uint8(s[3]<<7)
+		d[5] = uint8(s[3] >> 1)
+		d[6] = uint8(s[3]>>9) | uint8(s[4]<<4)
+		d[7] = uint8(s[4] >> 4)
+		d[8] = uint8(s[4]>>12) | uint8(s[5]<<1)
+		d[9] = uint8(s[5]>>7) | uint8(s[6]<<6)
+		d[10] = uint8(s[6] >> 2)
+		d[11] = uint8(s[6]>>10) | uint8(s[7]<<3)
+		d[12] = uint8(s[7] >> 5)
+		n += copy(dst[n:], d[:(len(src)*13+7)/8])
+	}
+	return n
+}
+
+// UnpackUint13Uint32Slice unpacks len(dst) 13-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*13+7)/8 bytes.
+func UnpackUint13Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+13 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 8191
+		d[1] = (uint32(s[1])>>5 | uint32(s[2])<<3 | uint32(s[3])<<11) & 8191
+		d[2] = (uint32(s[3])>>2 | uint32(s[4])<<6) & 8191
+		d[3] = (uint32(s[4])>>7 | uint32(s[5])<<1 | uint32(s[6])<<9) & 8191
+		d[4] = (uint32(s[6])>>4 | uint32(s[7])<<4 | uint32(s[8])<<12) & 8191
+		d[5] = (uint32(s[8])>>1 | uint32(s[9])<<7) & 8191
+		d[6] = (uint32(s[9])>>6 | uint32(s[10])<<2 | uint32(s[11])<<10) & 8191
+		d[7] = (uint32(s[11])>>3 | uint32(s[12])<<5) & 8191
+		n += 13
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [13]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*13+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 8191
+		d[1] = (uint32(s[1])>>5 | uint32(s[2])<<3 | uint32(s[3])<<11) & 8191
+		d[2] = (uint32(s[3])>>2 | uint32(s[4])<<6) & 8191
+		d[3] = (uint32(s[4])>>7 | uint32(s[5])<<1 | uint32(s[6])<<9) & 8191
+		d[4] = (uint32(s[6])>>4 | uint32(s[7])<<4 | uint32(s[8])<<12) & 8191
+		d[5] = (uint32(s[8])>>1 | uint32(s[9])<<7) & 8191
+		d[6] = (uint32(s[9])>>6 | uint32(s[10])<<2 | uint32(s[11])<<10) & 8191
+		d[7] = (uint32(s[11])>>3 | uint32(s[12])<<5) & 8191
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint14Uint32Slice packs the lowest 14 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*14+7)/8 bytes and the values must fit in 14 bits.
+func PackUint14Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+14 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<6)
+		d[2] = uint8(s[1] >> 2)
+		d[3] = uint8(s[1]>>10) | uint8(s[2]<<4)
+		d[4] = uint8(s[2] >> 4)
+		d[5] = uint8(s[2]>>12) | uint8(s[3]<<2)
+		d[6] = uint8(s[3] >> 6)
+		d[7] = uint8(s[4])
+		d[8] = uint8(s[4]>>8) | uint8(s[5]<<6)
+		d[9] = uint8(s[5] >> 2)
+		d[10] = uint8(s[5]>>10) | uint8(s[6]<<4)
+		d[11] = uint8(s[6] >> 4)
+		d[12] = uint8(s[6]>>12) | uint8(s[7]<<2)
+		d[13] = uint8(s[7] >> 6)
+		n += 14
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [14]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<6)
+		d[2] = uint8(s[1] >> 2)
+		d[3] = uint8(s[1]>>10) | uint8(s[2]<<4)
+		d[4] = uint8(s[2] >> 4)
+		d[5] = uint8(s[2]>>12) | uint8(s[3]<<2)
+		d[6] = uint8(s[3] >> 6)
+		d[7] = uint8(s[4])
+		d[8] = uint8(s[4]>>8) | uint8(s[5]<<6)
+		d[9] = uint8(s[5] >> 2)
+		d[10] = uint8(s[5]>>10) | uint8(s[6]<<4)
+		d[11] = uint8(s[6] >> 4)
+		d[12] = uint8(s[6]>>12) | uint8(s[7]<<2)
+		d[13] = uint8(s[7] >> 6)
+		n += copy(dst[n:], d[:(len(src)*14+7)/8])
+	}
+	return n
+}
+
+// UnpackUint14Uint32Slice unpacks len(dst) 14-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*14+7)/8 bytes.
+func UnpackUint14Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+14 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 16383
+		d[1] = (uint32(s[1])>>6 | uint32(s[2])<<2 | uint32(s[3])<<10) & 16383
+		d[2] = (uint32(s[3])>>4 | uint32(s[4])<<4 | uint32(s[5])<<12) & 16383
+		d[3] = (uint32(s[5])>>2 | uint32(s[6])<<6) & 16383
+		d[4] = (uint32(s[7]) | uint32(s[8])<<8) & 16383
+		d[5] = (uint32(s[8])>>6 | uint32(s[9])<<2 | uint32(s[10])<<10) & 16383
+		d[6] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12) & 16383
+		d[7] = (uint32(s[12])>>2 | uint32(s[13])<<6) & 16383
+		n += 14
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [14]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*14+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 16383
+		d[1] = (uint32(s[1])>>6 | uint32(s[2])<<2 | uint32(s[3])<<10) & 16383
+		d[2] = (uint32(s[3])>>4 | uint32(s[4])<<4 | uint32(s[5])<<12) & 16383
+		d[3] = (uint32(s[5])>>2 | uint32(s[6])<<6) & 16383
+		d[4] = (uint32(s[7]) | uint32(s[8])<<8) & 16383
+		d[5] = (uint32(s[8])>>6 | uint32(s[9])<<2 | uint32(s[10])<<10) & 16383
+		d[6] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12) & 16383
+		d[7] = (uint32(s[12])>>2 | uint32(s[13])<<6) & 16383
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint15Uint32Slice packs the lowest 15 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*15+7)/8 bytes and the values must fit in 15 bits.
+func PackUint15Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+15 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<7)
+		d[2] = uint8(s[1] >> 1)
+		d[3] = uint8(s[1]>>9) | uint8(s[2]<<6)
+		d[4] = uint8(s[2] >> 2)
+		d[5] = uint8(s[2]>>10) | uint8(s[3]<<5)
+		d[6] = uint8(s[3] >> 3)
+		d[7] = uint8(s[3]>>11) | uint8(s[4]<<4)
+		d[8] = uint8(s[4] >> 4)
+		d[9] = uint8(s[4]>>12) | uint8(s[5]<<3)
+		d[10] = uint8(s[5] >> 5)
+		d[11] = uint8(s[5]>>13) | uint8(s[6]<<2)
+		d[12] = uint8(s[6] >> 6)
+		d[13] = uint8(s[6]>>14) | uint8(s[7]<<1)
+		d[14] = uint8(s[7] >> 7)
+		n += 15
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [15]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0]>>8) | uint8(s[1]<<7)
+		d[2] = uint8(s[1] >> 1)
+		d[3] = uint8(s[1]>>9) | uint8(s[2]<<6)
+		d[4] = uint8(s[2] >> 2)
+		d[5] = uint8(s[2]>>10) | uint8(s[3]<<5)
+		d[6] = uint8(s[3] >> 3)
+		d[7] = uint8(s[3]>>11) | uint8(s[4]<<4)
+		d[8] = uint8(s[4] >> 4)
+		d[9] = uint8(s[4]>>12) | uint8(s[5]<<3)
+		d[10] = uint8(s[5] >> 5)
+		d[11] = uint8(s[5]>>13) | uint8(s[6]<<2)
+		d[12] = uint8(s[6] >> 6)
+		d[13] = uint8(s[6]>>14) | uint8(s[7]<<1)
+		d[14] = uint8(s[7] >> 7)
+		n += copy(dst[n:], d[:(len(src)*15+7)/8])
+	}
+	return n
+}
+
+// UnpackUint15Uint32Slice unpacks len(dst) 15-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*15+7)/8 bytes.
+func UnpackUint15Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+15 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 32767
+		d[1] = (uint32(s[1])>>7 | uint32(s[2])<<1 | uint32(s[3])<<9) & 32767
+		d[2] = (uint32(s[3])>>6 | uint32(s[4])<<2 | uint32(s[5])<<10) & 32767
+		d[3] = (uint32(s[5])>>5 | uint32(s[6])<<3 | uint32(s[7])<<11) & 32767
+		d[4] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12) & 32767
+		d[5] = (uint32(s[9])>>3 | uint32(s[10])<<5 | uint32(s[11])<<13) & 32767
+		d[6] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14) & 32767
+		d[7] = (uint32(s[13])>>1 | uint32(s[14])<<7) & 32767
+		n += 15
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [15]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*15+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 32767
+		d[1] = (uint32(s[1])>>7 | uint32(s[2])<<1 | uint32(s[3])<<9) & 32767
+		d[2] = (uint32(s[3])>>6 | uint32(s[4])<<2 | uint32(s[5])<<10) & 32767
+		d[3] = (uint32(s[5])>>5 | uint32(s[6])<<3 | uint32(s[7])<<11) & 32767
+		d[4] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12) & 32767
+		d[5] = (uint32(s[9])>>3 | uint32(s[10])<<5 | uint32(s[11])<<13) & 32767
+		d[6] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14) & 32767
+		d[7] = (uint32(s[13])>>1 | uint32(s[14])<<7) & 32767
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint16Uint32Slice packs the lowest 16 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*16+7)/8 bytes and the values must fit in 16 bits.
+func PackUint16Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+16 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[1])
+		d[3] = uint8(s[1] >> 8)
+		d[4] = uint8(s[2])
+		d[5] = uint8(s[2] >> 8)
+		d[6] = uint8(s[3])
+		d[7] = uint8(s[3] >> 8)
+		d[8] = uint8(s[4])
+		d[9] = uint8(s[4] >> 8)
+		d[10] = uint8(s[5])
+		d[11] = uint8(s[5] >> 8)
+		d[12] = uint8(s[6])
+		d[13] = uint8(s[6] >> 8)
+		d[14] = uint8(s[7])
+		d[15] = uint8(s[7] >> 8)
+		n += 16
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [16]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[1])
+		d[3] = uint8(s[1] >> 8)
+		d[4] = uint8(s[2])
+		d[5] = uint8(s[2] >> 8)
+		d[6] = uint8(s[3])
+		d[7] = uint8(s[3] >> 8)
+		d[8] = uint8(s[4])
+		d[9] = uint8(s[4] >> 8)
+		d[10] = uint8(s[5])
+		d[11] = uint8(s[5] >> 8)
+		d[12] = uint8(s[6])
+		d[13] = uint8(s[6] >> 8)
+		d[14] = uint8(s[7])
+		d[15] = uint8(s[7] >> 8)
+		n += copy(dst[n:], d[:(len(src)*16+7)/8])
+	}
+	return n
+}
+
+// UnpackUint16Uint32Slice unpacks len(dst) 16-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*16+7)/8 bytes.
+func UnpackUint16Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+16 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 65535
+		d[1] = (uint32(s[2]) | uint32(s[3])<<8) & 65535
+		d[2] = (uint32(s[4]) | uint32(s[5])<<8) & 65535
+		d[3] = (uint32(s[6]) | uint32(s[7])<<8) & 65535
+		d[4] = (uint32(s[8]) | uint32(s[9])<<8) & 65535
+		d[5] = (uint32(s[10]) | uint32(s[11])<<8) & 65535
+		d[6] = (uint32(s[12]) | uint32(s[13])<<8) & 65535
+		d[7] = (uint32(s[14]) | uint32(s[15])<<8) & 65535
+		n += 16
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [16]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*16+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8) & 65535
+		d[1] = (uint32(s[2]) | uint32(s[3])<<8) & 65535
+		d[2] = (uint32(s[4]) | uint32(s[5])<<8) & 65535
+		d[3] = (uint32(s[6]) | uint32(s[7])<<8) & 65535
+		d[4] = (uint32(s[8]) | uint32(s[9])<<8) & 65535
+		d[5] = (uint32(s[10]) | uint32(s[11])<<8) & 65535
+		d[6] = (uint32(s[12]) | uint32(s[13])<<8) & 65535
+		d[7] = (uint32(s[14]) | uint32(s[15])<<8) & 65535
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint17Uint32Slice packs the lowest 17 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*17+7)/8 bytes and the values must fit in 17 bits.
+func PackUint17Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+17 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<1)
+		d[3] = uint8(s[1] >> 7)
+		d[4] = uint8(s[1]>>15) | uint8(s[2]<<2)
+		d[5] = uint8(s[2] >> 6)
+		d[6] = uint8(s[2]>>14) | uint8(s[3]<<3)
+		d[7] = uint8(s[3] >> 5)
+		d[8] = uint8(s[3]>>13) | uint8(s[4]<<4)
+		d[9] = uint8(s[4] >> 4)
+		d[10] = uint8(s[4]>>12) | uint8(s[5]<<5)
+		d[11] = uint8(s[5] >> 3)
+		d[12] = uint8(s[5]>>11) | uint8(s[6]<<6)
+		d[13] = uint8(s[6] >> 2)
+		d[14] = uint8(s[6]>>10) | uint8(s[7]<<7)
+		d[15] = uint8(s[7] >> 1)
+		d[16] = uint8(s[7] >> 9)
+		n += 17
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [17]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<1)
+		d[3] = uint8(s[1] >> 7)
+		d[4] = uint8(s[1]>>15) | uint8(s[2]<<2)
+		d[5] = uint8(s[2] >> 6)
+		d[6] = uint8(s[2]>>14) | uint8(s[3]<<3)
+		d[7] = uint8(s[3] >> 5)
+		d[8] = uint8(s[3]>>13) | uint8(s[4]<<4)
+		d[9] = uint8(s[4] >> 4)
+		d[10] = uint8(s[4]>>12) | uint8(s[5]<<5)
+		d[11] = uint8(s[5] >> 3)
+		d[12] = uint8(s[5]>>11) | uint8(s[6]<<6)
+		d[13] = uint8(s[6] >> 2)
+		d[14] = uint8(s[6]>>10) | uint8(s[7]<<7)
+		d[15] = uint8(s[7] >> 1)
+		d[16] = uint8(s[7] >> 9)
+		n += copy(dst[n:], d[:(len(src)*17+7)/8])
+	}
+	return n
+}
+
+// UnpackUint17Uint32Slice unpacks len(dst) 17-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*17+7)/8 bytes.
+func UnpackUint17Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+17 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 131071
+		d[1] = (uint32(s[2])>>1 | uint32(s[3])<<7 | uint32(s[4])<<15) & 131071
+		d[2] = (uint32(s[4])>>2 | uint32(s[5])<<6 | uint32(s[6])<<14) & 131071
+		d[3] = (uint32(s[6])>>3 | uint32(s[7])<<5 | uint32(s[8])<<13) & 131071
+		d[4] = (uint32(s[8])>>4 | uint32(s[9])<<4 | uint32(s[10])<<12) & 131071
+		d[5] = (uint32(s[10])>>5 | uint32(s[11])<<3 | uint32(s[12])<<11) & 131071
+		d[6] = (uint32(s[12])>>6 | uint32(s[13])<<2 | uint32(s[14])<<10) & 131071
+		d[7] = (uint32(s[14])>>7 | uint32(s[15])<<1 | uint32(s[16])<<9) & 131071
+		n += 17
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [17]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*17+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 131071
+		d[1] = (uint32(s[2])>>1 | uint32(s[3])<<7 | uint32(s[4])<<15) & 131071
+		d[2] = (uint32(s[4])>>2 | uint32(s[5])<<6 | uint32(s[6])<<14) & 131071
+		d[3] = (uint32(s[6])>>3 | uint32(s[7])<<5 | uint32(s[8])<<13) & 131071
+		d[4] = (uint32(s[8])>>4 | uint32(s[9])<<4 | uint32(s[10])<<12) & 131071
+		d[5] = (uint32(s[10])>>5 | uint32(s[11])<<3 | uint32(s[12])<<11) & 131071
+		d[6] = (uint32(s[12])>>6 | uint32(s[13])<<2 | uint32(s[14])<<10) & 131071
+		d[7] = (uint32(s[14])>>7 | uint32(s[15])<<1 | uint32(s[16])<<9) & 131071
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint18Uint32Slice packs the lowest 18 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*18+7)/8 bytes and the values must fit in 18 bits.
+func PackUint18Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+18 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<2)
+		d[3] = uint8(s[1] >> 6)
+		d[4] = uint8(s[1]>>14) | uint8(s[2]<<4)
+		d[5] = uint8(s[2] >> 4)
+		d[6] = uint8(s[2]>>12) | uint8(s[3]<<6)
+		d[7] = uint8(s[3] >> 2)
+		d[8] = uint8(s[3] >> 10)
+		d[9] = uint8(s[4])
+		d[10] = uint8(s[4] >> 8)
+		d[11] = uint8(s[4]>>16) | uint8(s[5]<<2)
+		d[12] = uint8(s[5] >> 6)
+		d[13] = uint8(s[5]>>14) | uint8(s[6]<<4)
+		d[14] = uint8(s[6] >> 4)
+		d[15] = uint8(s[6]>>12) | uint8(s[7]<<6)
+		d[16] = uint8(s[7] >> 2)
+		d[17] = uint8(s[7] >> 10)
+		n += 18
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [18]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<2)
+		d[3] = uint8(s[1] >> 6)
+		d[4] = uint8(s[1]>>14) | uint8(s[2]<<4)
+		d[5] = uint8(s[2] >> 4)
+		d[6] = uint8(s[2]>>12) | uint8(s[3]<<6)
+		d[7] = uint8(s[3] >> 2)
+		d[8] = uint8(s[3] >> 10)
+		d[9] = uint8(s[4])
+		d[10] = uint8(s[4] >> 8)
+		d[11] = uint8(s[4]>>16) | uint8(s[5]<<2)
+		d[12] = uint8(s[5] >> 6)
+		d[13] = uint8(s[5]>>14) | uint8(s[6]<<4)
+		d[14] = uint8(s[6] >> 4)
+		d[15] = uint8(s[6]>>12) | uint8(s[7]<<6)
+		d[16] = uint8(s[7] >> 2)
+		d[17] = uint8(s[7] >> 10)
+		n += copy(dst[n:], d[:(len(src)*18+7)/8])
+	}
+	return n
+}
+
+// UnpackUint18Uint32Slice unpacks len(dst) 18-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*18+7)/8 bytes.
+func UnpackUint18Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+18 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 262143
+		d[1] = (uint32(s[2])>>2 | uint32(s[3])<<6 | uint32(s[4])<<14) & 262143
+		d[2] = (uint32(s[4])>>4 | uint32(s[5])<<4 | uint32(s[6])<<12) & 262143
+		d[3] = (uint32(s[6])>>6 | uint32(s[7])<<2 | uint32(s[8])<<10) & 262143
+		d[4] = (uint32(s[9]) | uint32(s[10])<<8 | uint32(s[11])<<16) & 262143
+		d[5] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14) & 262143
+		d[6] = (uint32(s[13])>>4 | uint32(s[14])<<4 | uint32(s[15])<<12) & 262143
+		d[7] = (uint32(s[15])>>6 | uint32(s[16])<<2 | uint32(s[17])<<10) & 262143
+		n += 18
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [18]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*18+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 262143
+		d[1] = (uint32(s[2])>>2 | uint32(s[3])<<6 | uint32(s[4])<<14) & 262143
+		d[2] = (uint32(s[4])>>4 | uint32(s[5])<<4 | uint32(s[6])<<12) & 262143
+		d[3] = (uint32(s[6])>>6 | uint32(s[7])<<2 | uint32(s[8])<<10) & 262143
+		d[4] = (uint32(s[9]) | uint32(s[10])<<8 | uint32(s[11])<<16) & 262143
+		d[5] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14) & 262143
+		d[6] = (uint32(s[13])>>4 | uint32(s[14])<<4 | uint32(s[15])<<12) & 262143
+		d[7] = (uint32(s[15])>>6 | uint32(s[16])<<2 | uint32(s[17])<<10) & 262143
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint19Uint32Slice packs the lowest 19 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*19+7)/8 bytes and the values must fit in 19 bits.
+func PackUint19Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+19 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<3)
+		d[3] = uint8(s[1] >> 5)
+		d[4] = uint8(s[1]>>13) | uint8(s[2]<<6)
+		d[5] = uint8(s[2] >> 2)
+		d[6] = uint8(s[2] >> 10)
+		d[7] = uint8(s[2]>>18) | uint8(s[3]<<1)
+		d[8] = uint8(s[3] >> 7)
+		d[9] = uint8(s[3]>>15) | uint8(s[4]<<4)
+		d[10] = uint8(s[4] >> 4)
+		d[11] = uint8(s[4]>>12) | uint8(s[5]<<7)
+		d[12] = uint8(s[5] >> 1)
+		d[13] = uint8(s[5] >> 9)
+		d[14] = uint8(s[5]>>17) | uint8(s[6]<<2)
+		d[15] = uint8(s[6] >> 6)
+		d[16] = uint8(s[6]>>14) | uint8(s[7]<<5)
+		d[17] = uint8(s[7] >> 3)
+		d[18] = uint8(s[7] >> 11)
+		n += 19
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [19]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<3)
+		d[3] = uint8(s[1] >> 5)
+		d[4] = uint8(s[1]>>13) | uint8(s[2]<<6)
+		d[5] = uint8(s[2] >> 2)
+		d[6] = uint8(s[2] >> 10)
+		d[7] = uint8(s[2]>>18) | uint8(s[3]<<1)
+		d[8] = uint8(s[3] >> 7)
+		d[9] = uint8(s[3]>>15) | uint8(s[4]<<4)
+		d[10] = uint8(s[4] >> 4)
+		d[11] = uint8(s[4]>>12) | uint8(s[5]<<7)
+		d[12] = uint8(s[5] >> 1)
+		d[13] = uint8(s[5] >> 9)
+		d[14] = uint8(s[5]>>17) | uint8(s[6]<<2)
+		d[15] = uint8(s[6] >> 6)
+		d[16] = uint8(s[6]>>14) | uint8(s[7]<<5)
+		d[17] = uint8(s[7] >> 3)
+		d[18] = uint8(s[7] >> 11)
+		n += copy(dst[n:], d[:(len(src)*19+7)/8])
+	}
+	return n
+}
+
+// UnpackUint19Uint32Slice unpacks len(dst) 19-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*19+7)/8 bytes.
+func UnpackUint19Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+19 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 524287
+		d[1] = (uint32(s[2])>>3 | uint32(s[3])<<5 | uint32(s[4])<<13) & 524287
+		d[2] = (uint32(s[4])>>6 | uint32(s[5])<<2 | uint32(s[6])<<10 | uint32(s[7])<<18) & 524287
+		d[3] = (uint32(s[7])>>1 | uint32(s[8])<<7 | uint32(s[9])<<15) & 524287
+		d[4] = (uint32(s[9])>>4 | uint32(s[10])<<4 | uint32(s[11])<<12) & 524287
+		d[5] = (uint32(s[11])>>7 | uint32(s[12])<<1 | uint32(s[13])<<9 | uint32(s[14])<<17) & 524287
+		d[6] = (uint32(s[14])>>2 | uint32(s[15])<<6 | uint32(s[16])<<14) & 524287
+		d[7] = (uint32(s[16])>>5 | uint32(s[17])<<3 | uint32(s[18])<<11) & 524287
+		n += 19
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [19]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*19+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 524287
+		d[1] = (uint32(s[2])>>3 | uint32(s[3])<<5 | uint32(s[4])<<13) & 524287
+		d[2] = (uint32(s[4])>>6 | uint32(s[5])<<2 | uint32(s[6])<<10 | uint32(s[7])<<18) & 524287
+		d[3] = (uint32(s[7])>>1 | uint32(s[8])<<7 | uint32(s[9])<<15) & 524287
+		d[4] = (uint32(s[9])>>4 | uint32(s[10])<<4 | uint32(s[11])<<12) & 524287
+		d[5] = (uint32(s[11])>>7 | uint32(s[12])<<1 | uint32(s[13])<<9 | uint32(s[14])<<17) & 524287
+		d[6] = (uint32(s[14])>>2 | uint32(s[15])<<6 | uint32(s[16])<<14) & 524287
+		d[7] = (uint32(s[16])>>5 | uint32(s[17])<<3 | uint32(s[18])<<11) & 524287
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint20Uint32Slice packs the lowest 20 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*20+7)/8 bytes and the values must fit in 20 bits.
+func PackUint20Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+20 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<4)
+		d[3] = uint8(s[1] >> 4)
+		d[4] = uint8(s[1] >> 12)
+		d[5] = uint8(s[2])
+		d[6] = uint8(s[2] >> 8)
+		d[7] = uint8(s[2]>>16) | uint8(s[3]<<4)
+		d[8] = uint8(s[3] >> 4)
+		d[9] = uint8(s[3] >> 12)
+		d[10] = uint8(s[4])
+		d[11] = uint8(s[4] >> 8)
+		d[12] = uint8(s[4]>>16) | uint8(s[5]<<4)
+		d[13] = uint8(s[5] >> 4)
+		d[14] = uint8(s[5] >> 12)
+		d[15] = uint8(s[6])
+		d[16] = uint8(s[6] >> 8)
+		d[17] = uint8(s[6]>>16) | uint8(s[7]<<4)
+		d[18] = uint8(s[7] >> 4)
+		d[19] = uint8(s[7] >> 12)
+		n += 20
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [20]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<4)
+		d[3] = uint8(s[1] >> 4)
+		d[4] = uint8(s[1] >> 12)
+		d[5] = uint8(s[2])
+		d[6] = uint8(s[2] >> 8)
+		d[7] = uint8(s[2]>>16) | uint8(s[3]<<4)
+		d[8] = uint8(s[3] >> 4)
+		d[9] = uint8(s[3] >> 12)
+		d[10] = uint8(s[4])
+		d[11] = uint8(s[4] >> 8)
+		d[12] = uint8(s[4]>>16) | uint8(s[5]<<4)
+		d[13] = uint8(s[5] >> 4)
+		d[14] = uint8(s[5] >> 12)
+		d[15] = uint8(s[6])
+		d[16] = uint8(s[6] >> 8)
+		d[17] = uint8(s[6]>>16) | uint8(s[7]<<4)
+		d[18] = uint8(s[7] >> 4)
+		d[19] = uint8(s[7] >> 12)
+		n += copy(dst[n:], d[:(len(src)*20+7)/8])
+	}
+	return n
+}
+
+// UnpackUint20Uint32Slice unpacks len(dst) 20-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*20+7)/8 bytes.
+func UnpackUint20Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+20 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 1048575
+		d[1] = (uint32(s[2])>>4 | uint32(s[3])<<4 | uint32(s[4])<<12) & 1048575
+		d[2] = (uint32(s[5]) | uint32(s[6])<<8 | uint32(s[7])<<16) & 1048575
+		d[3] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12) & 1048575
+		d[4] = (uint32(s[10]) | uint32(s[11])<<8 | uint32(s[12])<<16) & 1048575
+		d[5] = (uint32(s[12])>>4 | uint32(s[13])<<4 | uint32(s[14])<<12) & 1048575
+		d[6] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16) & 1048575
+		d[7] = (uint32(s[17])>>4 | uint32(s[18])<<4 | uint32(s[19])<<12) & 1048575
+		n += 20
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [20]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*20+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 1048575
+		d[1] = (uint32(s[2])>>4 | uint32(s[3])<<4 | uint32(s[4])<<12) & 1048575
+		d[2] = (uint32(s[5]) | uint32(s[6])<<8 | uint32(s[7])<<16) & 1048575
+		d[3] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12) & 1048575
+		d[4] = (uint32(s[10]) | uint32(s[11])<<8 | uint32(s[12])<<16) & 1048575
+		d[5] = (uint32(s[12])>>4 | uint32(s[13])<<4 | uint32(s[14])<<12) & 1048575
+		d[6] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16) & 1048575
+		d[7] = (uint32(s[17])>>4 | uint32(s[18])<<4 | uint32(s[19])<<12) & 1048575
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint21Uint32Slice packs the lowest 21 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*21+7)/8 bytes and the values must fit in 21 bits.
+func PackUint21Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+21 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<5)
+		d[3] = uint8(s[1] >> 3)
+		d[4] = uint8(s[1] >> 11)
+		d[5] = uint8(s[1]>>19) | uint8(s[2]<<2)
+		d[6] = uint8(s[2] >> 6)
+		d[7] = uint8(s[2]>>14) | uint8(s[3]<<7)
+		d[8] = uint8(s[3] >> 1)
+		d[9] = uint8(s[3] >> 9)
+		d[10] = uint8(s[3]>>17) | uint8(s[4]<<4)
+		d[11] = uint8(s[4] >> 4)
+		d[12] = uint8(s[4] >> 12)
+		d[13] = uint8(s[4]>>20) | uint8(s[5]<<1)
+		d[14] = uint8(s[5] >> 7)
+		d[15] = uint8(s[5]>>15) | uint8(s[6]<<6)
+		d[16] = uint8(s[6] >> 2)
+		d[17] = uint8(s[6] >> 10)
+		d[18] = uint8(s[6]>>18) | uint8(s[7]<<3)
+		d[19] = uint8(s[7] >> 5)
+		d[20] = uint8(s[7] >> 13)
+		n += 21
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [21]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<5)
+		d[3] = uint8(s[1] >> 3)
+		d[4] = uint8(s[1] >> 11)
+		d[5] = uint8(s[1]>>19) | uint8(s[2]<<2)
+		d[6] = uint8(s[2] >> 6)
+		d[7] = uint8(s[2]>>14) | uint8(s[3]<<7)
+		d[8] = uint8(s[3] >> 1)
+		d[9] = uint8(s[3] >> 9)
+		d[10] = uint8(s[3]>>17) | uint8(s[4]<<4)
+		d[11] = uint8(s[4] >> 4)
+		d[12] = uint8(s[4] >> 12)
+		d[13] = uint8(s[4]>>20) | uint8(s[5]<<1)
+		d[14] = uint8(s[5] >> 7)
+		d[15] = uint8(s[5]>>15) | uint8(s[6]<<6)
+		d[16] = uint8(s[6] >> 2)
+		d[17] = uint8(s[6] >> 10)
+		d[18] = uint8(s[6]>>18) | uint8(s[7]<<3)
+		d[19] = uint8(s[7] >> 5)
+		d[20] = uint8(s[7] >> 13)
+		n += copy(dst[n:], d[:(len(src)*21+7)/8])
+	}
+	return n
+}
+
+// UnpackUint21Uint32Slice unpacks len(dst) 21-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*21+7)/8 bytes.
+func UnpackUint21Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+21 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 2097151
+		d[1] = (uint32(s[2])>>5 | uint32(s[3])<<3 | uint32(s[4])<<11 | uint32(s[5])<<19) & 2097151
+		d[2] = (uint32(s[5])>>2 | uint32(s[6])<<6 | uint32(s[7])<<14) & 2097151
+		d[3] = (uint32(s[7])>>7 | uint32(s[8])<<1 | uint32(s[9])<<9 | uint32(s[10])<<17) & 2097151
+		d[4] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12 | uint32(s[13])<<20) & 2097151
+		d[5] = (uint32(s[13])>>1 | uint32(s[14])<<7 | uint32(s[15])<<15) & 2097151
+		d[6] = (uint32(s[15])>>6 | uint32(s[16])<<2 | uint32(s[17])<<10 | uint32(s[18])<<18) & 2097151
+		d[7] = (uint32(s[18])>>3 | uint32(s[19])<<5 | uint32(s[20])<<13) & 2097151
+		n += 21
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [21]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*21+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 2097151
+		d[1] = (uint32(s[2])>>5 | uint32(s[3])<<3 | uint32(s[4])<<11 | uint32(s[5])<<19) & 2097151
+		d[2] = (uint32(s[5])>>2 | uint32(s[6])<<6 | uint32(s[7])<<14) & 2097151
+		d[3] = (uint32(s[7])>>7 | uint32(s[8])<<1 | uint32(s[9])<<9 | uint32(s[10])<<17) & 2097151
+		d[4] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12 | uint32(s[13])<<20) & 2097151
+		d[5] = (uint32(s[13])>>1 | uint32(s[14])<<7 | uint32(s[15])<<15) & 2097151
+		d[6] = (uint32(s[15])>>6 | uint32(s[16])<<2 | uint32(s[17])<<10 | uint32(s[18])<<18) & 2097151
+		d[7] = (uint32(s[18])>>3 | uint32(s[19])<<5 | uint32(s[20])<<13) & 2097151
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint22Uint32Slice packs the lowest 22 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*22+7)/8 bytes and the values must fit in 22 bits.
+func PackUint22Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+22 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<6)
+		d[3] = uint8(s[1] >> 2)
+		d[4] = uint8(s[1] >> 10)
+		d[5] = uint8(s[1]>>18) | uint8(s[2]<<4)
+		d[6] = uint8(s[2] >> 4)
+		d[7] = uint8(s[2] >> 12)
+		d[8] = uint8(s[2]>>20) | uint8(s[3]<<2)
+		d[9] = uint8(s[3] >> 6)
+		d[10] = uint8(s[3] >> 14)
+		d[11] = uint8(s[4])
+		d[12] = uint8(s[4] >> 8)
+		d[13] = uint8(s[4]>>16) | uint8(s[5]<<6)
+		d[14] = uint8(s[5] >> 2)
+		d[15] = uint8(s[5] >> 10)
+		d[16] = uint8(s[5]>>18) | uint8(s[6]<<4)
+		d[17] = uint8(s[6] >> 4)
+		d[18] = uint8(s[6] >> 12)
+		d[19] = uint8(s[6]>>20) | uint8(s[7]<<2)
+		d[20] = uint8(s[7] >> 6)
+		d[21] = uint8(s[7] >> 14)
+		n += 22
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [22]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<6)
+		d[3] = uint8(s[1] >> 2)
+		d[4] = uint8(s[1] >> 10)
+		d[5] = uint8(s[1]>>18) | uint8(s[2]<<4)
+		d[6] = uint8(s[2] >> 4)
+		d[7] = uint8(s[2] >> 12)
+		d[8] = uint8(s[2]>>20) | uint8(s[3]<<2)
+		d[9] = uint8(s[3] >> 6)
+		d[10] = uint8(s[3] >> 14)
+		d[11] = uint8(s[4])
+		d[12] = uint8(s[4] >> 8)
+		d[13] = uint8(s[4]>>16) | uint8(s[5]<<6)
+		d[14] = uint8(s[5] >> 2)
+		d[15] = uint8(s[5] >> 10)
+		d[16] = uint8(s[5]>>18) | uint8(s[6]<<4)
+		d[17] = uint8(s[6] >> 4)
+		d[18] = uint8(s[6] >> 12)
+		d[19] = uint8(s[6]>>20) | uint8(s[7]<<2)
+		d[20] = uint8(s[7] >> 6)
+		d[21] = uint8(s[7] >> 14)
+		n += copy(dst[n:], d[:(len(src)*22+7)/8])
+	}
+	return n
+}
+
+// UnpackUint22Uint32Slice unpacks len(dst) 22-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*22+7)/8 bytes.
+func UnpackUint22Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+22 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 4194303
+		d[1] = (uint32(s[2])>>6 | uint32(s[3])<<2 | uint32(s[4])<<10 | uint32(s[5])<<18) & 4194303
+		d[2] = (uint32(s[5])>>4 | uint32(s[6])<<4 | uint32(s[7])<<12 | uint32(s[8])<<20) & 4194303
+		d[3] = (uint32(s[8])>>2 | uint32(s[9])<<6 | uint32(s[10])<<14) & 4194303
+		d[4] = (uint32(s[11]) | uint32(s[12])<<8 | uint32(s[13])<<16) & 4194303
+		d[5] = (uint32(s[13])>>6 | uint32(s[14])<<2 | uint32(s[15])<<10 | uint32(s[16])<<18) & 4194303
+		d[6] = (uint32(s[16])>>4 | uint32(s[17])<<4 | uint32(s[18])<<12 | uint32(s[19])<<20) & 4194303
+		d[7] = (uint32(s[19])>>2 | uint32(s[20])<<6 | uint32(s[21])<<14) & 4194303
+		n += 22
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [22]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*22+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 4194303
+		d[1] = (uint32(s[2])>>6 | uint32(s[3])<<2 | uint32(s[4])<<10 | uint32(s[5])<<18) & 4194303
+		d[2] = (uint32(s[5])>>4 | uint32(s[6])<<4 | uint32(s[7])<<12 | uint32(s[8])<<20) & 4194303
+		d[3] = (uint32(s[8])>>2 | uint32(s[9])<<6 | uint32(s[10])<<14) & 4194303
+		d[4] = (uint32(s[11]) | uint32(s[12])<<8 | uint32(s[13])<<16) & 4194303
+		d[5] = (uint32(s[13])>>6 | uint32(s[14])<<2 | uint32(s[15])<<10 | uint32(s[16])<<18) & 4194303
+		d[6] = (uint32(s[16])>>4 | uint32(s[17])<<4 | uint32(s[18])<<12 | uint32(s[19])<<20) & 4194303
+		d[7] = (uint32(s[19])>>2 | uint32(s[20])<<6 | uint32(s[21])<<14) & 4194303
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint23Uint32Slice packs the lowest 23 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*23+7)/8 bytes and the values must fit in 23 bits.
+func PackUint23Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+23 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<7)
+		d[3] = uint8(s[1] >> 1)
+		d[4] = uint8(s[1] >> 9)
+		d[5] = uint8(s[1]>>17) | uint8(s[2]<<6)
+		d[6] = uint8(s[2] >> 2)
+		d[7] = uint8(s[2] >> 10)
+		d[8] = uint8(s[2]>>18) | uint8(s[3]<<5)
+		d[9] = uint8(s[3] >> 3)
+		d[10] = uint8(s[3] >> 11)
+		d[11] = uint8(s[3]>>19) | uint8(s[4]<<4)
+		d[12] = uint8(s[4] >> 4)
+		d[13] = uint8(s[4] >> 12)
+		d[14] = uint8(s[4]>>20) | uint8(s[5]<<3)
+		d[15] = uint8(s[5] >> 5)
+		d[16] = uint8(s[5] >> 13)
+		d[17] = uint8(s[5]>>21) | uint8(s[6]<<2)
+		d[18] = uint8(s[6] >> 6)
+		d[19] = uint8(s[6] >> 14)
+		d[20] = uint8(s[6]>>22) | uint8(s[7]<<1)
+		d[21] = uint8(s[7] >> 7)
+		d[22] = uint8(s[7] >> 15)
+		n += 23
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [23]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0]>>16) | uint8(s[1]<<7)
+		d[3] = uint8(s[1] >> 1)
+		d[4] = uint8(s[1] >> 9)
+		d[5] = uint8(s[1]>>17) | uint8(s[2]<<6)
+		d[6] = uint8(s[2] >> 2)
+		d[7] = uint8(s[2] >> 10)
+		d[8] = uint8(s[2]>>18) | uint8(s[3]<<5)
+		d[9] = uint8(s[3] >> 3)
+		d[10] = uint8(s[3] >> 11)
+		d[11] = uint8(s[3]>>19) | uint8(s[4]<<4)
+		d[12] = uint8(s[4] >> 4)
+		d[13] = uint8(s[4] >> 12)
+		d[14] = uint8(s[4]>>20) | uint8(s[5]<<3)
+		d[15] = uint8(s[5] >> 5)
+		d[16] = uint8(s[5] >> 13)
+		d[17] = uint8(s[5]>>21) | uint8(s[6]<<2)
+		d[18] = uint8(s[6] >> 6)
+		d[19] = uint8(s[6] >> 14)
+		d[20] = uint8(s[6]>>22) | uint8(s[7]<<1)
+		d[21] = uint8(s[7] >> 7)
+		d[22] = uint8(s[7] >> 15)
+		n += copy(dst[n:], d[:(len(src)*23+7)/8])
+	}
+	return n
+}
+
+// UnpackUint23Uint32Slice unpacks len(dst) 23-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*23+7)/8 bytes.
+func UnpackUint23Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+23 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 8388607
+		d[1] = (uint32(s[2])>>7 | uint32(s[3])<<1 | uint32(s[4])<<9 | uint32(s[5])<<17) & 8388607
+		d[2] = (uint32(s[5])>>6 | uint32(s[6])<<2 | uint32(s[7])<<10 | uint32(s[8])<<18) & 8388607
+		d[3] = (uint32(s[8])>>5 | uint32(s[9])<<3 | uint32(s[10])<<11 | uint32(s[11])<<19) & 8388607
+		d[4] = (uint32(s[11])>>4 | uint32(s[12])<<4 | uint32(s[13])<<12 | uint32(s[14])<<20) & 8388607
+		d[5] = (uint32(s[14])>>3 | uint32(s[15])<<5 | uint32(s[16])<<13 | uint32(s[17])<<21) & 8388607
+		d[6] = (uint32(s[17])>>2 | uint32(s[18])<<6 | uint32(s[19])<<14 | uint32(s[20])<<22) & 8388607
+		d[7] = (uint32(s[20])>>1 | uint32(s[21])<<7 | uint32(s[22])<<15) & 8388607
+		n += 23
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [23]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*23+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 8388607
+		d[1] = (uint32(s[2])>>7 | uint32(s[3])<<1 | uint32(s[4])<<9 | uint32(s[5])<<17) & 8388607
+		d[2] = (uint32(s[5])>>6 | uint32(s[6])<<2 | uint32(s[7])<<10 | uint32(s[8])<<18) & 8388607
+		d[3] = (uint32(s[8])>>5 | uint32(s[9])<<3 | uint32(s[10])<<11 | uint32(s[11])<<19) & 8388607
+		d[4] = (uint32(s[11])>>4 | uint32(s[12])<<4 | uint32(s[13])<<12 | uint32(s[14])<<20) & 8388607
+		d[5] = (uint32(s[14])>>3 | uint32(s[15])<<5 | uint32(s[16])<<13 | uint32(s[17])<<21) & 8388607
+		d[6] = (uint32(s[17])>>2 | uint32(s[18])<<6 | uint32(s[19])<<14 | uint32(s[20])<<22) & 8388607
+		d[7] = (uint32(s[20])>>1 | uint32(s[21])<<7 | uint32(s[22])<<15) & 8388607
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint24Uint32Slice packs the lowest 24 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*24+7)/8 bytes and the values must fit in 24 bits.
+func PackUint24Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+24 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[1])
+		d[4] = uint8(s[1] >> 8)
+		d[5] = uint8(s[1] >> 16)
+		d[6] = uint8(s[2])
+		d[7] = uint8(s[2] >> 8)
+		d[8] = uint8(s[2] >> 16)
+		d[9] = uint8(s[3])
+		d[10] = uint8(s[3] >> 8)
+		d[11] = uint8(s[3] >> 16)
+		d[12] = uint8(s[4])
+		d[13] = uint8(s[4] >> 8)
+		d[14] = uint8(s[4] >> 16)
+		d[15] = uint8(s[5])
+		d[16] = uint8(s[5] >> 8)
+		d[17] = uint8(s[5] >> 16)
+		d[18] = uint8(s[6])
+		d[19] = uint8(s[6] >> 8)
+		d[20] = uint8(s[6] >> 16)
+		d[21] = uint8(s[7])
+		d[22] = uint8(s[7] >> 8)
+		d[23] = uint8(s[7] >> 16)
+		n += 24
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [24]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[1])
+		d[4] = uint8(s[1] >> 8)
+		d[5] = uint8(s[1] >> 16)
+		d[6] = uint8(s[2])
+		d[7] = uint8(s[2] >> 8)
+		d[8] = uint8(s[2] >> 16)
+		d[9] = uint8(s[3])
+		d[10] = uint8(s[3] >> 8)
+		d[11] = uint8(s[3] >> 16)
+		d[12] = uint8(s[4])
+		d[13] = uint8(s[4] >> 8)
+		d[14] = uint8(s[4] >> 16)
+		d[15] = uint8(s[5])
+		d[16] = uint8(s[5] >> 8)
+		d[17] = uint8(s[5] >> 16)
+		d[18] = uint8(s[6])
+		d[19] = uint8(s[6] >> 8)
+		d[20] = uint8(s[6] >> 16)
+		d[21] = uint8(s[7])
+		d[22] = uint8(s[7] >> 8)
+		d[23] = uint8(s[7] >> 16)
+		n += copy(dst[n:], d[:(len(src)*24+7)/8])
+	}
+	return n
+}
+
+// UnpackUint24Uint32Slice unpacks len(dst) 24-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*24+7)/8 bytes.
+func UnpackUint24Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+24 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 16777215
+		d[1] = (uint32(s[3]) | uint32(s[4])<<8 | uint32(s[5])<<16) & 16777215
+		d[2] = (uint32(s[6]) | uint32(s[7])<<8 | uint32(s[8])<<16) & 16777215
+		d[3] = (uint32(s[9]) | uint32(s[10])<<8 | uint32(s[11])<<16) & 16777215
+		d[4] = (uint32(s[12]) | uint32(s[13])<<8 | uint32(s[14])<<16) & 16777215
+		d[5] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16) & 16777215
+		d[6] = (uint32(s[18]) | uint32(s[19])<<8 | uint32(s[20])<<16) & 16777215
+		d[7] = (uint32(s[21]) | uint32(s[22])<<8 | uint32(s[23])<<16) & 16777215
+		n += 24
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [24]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*24+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16) & 16777215
+		d[1] = (uint32(s[3]) | uint32(s[4])<<8 | uint32(s[5])<<16) & 16777215
+		d[2] = (uint32(s[6]) | uint32(s[7])<<8 | uint32(s[8])<<16) & 16777215
+		d[3] = (uint32(s[9]) | uint32(s[10])<<8 | uint32(s[11])<<16) & 16777215
+		d[4] = (uint32(s[12]) | uint32(s[13])<<8 | uint32(s[14])<<16) & 16777215
+		d[5] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16) & 16777215
+		d[6] = (uint32(s[18]) | uint32(s[19])<<8 | uint32(s[20])<<16) & 16777215
+		d[7] = (uint32(s[21]) | uint32(s[22])<<8 | uint32(s[23])<<16) & 16777215
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint25Uint32Slice packs the lowest 25 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*25+7)/8 bytes and the values must fit in 25 bits.
+func PackUint25Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+25 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<1)
+		d[4] = uint8(s[1] >> 7)
+		d[5] = uint8(s[1] >> 15)
+		d[6] = uint8(s[1]>>23) | uint8(s[2]<<2)
+		d[7] = uint8(s[2] >> 6)
+		d[8] = uint8(s[2] >> 14)
+		d[9] = uint8(s[2]>>22) | uint8(s[3]<<3)
+		d[10] = uint8(s[3] >> 5)
+		d[11] = uint8(s[3] >> 13)
+		d[12] = uint8(s[3]>>21) | uint8(s[4]<<4)
+		d[13] = uint8(s[4] >> 4)
+		d[14] = uint8(s[4] >> 12)
+		d[15] = uint8(s[4]>>20) | uint8(s[5]<<5)
+		d[16] = uint8(s[5] >> 3)
+		d[17] = uint8(s[5] >> 11)
+		d[18] = uint8(s[5]>>19) | uint8(s[6]<<6)
+		d[19] = uint8(s[6] >> 2)
+		d[20] = uint8(s[6] >> 10)
+		d[21] = uint8(s[6]>>18) | uint8(s[7]<<7)
+		d[22] = uint8(s[7] >> 1)
+		d[23] = uint8(s[7] >> 9)
+		d[24] = uint8(s[7] >> 17)
+		n += 25
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [25]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<1)
+		d[4] = uint8(s[1] >> 7)
+		d[5] = uint8(s[1] >> 15)
+		d[6] = uint8(s[1]>>23) | uint8(s[2]<<2)
+		d[7] = uint8(s[2] >> 6)
+		d[8] = uint8(s[2] >> 14)
+		d[9] = uint8(s[2]>>22) | uint8(s[3]<<3)
+		d[10] = uint8(s[3] >> 5)
+		d[11] = uint8(s[3] >> 13)
+		d[12] = uint8(s[3]>>21) | uint8(s[4]<<4)
+		d[13] = uint8(s[4] >> 4)
+		d[14] = uint8(s[4] >> 12)
+		d[15] = uint8(s[4]>>20) | uint8(s[5]<<5)
+		d[16] = uint8(s[5] >> 3)
+		d[17] = uint8(s[5] >> 11)
+		d[18] = uint8(s[5]>>19) | uint8(s[6]<<6)
+		d[19] = uint8(s[6] >> 2)
+		d[20] = uint8(s[6] >> 10)
+		d[21] = uint8(s[6]>>18) | uint8(s[7]<<7)
+		d[22] = uint8(s[7] >> 1)
+		d[23] = uint8(s[7] >> 9)
+		d[24] = uint8(s[7] >> 17)
+		n += copy(dst[n:], d[:(len(src)*25+7)/8])
+	}
+	return n
+}
+
+// UnpackUint25Uint32Slice unpacks len(dst) 25-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*25+7)/8 bytes.
+func UnpackUint25Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+25 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 33554431
+		d[1] = (uint32(s[3])>>1 | uint32(s[4])<<7 | uint32(s[5])<<15 | uint32(s[6])<<23) & 33554431
+		d[2] = (uint32(s[6])>>2 | uint32(s[7])<<6 | uint32(s[8])<<14 | uint32(s[9])<<22) & 33554431
+		d[3] = (uint32(s[9])>>3 | uint32(s[10])<<5 | uint32(s[11])<<13 | uint32(s[12])<<21) & 33554431
+		d[4] = (uint32(s[12])>>4 | uint32(s[13])<<4 | uint32(s[14])<<12 | uint32(s[15])<<20) & 33554431
+		d[5] = (uint32(s[15])>>5 | uint32(s[16])<<3 | uint32(s[17])<<11 | uint32(s[18])<<19) & 33554431
+		d[6] = (uint32(s[18])>>6 | uint32(s[19])<<2 | uint32(s[20])<<10 | uint32(s[21])<<18) & 33554431
+		d[7] = (uint32(s[21])>>7 | uint32(s[22])<<1 | uint32(s[23])<<9 | uint32(s[24])<<17) & 33554431
+		n += 25
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [25]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*25+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 33554431
+		d[1] = (uint32(s[3])>>1 | uint32(s[4])<<7 | uint32(s[5])<<15 | uint32(s[6])<<23) & 33554431
+		d[2] = (uint32(s[6])>>2 | uint32(s[7])<<6 | uint32(s[8])<<14 | uint32(s[9])<<22) & 33554431
+		d[3] = (uint32(s[9])>>3 | uint32(s[10])<<5 | uint32(s[11])<<13 | uint32(s[12])<<21) & 33554431
+		d[4] = (uint32(s[12])>>4 | uint32(s[13])<<4 | uint32(s[14])<<12 | uint32(s[15])<<20) & 33554431
+		d[5] = (uint32(s[15])>>5 | uint32(s[16])<<3 | uint32(s[17])<<11 | uint32(s[18])<<19) & 33554431
+		d[6] = (uint32(s[18])>>6 | uint32(s[19])<<2 | uint32(s[20])<<10 | uint32(s[21])<<18) & 33554431
+		d[7] = (uint32(s[21])>>7 | uint32(s[22])<<1 | uint32(s[23])<<9 | uint32(s[24])<<17) & 33554431
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint26Uint32Slice packs the lowest 26 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*26+7)/8 bytes and the values must fit in 26 bits.
+func PackUint26Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+26 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<2)
+		d[4] = uint8(s[1] >> 6)
+		d[5] = uint8(s[1] >> 14)
+		d[6] = uint8(s[1]>>22) | uint8(s[2]<<4)
+		d[7] = uint8(s[2] >> 4)
+		d[8] = uint8(s[2] >> 12)
+		d[9] = uint8(s[2]>>20) | uint8(s[3]<<6)
+		d[10] = uint8(s[3] >> 2)
+		d[11] = uint8(s[3] >> 10)
+		d[12] = uint8(s[3] >> 18)
+		d[13] = uint8(s[4])
+		d[14] = uint8(s[4] >> 8)
+		d[15] = uint8(s[4] >> 16)
+		d[16] = uint8(s[4]>>24) | uint8(s[5]<<2)
+		d[17] = uint8(s[5] >> 6)
+		d[18] = uint8(s[5] >> 14)
+		d[19] = uint8(s[5]>>22) | uint8(s[6]<<4)
+		d[20] = uint8(s[6] >> 4)
+		d[21] = uint8(s[6] >> 12)
+		d[22] = uint8(s[6]>>20) | uint8(s[7]<<6)
+		d[23] = uint8(s[7] >> 2)
+		d[24] = uint8(s[7] >> 10)
+		d[25] = uint8(s[7] >> 18)
+		n += 26
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [26]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<2)
+		d[4] = uint8(s[1] >> 6)
+		d[5] = uint8(s[1] >> 14)
+		d[6] = uint8(s[1]>>22) | uint8(s[2]<<4)
+		d[7] = uint8(s[2] >> 4)
+		d[8] = uint8(s[2] >> 12)
+		d[9] = uint8(s[2]>>20) | uint8(s[3]<<6)
+		d[10] = uint8(s[3] >> 2)
+		d[11] = uint8(s[3] >> 10)
+		d[12] = uint8(s[3] >> 18)
+		d[13] = uint8(s[4])
+		d[14] = uint8(s[4] >> 8)
+		d[15] = uint8(s[4] >> 16)
+		d[16] = uint8(s[4]>>24) | uint8(s[5]<<2)
+		d[17] = uint8(s[5] >> 6)
+		d[18] = uint8(s[5] >> 14)
+		d[19] = uint8(s[5]>>22) | uint8(s[6]<<4)
+		d[20] = uint8(s[6] >> 4)
+		d[21] = uint8(s[6] >> 12)
+		d[22] = uint8(s[6]>>20) | uint8(s[7]<<6)
+		d[23] = uint8(s[7] >> 2)
+		d[24] = uint8(s[7] >> 10)
+		d[25] = uint8(s[7] >> 18)
+		n += copy(dst[n:], d[:(len(src)*26+7)/8])
+	}
+	return n
+}
+
+// UnpackUint26Uint32Slice unpacks len(dst) 26-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*26+7)/8 bytes.
+func UnpackUint26Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+26 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 67108863
+		d[1] = (uint32(s[3])>>2 | uint32(s[4])<<6 | uint32(s[5])<<14 | uint32(s[6])<<22) & 67108863
+		d[2] = (uint32(s[6])>>4 | uint32(s[7])<<4 | uint32(s[8])<<12 | uint32(s[9])<<20) & 67108863
+		d[3] = (uint32(s[9])>>6 | uint32(s[10])<<2 | uint32(s[11])<<10 | uint32(s[12])<<18) & 67108863
+		d[4] = (uint32(s[13]) | uint32(s[14])<<8 | uint32(s[15])<<16 | uint32(s[16])<<24) & 67108863
+		d[5] = (uint32(s[16])>>2 | uint32(s[17])<<6 | uint32(s[18])<<14 | uint32(s[19])<<22) & 67108863
+		d[6] = (uint32(s[19])>>4 | uint32(s[20])<<4 | uint32(s[21])<<12 | uint32(s[22])<<20) & 67108863
+		d[7] = (uint32(s[22])>>6 | uint32(s[23])<<2 | uint32(s[24])<<10 | uint32(s[25])<<18) & 67108863
+		n += 26
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [26]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*26+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 67108863
+		d[1] = (uint32(s[3])>>2 | uint32(s[4])<<6 | uint32(s[5])<<14 | uint32(s[6])<<22) & 67108863
+		d[2] = (uint32(s[6])>>4 | uint32(s[7])<<4 | uint32(s[8])<<12 | uint32(s[9])<<20) & 67108863
+		d[3] = (uint32(s[9])>>6 | uint32(s[10])<<2 | uint32(s[11])<<10 | uint32(s[12])<<18) & 67108863
+		d[4] = (uint32(s[13]) | uint32(s[14])<<8 | uint32(s[15])<<16 | uint32(s[16])<<24) & 67108863
+		d[5] = (uint32(s[16])>>2 | uint32(s[17])<<6 | uint32(s[18])<<14 | uint32(s[19])<<22) & 67108863
+		d[6] = (uint32(s[19])>>4 | uint32(s[20])<<4 | uint32(s[21])<<12 | uint32(s[22])<<20) & 67108863
+		d[7] = (uint32(s[22])>>6 | uint32(s[23])<<2 | uint32(s[24])<<10 | uint32(s[25])<<18) & 67108863
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint27Uint32Slice packs the lowest 27 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*27+7)/8 bytes and the values must fit in 27 bits.
+func PackUint27Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+27 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<3)
+		d[4] = uint8(s[1] >> 5)
+		d[5] = uint8(s[1] >> 13)
+		d[6] = uint8(s[1]>>21) | uint8(s[2]<<6)
+		d[7] = uint8(s[2] >> 2)
+		d[8] = uint8(s[2] >> 10)
+		d[9] = uint8(s[2] >> 18)
+		d[10] = uint8(s[2]>>26) | uint8(s[3]<<1)
+		d[11] = uint8(s[3] >> 7)
+		d[12] = uint8(s[3] >> 15)
+		d[13] = uint8(s[3]>>23) | uint8(s[4]<<4)
+		d[14] = uint8(s[4] >> 4)
+		d[15] = uint8(s[4] >> 12)
+		d[16] = uint8(s[4]>>20) | uint8(s[5]<<7)
+		d[17] = uint8(s[5] >> 1)
+		d[18] = uint8(s[5] >> 9)
+		d[19] = uint8(s[5] >> 17)
+		d[20] = uint8(s[5]>>25) | uint8(s[6]<<2)
+		d[21] = uint8(s[6] >> 6)
+		d[22] = uint8(s[6] >> 14)
+		d[23] = uint8(s[6]>>22) | uint8(s[7]<<5)
+		d[24] = uint8(s[7] >> 3)
+		d[25] = uint8(s[7] >> 11)
+		d[26] = uint8(s[7] >> 19)
+		n += 27
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [27]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<3)
+		d[4] = uint8(s[1] >> 5)
+		d[5] = uint8(s[1] >> 13)
+		d[6] = uint8(s[1]>>21) | uint8(s[2]<<6)
+		d[7] = uint8(s[2] >> 2)
+		d[8] = uint8(s[2] >> 10)
+		d[9] = uint8(s[2] >> 18)
+		d[10] = uint8(s[2]>>26) | uint8(s[3]<<1)
+		d[11] = uint8(s[3] >> 7)
+		d[12] = uint8(s[3] >> 15)
+		d[13] = uint8(s[3]>>23) | uint8(s[4]<<4)
+		d[14] = uint8(s[4] >> 4)
+		d[15] = uint8(s[4] >> 12)
+		d[16] = uint8(s[4]>>20) | uint8(s[5]<<7)
+		d[17] = uint8(s[5] >> 1)
+		d[18] = uint8(s[5] >> 9)
+		d[19] = uint8(s[5] >> 17)
+		d[20] = uint8(s[5]>>25) | uint8(s[6]<<2)
+		d[21] = uint8(s[6] >> 6)
+		d[22] = uint8(s[6] >> 14)
+		d[23] = uint8(s[6]>>22) | uint8(s[7]<<5)
+		d[24] = uint8(s[7] >> 3)
+		d[25] = uint8(s[7] >> 11)
+		d[26] = uint8(s[7] >> 19)
+		n += copy(dst[n:], d[:(len(src)*27+7)/8])
+	}
+	return n
+}
+
+// UnpackUint27Uint32Slice unpacks len(dst) 27-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*27+7)/8 bytes.
+func UnpackUint27Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+27 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 134217727
+		d[1] = (uint32(s[3])>>3 | uint32(s[4])<<5 | uint32(s[5])<<13 | uint32(s[6])<<21) & 134217727
+		d[2] = (uint32(s[6])>>6 | uint32(s[7])<<2 | uint32(s[8])<<10 | uint32(s[9])<<18 | uint32(s[10])<<26) & 134217727
+		d[3] = (uint32(s[10])>>1 | uint32(s[11])<<7 | uint32(s[12])<<15 | uint32(s[13])<<23) & 134217727
+		d[4] = (uint32(s[13])>>4 | uint32(s[14])<<4 | uint32(s[15])<<12 | uint32(s[16])<<20) & 134217727
+		d[5] = (uint32(s[16])>>7 | uint32(s[17])<<1 | uint32(s[18])<<9 | uint32(s[19])<<17 | uint32(s[20])<<25) & 134217727
+		d[6] = (uint32(s[20])>>2 | uint32(s[21])<<6 | uint32(s[22])<<14 | uint32(s[23])<<22) & 134217727
+		d[7] = (uint32(s[23])>>5 | uint32(s[24])<<3 | uint32(s[25])<<11 | uint32(s[26])<<19) & 134217727
+		n += 27
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [27]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*27+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 134217727
+		d[1] = (uint32(s[3])>>3 | uint32(s[4])<<5 | uint32(s[5])<<13 | uint32(s[6])<<21) & 134217727
+		d[2] = (uint32(s[6])>>6 | uint32(s[7])<<2 | uint32(s[8])<<10 | uint32(s[9])<<18 | uint32(s[10])<<26) & 134217727
+		d[3] = (uint32(s[10])>>1 | uint32(s[11])<<7 | uint32(s[12])<<15 | uint32(s[13])<<23) & 134217727
+		d[4] = (uint32(s[13])>>4 | uint32(s[14])<<4 | uint32(s[15])<<12 | uint32(s[16])<<20) & 134217727
+		d[5] = (uint32(s[16])>>7 | uint32(s[17])<<1 | uint32(s[18])<<9 | uint32(s[19])<<17 | uint32(s[20])<<25) & 134217727
+		d[6] = (uint32(s[20])>>2 | uint32(s[21])<<6 | uint32(s[22])<<14 | uint32(s[23])<<22) & 134217727
+		d[7] = (uint32(s[23])>>5 | uint32(s[24])<<3 | uint32(s[25])<<11 | uint32(s[26])<<19) & 134217727
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint28Uint32Slice packs the lowest 28 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*28+7)/8 bytes and the values must fit in 28 bits.
+func PackUint28Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+28 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<4)
+		d[4] = uint8(s[1] >> 4)
+		d[5] = uint8(s[1] >> 12)
+		d[6] = uint8(s[1] >> 20)
+		d[7] = uint8(s[2])
+		d[8] = uint8(s[2] >> 8)
+		d[9] = uint8(s[2] >> 16)
+		d[10] = uint8(s[2]>>24) | uint8(s[3]<<4)
+		d[11] = uint8(s[3] >> 4)
+		d[12] = uint8(s[3] >> 12)
+		d[13] = uint8(s[3] >> 20)
+		d[14] = uint8(s[4])
+		d[15] = uint8(s[4] >> 8)
+		d[16] = uint8(s[4] >> 16)
+		d[17] = uint8(s[4]>>24) | uint8(s[5]<<4)
+		d[18] = uint8(s[5] >> 4)
+		d[19] = uint8(s[5] >> 12)
+		d[20] = uint8(s[5] >> 20)
+		d[21] = uint8(s[6])
+		d[22] = uint8(s[6] >> 8)
+		d[23] = uint8(s[6] >> 16)
+		d[24] = uint8(s[6]>>24) | uint8(s[7]<<4)
+		d[25] = uint8(s[7] >> 4)
+		d[26] = uint8(s[7] >> 12)
+		d[27] = uint8(s[7] >> 20)
+		n += 28
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [28]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<4)
+		d[4] = uint8(s[1] >> 4)
+		d[5] = uint8(s[1] >> 12)
+		d[6] = uint8(s[1] >> 20)
+		d[7] = uint8(s[2])
+		d[8] = uint8(s[2] >> 8)
+		d[9] = uint8(s[2] >> 16)
+		d[10] = uint8(s[2]>>24) | uint8(s[3]<<4)
+		d[11] = uint8(s[3] >> 4)
+		d[12] = uint8(s[3] >> 12)
+		d[13] = uint8(s[3] >> 20)
+		d[14] = uint8(s[4])
+		d[15] = uint8(s[4] >> 8)
+		d[16] = uint8(s[4] >> 16)
+		d[17] = uint8(s[4]>>24) | uint8(s[5]<<4)
+		d[18] = uint8(s[5] >> 4)
+		d[19] = uint8(s[5] >> 12)
+		d[20] = uint8(s[5] >> 20)
+		d[21] = uint8(s[6])
+		d[22] = uint8(s[6] >> 8)
+		d[23] = uint8(s[6] >> 16)
+		d[24] = uint8(s[6]>>24) | uint8(s[7]<<4)
+		d[25] = uint8(s[7] >> 4)
+		d[26] = uint8(s[7] >> 12)
+		d[27] = uint8(s[7] >> 20)
+		n += copy(dst[n:], d[:(len(src)*28+7)/8])
+	}
+	return n
+}
+
+// UnpackUint28Uint32Slice unpacks len(dst) 28-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*28+7)/8 bytes.
+func UnpackUint28Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+28 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 268435455
+		d[1] = (uint32(s[3])>>4 | uint32(s[4])<<4 | uint32(s[5])<<12 | uint32(s[6])<<20) & 268435455
+		d[2] = (uint32(s[7]) | uint32(s[8])<<8 | uint32(s[9])<<16 | uint32(s[10])<<24) & 268435455
+		d[3] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12 | uint32(s[13])<<20) & 268435455
+		d[4] = (uint32(s[14]) | uint32(s[15])<<8 | uint32(s[16])<<16 | uint32(s[17])<<24) & 268435455
+		d[5] = (uint32(s[17])>>4 | uint32(s[18])<<4 | uint32(s[19])<<12 | uint32(s[20])<<20) & 268435455
+		d[6] = (uint32(s[21]) | uint32(s[22])<<8 | uint32(s[23])<<16 | uint32(s[24])<<24) & 268435455
+		d[7] = (uint32(s[24])>>4 | uint32(s[25])<<4 | uint32(s[26])<<12 | uint32(s[27])<<20) & 268435455
+		n += 28
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [28]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*28+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 268435455
+		d[1] = (uint32(s[3])>>4 | uint32(s[4])<<4 | uint32(s[5])<<12 | uint32(s[6])<<20) & 268435455
+		d[2] = (uint32(s[7]) | uint32(s[8])<<8 | uint32(s[9])<<16 | uint32(s[10])<<24) & 268435455
+		d[3] = (uint32(s[10])>>4 | uint32(s[11])<<4 | uint32(s[12])<<12 | uint32(s[13])<<20) & 268435455
+		d[4] = (uint32(s[14]) | uint32(s[15])<<8 | uint32(s[16])<<16 | uint32(s[17])<<24) & 268435455
+		d[5] = (uint32(s[17])>>4 | uint32(s[18])<<4 | uint32(s[19])<<12 | uint32(s[20])<<20) & 268435455
+		d[6] = (uint32(s[21]) | uint32(s[22])<<8 | uint32(s[23])<<16 | uint32(s[24])<<24) & 268435455
+		d[7] = (uint32(s[24])>>4 | uint32(s[25])<<4 | uint32(s[26])<<12 | uint32(s[27])<<20) & 268435455
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint29Uint32Slice packs the lowest 29 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*29+7)/8 bytes and the values must fit in 29 bits.
+func PackUint29Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+29 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<5)
+		d[4] = uint8(s[1] >> 3)
+		d[5] = uint8(s[1] >> 11)
+		d[6] = uint8(s[1] >> 19)
+		d[7] = uint8(s[1]>>27) | uint8(s[2]<<2)
+		d[8] = uint8(s[2] >> 6)
+		d[9] = uint8(s[2] >> 14)
+		d[10] = uint8(s[2]>>22) | uint8(s[3]<<7)
+		d[11] = uint8(s[3] >> 1)
+		d[12] = uint8(s[3] >> 9)
+		d[13] = uint8(s[3] >> 17)
+		d[14] = uint8(s[3]>>25) | uint8(s[4]<<4)
+		d[15] = uint8(s[4] >> 4)
+		d[16] = uint8(s[4] >> 12)
+		d[17] = uint8(s[4] >> 20)
+		d[18] = uint8(s[4]>>28) | uint8(s[5]<<1)
+		d[19] = uint8(s[5] >> 7)
+		d[20] = uint8(s[5] >> 15)
+		d[21] = uint8(s[5]>>23) | uint8(s[6]<<6)
+		d[22] = uint8(s[6] >> 2)
+		d[23] = uint8(s[6] >> 10)
+		d[24] = uint8(s[6] >> 18)
+		d[25] = uint8(s[6]>>26) | uint8(s[7]<<3)
+		d[26] = uint8(s[7] >> 5)
+		d[27] = uint8(s[7] >> 13)
+		d[28] = uint8(s[7] >> 21)
+		n += 29
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [29]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<5)
+		d[4] = uint8(s[1] >> 3)
+		d[5] = uint8(s[1] >> 11)
+		d[6] = uint8(s[1] >> 19)
+		d[7] = uint8(s[1]>>27) | uint8(s[2]<<2)
+		d[8] = uint8(s[2] >> 6)
+		d[9] = uint8(s[2] >> 14)
+		d[10] = uint8(s[2]>>22) | uint8(s[3]<<7)
+		d[11] = uint8(s[3] >> 1)
+		d[12] = uint8(s[3] >> 9)
+		d[13] = uint8(s[3] >> 17)
+		d[14] = uint8(s[3]>>25) | uint8(s[4]<<4)
+		d[15] = uint8(s[4] >> 4)
+		d[16] = uint8(s[4] >> 12)
+		d[17] = uint8(s[4] >> 20)
+		d[18] = uint8(s[4]>>28) | uint8(s[5]<<1)
+		d[19] = uint8(s[5] >> 7)
+		d[20] = uint8(s[5] >> 15)
+		d[21] = uint8(s[5]>>23) | uint8(s[6]<<6)
+		d[22] = uint8(s[6] >> 2)
+		d[23] = uint8(s[6] >> 10)
+		d[24] = uint8(s[6] >> 18)
+		d[25] = uint8(s[6]>>26) | uint8(s[7]<<3)
+		d[26] = uint8(s[7] >> 5)
+		d[27] = uint8(s[7] >> 13)
+		d[28] = uint8(s[7] >> 21)
+		n += copy(dst[n:], d[:(len(src)*29+7)/8])
+	}
+	return n
+}
+
+// UnpackUint29Uint32Slice unpacks len(dst) 29-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*29+7)/8 bytes.
+func UnpackUint29Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+29 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 536870911
+		d[1] = (uint32(s[3])>>5 | uint32(s[4])<<3 | uint32(s[5])<<11 | uint32(s[6])<<19 | uint32(s[7])<<27) & 536870911
+		d[2] = (uint32(s[7])>>2 | uint32(s[8])<<6 | uint32(s[9])<<14 | uint32(s[10])<<22) & 536870911
+		d[3] = (uint32(s[10])>>7 | uint32(s[11])<<1 | uint32(s[12])<<9 | uint32(s[13])<<17 | uint32(s[14])<<25) & 536870911
+		d[4] = (uint32(s[14])>>4 | uint32(s[15])<<4 | uint32(s[16])<<12 | uint32(s[17])<<20 | uint32(s[18])<<28) & 536870911
+		d[5] = (uint32(s[18])>>1 | uint32(s[19])<<7 | uint32(s[20])<<15 | uint32(s[21])<<23) & 536870911
+		d[6] = (uint32(s[21])>>6 | uint32(s[22])<<2 | uint32(s[23])<<10 | uint32(s[24])<<18 | uint32(s[25])<<26) & 536870911
+		d[7] = (uint32(s[25])>>3 | uint32(s[26])<<5 | uint32(s[27])<<13 | uint32(s[28])<<21) & 536870911
+		n += 29
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [29]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*29+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 536870911
+		d[1] = (uint32(s[3])>>5 | uint32(s[4])<<3 | uint32(s[5])<<11 | uint32(s[6])<<19 | uint32(s[7])<<27) & 536870911
+		d[2] = (uint32(s[7])>>2 | uint32(s[8])<<6 | uint32(s[9])<<14 | uint32(s[10])<<22) & 536870911
+		d[3] = (uint32(s[10])>>7 | uint32(s[11])<<1 | uint32(s[12])<<9 | uint32(s[13])<<17 | uint32(s[14])<<25) & 536870911
+		d[4] = (uint32(s[14])>>4 | uint32(s[15])<<4 | uint32(s[16])<<12 | uint32(s[17])<<20 | uint32(s[18])<<28) & 536870911
+		d[5] = (uint32(s[18])>>1 | uint32(s[19])<<7 | uint32(s[20])<<15 | uint32(s[21])<<23) & 536870911
+		d[6] = (uint32(s[21])>>6 | uint32(s[22])<<2 | uint32(s[23])<<10 | uint32(s[24])<<18 | uint32(s[25])<<26) & 536870911
+		d[7] = (uint32(s[25])>>3 | uint32(s[26])<<5 | uint32(s[27])<<13 | uint32(s[28])<<21) & 536870911
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint30Uint32Slice packs the lowest 30 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*30+7)/8 bytes and the values must fit in 30 bits.
+func PackUint30Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+30 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<6)
+		d[4] = uint8(s[1] >> 2)
+		d[5] = uint8(s[1] >> 10)
+		d[6] = uint8(s[1] >> 18)
+		d[7] = uint8(s[1]>>26) | uint8(s[2]<<4)
+		d[8] = uint8(s[2] >> 4)
+		d[9] = uint8(s[2] >> 12)
+		d[10] = uint8(s[2] >> 20)
+		d[11] = uint8(s[2]>>28) | uint8(s[3]<<2)
+		d[12] = uint8(s[3] >> 6)
+		d[13] = uint8(s[3] >> 14)
+		d[14] = uint8(s[3] >> 22)
+		d[15] = uint8(s[4])
+		d[16] = uint8(s[4] >> 8)
+		d[17] = uint8(s[4] >> 16)
+		d[18] = uint8(s[4]>>24) | uint8(s[5]<<6)
+		d[19] = uint8(s[5] >> 2)
+		d[20] = uint8(s[5] >> 10)
+		d[21] = uint8(s[5] >> 18)
+		d[22] = uint8(s[5]>>26) | uint8(s[6]<<4)
+		d[23] = uint8(s[6] >> 4)
+		d[24] = uint8(s[6] >> 12)
+		d[25] = uint8(s[6] >> 20)
+		d[26] = uint8(s[6]>>28) | uint8(s[7]<<2)
+		d[27] = uint8(s[7] >> 6)
+		d[28] = uint8(s[7] >> 14)
+		d[29] = uint8(s[7] >> 22)
+		n += 30
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [30]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<6)
+		d[4] = uint8(s[1] >> 2)
+		d[5] = uint8(s[1] >> 10)
+		d[6] = uint8(s[1] >> 18)
+		d[7] = uint8(s[1]>>26) | uint8(s[2]<<4)
+		d[8] = uint8(s[2] >> 4)
+		d[9] = uint8(s[2] >> 12)
+		d[10] = uint8(s[2] >> 20)
+		d[11] = uint8(s[2]>>28) | uint8(s[3]<<2)
+		d[12] = uint8(s[3] >> 6)
+		d[13] = uint8(s[3] >> 14)
+		d[14] = uint8(s[3] >> 22)
+		d[15] = uint8(s[4])
+		d[16] = uint8(s[4] >> 8)
+		d[17] = uint8(s[4] >> 16)
+		d[18] = uint8(s[4]>>24) | uint8(s[5]<<6)
+		d[19] = uint8(s[5] >> 2)
+		d[20] = uint8(s[5] >> 10)
+		d[21] = uint8(s[5] >> 18)
+		d[22] = uint8(s[5]>>26) | uint8(s[6]<<4)
+		d[23] = uint8(s[6] >> 4)
+		d[24] = uint8(s[6] >> 12)
+		d[25] = uint8(s[6] >> 20)
+		d[26] = uint8(s[6]>>28) | uint8(s[7]<<2)
+		d[27] = uint8(s[7] >> 6)
+		d[28] = uint8(s[7] >> 14)
+		d[29] = uint8(s[7] >> 22)
+		n += copy(dst[n:], d[:(len(src)*30+7)/8])
+	}
+	return n
+}
+
+// UnpackUint30Uint32Slice unpacks len(dst) 30-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*30+7)/8 bytes.
+func UnpackUint30Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+30 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 1073741823
+		d[1] = (uint32(s[3])>>6 | uint32(s[4])<<2 | uint32(s[5])<<10 | uint32(s[6])<<18 | uint32(s[7])<<26) & 1073741823
+		d[2] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12 | uint32(s[10])<<20 | uint32(s[11])<<28) & 1073741823
+		d[3] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14 | uint32(s[14])<<22) & 1073741823
+		d[4] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16 | uint32(s[18])<<24) & 1073741823
+		d[5] = (uint32(s[18])>>6 | uint32(s[19])<<2 | uint32(s[20])<<10 | uint32(s[21])<<18 | uint32(s[22])<<26) & 1073741823
+		d[6] = (uint32(s[22])>>4 | uint32(s[23])<<4 | uint32(s[24])<<12 | uint32(s[25])<<20 | uint32(s[26])<<28) & 1073741823
+		d[7] = (uint32(s[26])>>2 | uint32(s[27])<<6 | uint32(s[28])<<14 | uint32(s[29])<<22) & 1073741823
+		n += 30
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [30]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*30+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 1073741823
+		d[1] = (uint32(s[3])>>6 | uint32(s[4])<<2 | uint32(s[5])<<10 | uint32(s[6])<<18 | uint32(s[7])<<26) & 1073741823
+		d[2] = (uint32(s[7])>>4 | uint32(s[8])<<4 | uint32(s[9])<<12 | uint32(s[10])<<20 | uint32(s[11])<<28) & 1073741823
+		d[3] = (uint32(s[11])>>2 | uint32(s[12])<<6 | uint32(s[13])<<14 | uint32(s[14])<<22) & 1073741823
+		d[4] = (uint32(s[15]) | uint32(s[16])<<8 | uint32(s[17])<<16 | uint32(s[18])<<24) & 1073741823
+		d[5] = (uint32(s[18])>>6 | uint32(s[19])<<2 | uint32(s[20])<<10 | uint32(s[21])<<18 | uint32(s[22])<<26) & 1073741823
+		d[6] = (uint32(s[22])>>4 | uint32(s[23])<<4 | uint32(s[24])<<12 | uint32(s[25])<<20 | uint32(s[26])<<28) & 1073741823
+		d[7] = (uint32(s[26])>>2 | uint32(s[27])<<6 | uint32(s[28])<<14 | uint32(s[29])<<22) & 1073741823
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint31Uint32Slice packs the lowest 31 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*31+7)/8 bytes and the values must fit in 31 bits.
+func PackUint31Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+31 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<7)
+		d[4] = uint8(s[1] >> 1)
+		d[5] = uint8(s[1] >> 9)
+		d[6] = uint8(s[1] >> 17)
+		d[7] = uint8(s[1]>>25) | uint8(s[2]<<6)
+		d[8] = uint8(s[2] >> 2)
+		d[9] = uint8(s[2] >> 10)
+		d[10] = uint8(s[2] >> 18)
+		d[11] = uint8(s[2]>>26) | uint8(s[3]<<5)
+		d[12] = uint8(s[3] >> 3)
+		d[13] = uint8(s[3] >> 11)
+		d[14] = uint8(s[3] >> 19)
+		d[15] = uint8(s[3]>>27) | uint8(s[4]<<4)
+		d[16] = uint8(s[4] >> 4)
+		d[17] = uint8(s[4] >> 12)
+		d[18] = uint8(s[4] >> 20)
+		d[19] = uint8(s[4]>>28) | uint8(s[5]<<3)
+		d[20] = uint8(s[5] >> 5)
+		d[21] = uint8(s[5] >> 13)
+		d[22] = uint8(s[5] >> 21)
+		d[23] = uint8(s[5]>>29) | uint8(s[6]<<2)
+		d[24] = uint8(s[6] >> 6)
+		d[25] = uint8(s[6] >> 14)
+		d[26] = uint8(s[6] >> 22)
+		d[27] = uint8(s[6]>>30) | uint8(s[7]<<1)
+		d[28] = uint8(s[7] >> 7)
+		d[29] = uint8(s[7] >> 15)
+		d[30] = uint8(s[7] >> 23)
+		n += 31
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [31]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0]>>24) | uint8(s[1]<<7)
+		d[4] = uint8(s[1] >> 1)
+		d[5] = uint8(s[1] >> 9)
+		d[6] = uint8(s[1] >> 17)
+		d[7] = uint8(s[1]>>25) | uint8(s[2]<<6)
+		d[8] = uint8(s[2] >> 2)
+		d[9] = uint8(s[2] >> 10)
+		d[10] = uint8(s[2] >> 18)
+		d[11] = uint8(s[2]>>26) | uint8(s[3]<<5)
+		d[12] = uint8(s[3] >> 3)
+		d[13] = uint8(s[3] >> 11)
+		d[14] = uint8(s[3] >> 19)
+		d[15] = uint8(s[3]>>27) | uint8(s[4]<<4)
+		d[16] = uint8(s[4] >> 4)
+		d[17] = uint8(s[4] >> 12)
+		d[18] = uint8(s[4] >> 20)
+		d[19] = uint8(s[4]>>28) | uint8(s[5]<<3)
+		d[20] = uint8(s[5] >> 5)
+		d[21] = uint8(s[5] >> 13)
+		d[22] = uint8(s[5] >> 21)
+		d[23] = uint8(s[5]>>29) | uint8(s[6]<<2)
+		d[24] = uint8(s[6] >> 6)
+		d[25] = uint8(s[6] >> 14)
+		d[26] = uint8(s[6] >> 22)
+		d[27] = uint8(s[6]>>30) | uint8(s[7]<<1)
+		d[28] = uint8(s[7] >> 7)
+		d[29] = uint8(s[7] >> 15)
+		d[30] = uint8(s[7] >> 23)
+		n += copy(dst[n:], d[:(len(src)*31+7)/8])
+	}
+	return n
+}
+
+// UnpackUint31Uint32Slice unpacks len(dst) 31-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*31+7)/8 bytes.
+func UnpackUint31Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+31 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 2147483647
+		d[1] = (uint32(s[3])>>7 | uint32(s[4])<<1 | uint32(s[5])<<9 | uint32(s[6])<<17 | uint32(s[7])<<25) & 2147483647
+		d[2] = (uint32(s[7])>>6 | uint32(s[8])<<2 | uint32(s[9])<<10 | uint32(s[10])<<18 | uint32(s[11])<<26) & 2147483647
+		d[3] = (uint32(s[11])>>5 | uint32(s[12])<<3 | uint32(s[13])<<11 | uint32(s[14])<<19 | uint32(s[15])<<27) & 2147483647
+		d[4] = (uint32(s[15])>>4 | uint32(s[16])<<4 | uint32(s[17])<<12 | uint32(s[18])<<20 | uint32(s[19])<<28) & 2147483647
+		d[5] = (uint32(s[19])>>3 | uint32(s[20])<<5 | uint32(s[21])<<13 | uint32(s[22])<<21 | uint32(s[23])<<29) & 2147483647
+		d[6] = (uint32(s[23])>>2 | uint32(s[24])<<6 | uint32(s[25])<<14 | uint32(s[26])<<22 | uint32(s[27])<<30) & 2147483647
+		d[7] = (uint32(s[27])>>1 | uint32(s[28])<<7 | uint32(s[29])<<15 | uint32(s[30])<<23) & 2147483647
+		n += 31
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [31]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*31+7)/8])
+		d[0] = (uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24) & 2147483647
+		d[1] = (uint32(s[3])>>7 | uint32(s[4])<<1 | uint32(s[5])<<9 | uint32(s[6])<<17 | uint32(s[7])<<25) & 2147483647
+		d[2] = (uint32(s[7])>>6 | uint32(s[8])<<2 | uint32(s[9])<<10 | uint32(s[10])<<18 | uint32(s[11])<<26) & 2147483647
+		d[3] = (uint32(s[11])>>5 | uint32(s[12])<<3 | uint32(s[13])<<11 | uint32(s[14])<<19 | uint32(s[15])<<27) & 2147483647
+		d[4] = (uint32(s[15])>>4 | uint32(s[16])<<4 | uint32(s[17])<<12 | uint32(s[18])<<20 | uint32(s[19])<<28) & 2147483647
+		d[5] = (uint32(s[19])>>3 | uint32(s[20])<<5 | uint32(s[21])<<13 | uint32(s[22])<<21 | uint32(s[23])<<29) & 2147483647
+		d[6] = (uint32(s[23])>>2 | uint32(s[24])<<6 | uint32(s[25])<<14 | uint32(s[26])<<22 | uint32(s[27])<<30) & 2147483647
+		d[7] = (uint32(s[27])>>1 | uint32(s[28])<<7 | uint32(s[29])<<15 | uint32(s[30])<<23) & 2147483647
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackUint32Uint32Slice packs the lowest 32 bits of each value of src into dst and returns the number of bytes written.
+// The dst slice must have room for (len(src)*32+7)/8 bytes and the values must fit in 32 bits.
+func PackUint32Uint32Slice(dst []byte, src []uint32) int {
+	n := 0
+	for len(src) >= 8 {
+		s := src[:8:len(src)]
+		d := dst[n : n+32 : len(dst)]
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0] >> 24)
+		d[4] = uint8(s[1])
+		d[5] = uint8(s[1] >> 8)
+		d[6] = uint8(s[1] >> 16)
+		d[7] = uint8(s[1] >> 24)
+		d[8] = uint8(s[2])
+		d[9] = uint8(s[2] >> 8)
+		d[10] = uint8(s[2] >> 16)
+		d[11] = uint8(s[2] >> 24)
+		d[12] = uint8(s[3])
+		d[13] = uint8(s[3] >> 8)
+		d[14] = uint8(s[3] >> 16)
+		d[15] = uint8(s[3] >> 24)
+		d[16] = uint8(s[4])
+		d[17] = uint8(s[4] >> 8)
+		d[18] = uint8(s[4] >> 16)
+		d[19] = uint8(s[4] >> 24)
+		d[20] = uint8(s[5])
+		d[21] = uint8(s[5] >> 8)
+		d[22] = uint8(s[5] >> 16)
+		d[23] = uint8(s[5] >> 24)
+		d[24] = uint8(s[6])
+		d[25] = uint8(s[6] >> 8)
+		d[26] = uint8(s[6] >> 16)
+		d[27] = uint8(s[6] >> 24)
+		d[28] = uint8(s[7])
+		d[29] = uint8(s[7] >> 8)
+		d[30] = uint8(s[7] >> 16)
+		d[31] = uint8(s[7] >> 24)
+		n += 32
+		src = src[8:]
+	}
+	if len(src) > 0 {
+		var s [8]uint32
+		var d [32]byte
+		copy(s[:], src)
+		d[0] = uint8(s[0])
+		d[1] = uint8(s[0] >> 8)
+		d[2] = uint8(s[0] >> 16)
+		d[3] = uint8(s[0] >> 24)
+		d[4] = uint8(s[1])
+		d[5] = uint8(s[1] >> 8)
+		d[6] = uint8(s[1] >> 16)
+		d[7] = uint8(s[1] >> 24)
+		d[8] = uint8(s[2])
+		d[9] = uint8(s[2] >> 8)
+		d[10] = uint8(s[2] >> 16)
+		d[11] = uint8(s[2] >> 24)
+		d[12] = uint8(s[3])
+		d[13] = uint8(s[3] >> 8)
+		d[14] = uint8(s[3] >> 16)
+		d[15] = uint8(s[3] >> 24)
+		d[16] = uint8(s[4])
+		d[17] = uint8(s[4] >> 8)
+		d[18] = uint8(s[4] >> 16)
+		d[19] = uint8(s[4] >> 24)
+		d[20] = uint8(s[5])
+		d[21] = uint8(s[5] >> 8)
+		d[22] = uint8(s[5] >> 16)
+		d[23] = uint8(s[5] >> 24)
+		d[24] = uint8(s[6])
+		d[25] = uint8(s[6] >> 8)
+		d[26] = uint8(s[6] >> 16)
+		d[27] = uint8(s[6] >> 24)
+		d[28] = uint8(s[7])
+		d[29] = uint8(s[7] >> 8)
+		d[30] = uint8(s[7] >> 16)
+		d[31] = uint8(s[7] >> 24)
+		n += copy(dst[n:], d[:(len(src)*32+7)/8])
+	}
+	return n
+}
+
+// UnpackUint32Uint32Slice unpacks len(dst) 32-bit values from src and returns the number of bytes read.
+// The src slice must contain at least (len(dst)*32+7)/8 bytes.
+func UnpackUint32Uint32Slice(dst []uint32, src []byte) int {
+	n := 0
+	for len(dst) >= 8 {
+		s := src[n : n+32 : len(src)]
+		d := dst[:8:len(dst)]
+		d[0] = uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+		d[1] = uint32(s[4]) | uint32(s[5])<<8 | uint32(s[6])<<16 | uint32(s[7])<<24
+		d[2] = uint32(s[8]) | uint32(s[9])<<8 | uint32(s[10])<<16 | uint32(s[11])<<24
+		d[3] = uint32(s[12]) | uint32(s[13])<<8 | uint32(s[14])<<16 | uint32(s[15])<<24
+		d[4] = uint32(s[16]) | uint32(s[17])<<8 | uint32(s[18])<<16 | uint32(s[19])<<24
+		d[5] = uint32(s[20]) | uint32(s[21])<<8 | uint32(s[22])<<16 | uint32(s[23])<<24
+		d[6] = uint32(s[24]) | uint32(s[25])<<8 | uint32(s[26])<<16 | uint32(s[27])<<24
+		d[7] = uint32(s[28]) | uint32(s[29])<<8 | uint32(s[30])<<16 | uint32(s[31])<<24
+		n += 32
+		dst = dst[8:]
+	}
+	if len(dst) > 0 {
+		var s [32]byte
+		var d [8]uint32
+		m := copy(s[:], src[n:n+(len(dst)*32+7)/8])
+		d[0] = uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+		d[1] = uint32(s[4]) | uint32(s[5])<<8 | uint32(s[6])<<16 | uint32(s[7])<<24
+		d[2] = uint32(s[8]) | uint32(s[9])<<8 | uint32(s[10])<<16 | uint32(s[11])<<24
+		d[3] = uint32(s[12]) | uint32(s[13])<<8 | uint32(s[14])<<16 | uint32(s[15])<<24
+		d[4] = uint32(s[16]) | uint32(s[17])<<8 | uint32(s[18])<<16 | uint32(s[19])<<24
+		d[5] = uint32(s[20]) | uint32(s[21])<<8 | uint32(s[22])<<16 | uint32(s[23])<<24
+		d[6] = uint32(s[24]) | uint32(s[25])<<8 | uint32(s[26])<<16 | uint32(s[27])<<24
+		d[7] = uint32(s[28]) | uint32(s[29])<<8 | uint32(s[30])<<16 | uint32(s[31])<<24
+		copy(dst, d[:])
+		n += m
+	}
+	return n
+}
+
+// PackBits packs the lowest bits of each value of src into dst using PackUint<bits>Uint32Slice.
+// Nothing is written if bits is zero.
+func PackBits(bits int, dst []byte, src []uint32) int {
+	switch bits {
+	case 1:
+		return PackUint1Uint32Slice(dst, src)
+	case 2:
+		return PackUint2Uint32Slice(dst, src)
+	case 3:
+		return PackUint3Uint32Slice(dst, src)
+	case 4:
+		return PackUint4Uint32Slice(dst, src)
+	case 5:
+		return PackUint5Uint32Slice(dst, src)
+	case 6:
+		return PackUint6Uint32Slice(dst, src)
+	case 7:
+		return PackUint7Uint32Slice(dst, src)
+	case 8:
+		return PackUint8Uint32Slice(dst, src)
+	case 9:
+		return PackUint9Uint32Slice(dst, src)
+	case 10:
+		return PackUint10Uint32Slice(dst, src)
+	case 11:
+		return PackUint11Uint32Slice(dst, src)
+	case 12:
+		return PackUint12Uint32Slice(dst, src)
+	case 13:
+		return PackUint13Uint32Slice(dst, src)
+	case 14:
+		return PackUint14Uint32Slice(dst, src)
+	case 15:
+		return PackUint15Uint32Slice(dst, src)
+	case 16:
+		return PackUint16Uint32Slice(dst, src)
+	case 17:
+		return PackUint17Uint32Slice(dst, src)
+	case 18:
+		return PackUint18Uint32Slice(dst, src)
+	case 19:
+		return PackUint19Uint32Slice(dst, src)
+	case 20:
+		return PackUint20Uint32Slice(dst, src)
+	case 21:
+		return PackUint21Uint32Slice(dst, src)
+	case 22:
+		return PackUint22Uint32Slice(dst, src)
+	case 23:
+		return PackUint23Uint32Slice(dst, src)
+	case 24:
+		return PackUint24Uint32Slice(dst, src)
+	case 25:
+		return PackUint25Uint32Slice(dst, src)
+	case 26:
+		return PackUint26Uint32Slice(dst, src)
+	case 27:
+		return PackUint27Uint32Slice(dst, src)
+	case 28:
+		return PackUint28Uint32Slice(dst, src)
+	case 29:
+		return PackUint29Uint32Slice(dst, src)
+	case 30:
+		return PackUint30Uint32Slice(dst, src)
+	case 31:
+		return PackUint31Uint32Slice(dst, src)
+	case 32:
+		return PackUint32Uint32Slice(dst, src)
+	}
+	return 0
+}
+
+// UnpackBits unpacks len(dst) values from src using UnpackUint<bits>Uint32Slice.
+// All values are zero if bits is zero.
+func UnpackBits(bits int, dst []uint32, src []byte) int {
+	switch bits {
+	case 1:
+		return UnpackUint1Uint32Slice(dst, src)
+	case 2:
+		return UnpackUint2Uint32Slice(dst, src)
+	case 3:
+		return UnpackUint3Uint32Slice(dst, src)
+	case 4:
+		return UnpackUint4Uint32Slice(dst, src)
+	case 5:
+		return UnpackUint5Uint32Slice(dst, src)
+	case 6:
+		return UnpackUint6Uint32Slice(dst, src)
+	case 7:
+		return UnpackUint7Uint32Slice(dst, src)
+	case 8:
+		return UnpackUint8Uint32Slice(dst, src)
+	case 9:
+		return UnpackUint9Uint32Slice(dst, src)
+	case 10:
+		return UnpackUint10Uint32Slice(dst, src)
+	case 11:
+		return UnpackUint11Uint32Slice(dst, src)
+	case 12:
+		return UnpackUint12Uint32Slice(dst, src)
+	case 13:
+		return UnpackUint13Uint32Slice(dst, src)
+	case 14:
+		return UnpackUint14Uint32Slice(dst, src)
+	case 15:
+		return UnpackUint15Uint32Slice(dst, src)
+	case 16:
+		return UnpackUint16Uint32Slice(dst, src)
+	case 17:
+		return UnpackUint17Uint32Slice(dst, src)
+	case 18:
+		return UnpackUint18Uint32Slice(dst, src)
+	case 19:
+		return UnpackUint19Uint32Slice(dst, src)
+	case 20:
+		return UnpackUint20Uint32Slice(dst, src)
+	case 21:
+		return UnpackUint21Uint32Slice(dst, src)
+	case 22:
+		return UnpackUint22Uint32Slice(dst, src)
+	case 23:
+		return UnpackUint23Uint32Slice(dst, src)
+	case 24:
+		return UnpackUint24Uint32Slice(dst, src)
+	case 25:
+		return UnpackUint25Uint32Slice(dst, src)
+	case 26:
+		return UnpackUint26Uint32Slice(dst, src)
+	case 27:
+		return UnpackUint27Uint32Slice(dst, src)
+	case 28:
+		return UnpackUint28Uint32Slice(dst, src)
+	case 29:
+		return UnpackUint29Uint32Slice(dst, src)
+	case 30:
+		return UnpackUint30Uint32Slice(dst, src)
+	case 31:
+		return UnpackUint31Uint32Slice(dst, src)
+	case 32:
+		return UnpackUint32Uint32Slice(dst, src)
+	}
+	for i := range dst {
+		dst[i] = 0
+	}
+	return 0
+}
@@ -13,17 +13,18 @@ import (
 
 func TestPackUnpack(t *testing.T) {
 	cases := []struct {
-		bits     uint
-		unpackFn func([]byte) []uint8
-		packFn   func([]byte, []uint8) int
+		bits       uint
+		unpackFn   func([]byte) []uint8
+		unpackToFn func([]uint8, []byte) int
+		packFn     func([]byte, []uint8) int
 	}{
-		{bits: 1, unpackFn: UnpackUint1Slice, packFn: PackUint1Slice},
-		{bits: 2, unpackFn: UnpackUint2Slice, packFn: PackUint2Slice},
-		{bits: 3, unpackFn: UnpackUint3Slice, packFn: PackUint3Slice},
-		{bits: 4, unpackFn: UnpackUint4Slice, packFn: PackUint4Slice},
-		{bits: 5, unpackFn: UnpackUint5Slice, packFn: PackUint5Slice},
-		{bits: 6, unpackFn: UnpackUint6Slice, packFn: PackUint6Slice},
-		{bits: 7, unpackFn: UnpackUint7Slice, packFn: PackUint7Slice},
+		{bits: 1, unpackFn: UnpackUint1Slice, unpackToFn: UnpackUint1SliceTo, packFn: PackUint1Slice},
+		{bits: 2, unpackFn: UnpackUint2Slice, unpackToFn: UnpackUint2SliceTo, packFn: PackUint2Slice},
+		{bits: 3, unpackFn: UnpackUint3Slice, unpackToFn: UnpackUint3SliceTo, packFn: PackUint3Slice},
+		{bits: 4, unpackFn: UnpackUint4Slice, unpackToFn: UnpackUint4SliceTo, packFn: PackUint4Slice},
+		{bits: 5, unpackFn: UnpackUint5Slice, unpackToFn: UnpackUint5SliceTo, packFn: PackUint5Slice},
+		{bits: 6, unpackFn: UnpackUint6Slice, unpackToFn: UnpackUint6SliceTo, packFn: PackUint6Slice},
+		{bits: 7, unpackFn: UnpackUint7Slice, unpackToFn: UnpackUint7SliceTo, packFn: PackUint7Slice},
 	}
 	for _, c := range cases {
 		values := make([]uint8, 256)
@@ -59,6 +60,17 @@ func TestPackUnpack(t *testing.T) {
 			}
 		})
 
+		t.Run(fmt.Sprintf("Bits=%d,UnpackTo", c.bits), func(t *testing.T) {
+			buf := make([]uint8, len(values)*2)
+			for i := range values {
+				packed := pack(i)
+				expected := c.unpackFn(packed)
+				n := c.unpackToFn(buf, packed)
+				require.Equal(t, len(expected), n)
+				require.Equal(t, expected, buf[:n])
+			}
+		})
+
 		t.Run(fmt.Sprintf("Bits=%d,Pack", c.bits), func(t *testing.T) {
 			for i := range values {
 				expected := pack(i)
@@ -95,3 +107,72 @@ func BenchmarkUnpackInt5Array(b *testing.B) {
 		UnpackUint5Slice(data)
 	}
 }
+
+func BenchmarkUnpackInt3ArrayTo(b *testing.B) {
+	r := rand.New(rand.NewSource(1234))
+	data := make([]byte, 1024)
+	for i := range data {
+		data[i] = byte(r.Uint32() & 0xff)
+	}
+	values := make([]uint8, len(data)*8/3)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		UnpackUint3SliceTo(values, data)
+	}
+}
+
+func TestPackUnpackBits(t *testing.T) {
+	r := rand.New(rand.NewSource(1234))
+	for bits := 0; bits <= 32; bits++ {
+		mask := uint32(1<<uint(bits) - 1)
+		for size := 0; size <= 40; size++ {
+			values := make([]uint32, size)
+			for i := range values {
+				values[i] = r.Uint32() & mask
+			}
+
+			// Pack the values one bit at a time.
+			expected := make([]byte, (size*bits+7)/8)
+			for i, value := range values {
+				for b := 0; b < bits; b++ {
+					if value&(1<<uint(b)) != 0 {
+						pos := i*bits + b
+						expected[pos/8] |= 1 << uint(pos%8)
+					}
+				}
+			}
+
+			packed := make([]byte, len(expected)+4)
+			n := PackBits(bits, packed, values)
+			require.Equal(t, expected, packed[:n], "bits=%d size=%d", bits, size)
+
+			unpacked := make([]uint32, size)
+			for i := range unpacked {
+				unpacked[i] = 0xffffffff
+			}
+			m := UnpackBits(bits, unpacked, expected)
+			require.Equal(t, len(expected), m, "bits=%d size=%d", bits, size)
+			require.Equal(t, values, unpacked, "bits=%d size=%d", bits, size)
+		}
+	}
+}
+
+func benchmarkUnpackBits(b *testing.B, bits int) {
+	r := rand.New(rand.NewSource(1234))
+	values := make([]uint32, 1024)
+	data := make([]byte, len(values)*4)
+	for i := range data {
+		data[i] = byte(r.Uint32() & 0xff)
+	}
+	b.SetBytes(int64(len(values) * 4))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		UnpackBits(bits, values, data)
+	}
+}
+
+func BenchmarkUnpackBits5(b *testing.B)  { benchmarkUnpackBits(b, 5) }
+func BenchmarkUnpackBits13(b *testing.B) { benchmarkUnpackBits(b, 13) }
+func BenchmarkUnpackBits32(b *testing.B) { benchmarkUnpackBits(b, 32) }
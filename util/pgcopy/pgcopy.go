@@ -0,0 +1,200 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+// Package pgcopy implements a reader for the text format used by the PostgreSQL COPY command.
+//
+// Each row is one line with columns separated by tabs. NULL values are written as \N and
+// special characters in values are escaped with a backslash. The data can be optionally
+// terminated by a line containing only \. which is how psql marks the end of the data.
+package pgcopy
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reader reads rows in the COPY text format.
+type Reader struct {
+	// Delimiter is the column separator, a tab by default.
+	Delimiter byte
+
+	r    *bufio.Reader
+	line int
+	buf  bytes.Buffer
+	done bool
+}
+
+// NewReader creates a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{Delimiter: '\t', r: bufio.NewReader(r)}
+}
+
+// Line returns the number of the last line that was read.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Read reads one row and returns its values. NULL values are returned as invalid sql.NullString values.
+// Returns io.EOF when there are no more rows.
+func (r *Reader) Read() ([]sql.NullString, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	line, err := r.r.ReadString('\n')
+	if err != nil {
+		if err != io.EOF {
+			return nil, errors.Wrap(err, "read failed")
+		}
+		if line == "" {
+			r.done = true
+			return nil, io.EOF
+		}
+	}
+	r.line++
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if line == `\.` {
+		r.done = true
+		return nil, io.EOF
+	}
+
+	var row []sql.NullString
+	for {
+		i := strings.IndexByte(line, r.Delimiter)
+		field := line
+		if i >= 0 {
+			field = line[:i]
+		}
+		value, err := r.unescape(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d, column %d", r.line, len(row)+1)
+		}
+		row = append(row, value)
+		if i < 0 {
+			break
+		}
+		line = line[i+1:]
+	}
+	return row, nil
+}
+
+// ReadHeader reads the first row and returns it as a list of column names.
+func (r *Reader) ReadHeader() ([]string, error) {
+	row, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("missing header")
+		}
+		return nil, err
+	}
+	names := make([]string, len(row))
+	for i, value := range row {
+		if !value.Valid {
+			return nil, errors.Errorf("column %d has no name", i+1)
+		}
+		names[i] = value.String
+	}
+	return names, nil
+}
+
+func (r *Reader) unescape(field string) (sql.NullString, error) {
+	if field == `\N` {
+		return sql.NullString{}, nil
+	}
+	if strings.IndexByte(field, '\\') < 0 {
+		return sql.NullString{String: field, Valid: true}, nil
+	}
+
+	r.buf.Reset()
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' {
+			r.buf.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(field) {
+			return sql.NullString{}, errors.New("unterminated escape sequence")
+		}
+		c = field[i]
+		switch c {
+		case 'b':
+			r.buf.WriteByte('\b')
+		case 'f':
+			r.buf.WriteByte('\f')
+		case 'n':
+			r.buf.WriteByte('\n')
+		case 'r':
+			r.buf.WriteByte('\r')
+		case 't':
+			r.buf.WriteByte('\t')
+		case 'v':
+			r.buf.WriteByte('\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// Octal value with one to three digits.
+			j := i + 1
+			for j < len(field) && j < i+3 && field[j] >= '0' && field[j] <= '7' {
+				j++
+			}
+			x, _ := strconv.ParseUint(field[i:j], 8, 16)
+			r.buf.WriteByte(byte(x))
+			i = j - 1
+		case 'x':
+			// Hexadecimal value with one or two digits.
+			j := i + 1
+			for j < len(field) && j < i+3 && isHexDigit(field[j]) {
+				j++
+			}
+			if j == i+1 {
+				// Not followed by a hex digit, treated as a plain character.
+				r.buf.WriteByte(c)
+				break
+			}
+			x, _ := strconv.ParseUint(field[i+1:j], 16, 8)
+			r.buf.WriteByte(byte(x))
+			i = j - 1
+		default:
+			r.buf.WriteByte(c)
+		}
+	}
+	return sql.NullString{String: r.buf.String(), Valid: true}, nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// ParseInt32Array parses a one-dimensional PostgreSQL array of integers, e.g. "{1,-2,3}".
+// The values are appended to dst.
+func ParseInt32Array(s string, dst []int32) ([]int32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, errors.New("invalid array")
+	}
+	s = s[1 : len(s)-1]
+	if strings.TrimSpace(s) == "" {
+		return dst, nil
+	}
+	for _, elem := range strings.Split(s, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			return nil, errors.New("empty array element")
+		}
+		if strings.EqualFold(elem, "NULL") {
+			return nil, errors.New("NULL values are not supported")
+		}
+		x, err := strconv.ParseInt(elem, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid array element %q", elem)
+		}
+		dst = append(dst, int32(x))
+	}
+	return dst, nil
+}
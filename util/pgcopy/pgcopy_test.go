@@ -0,0 +1,83 @@
+// Copyright (C) 2016  Lukas Lalinsky
+// Distributed under the MIT license, see the LICENSE file for details.
+
+package pgcopy
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func value(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+func TestReader(t *testing.T) {
+	input := "id\tfingerprint\tnote\n" +
+		"1\t{1,-2,3}\t\\N\n" +
+		"2\t{}\ta\\tb\\\\c\\nd\r\n" +
+		"3\t{4}\t\\101\\x42\\x\\q\n" +
+		"\\.\n" +
+		"4\t{5}\t\n"
+	r := NewReader(strings.NewReader(input))
+
+	header, err := r.ReadHeader()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "fingerprint", "note"}, header)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{value("1"), value("{1,-2,3}"), {Valid: false}}, row)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{value("2"), value("{}"), value("a\tb\\c\nd")}, row)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{value("3"), value("{4}"), value("ABxq")}, row)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err, "reading should stop at the end-of-data marker")
+	assert.Equal(t, 5, r.Line())
+}
+
+func TestReader_NoTrailingNewline(t *testing.T) {
+	r := NewReader(strings.NewReader("1\t\n2\tx"))
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{value("1"), value("")}, row)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []sql.NullString{value("2"), value("x")}, row)
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReader_InvalidEscape(t *testing.T) {
+	r := NewReader(strings.NewReader("1\tabc\\\n"))
+	_, err := r.Read()
+	assert.Error(t, err)
+}
+
+func TestParseInt32Array(t *testing.T) {
+	values, err := ParseInt32Array("{1, -2,2147483647,-2147483648}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, -2, 2147483647, -2147483648}, values)
+
+	values, err = ParseInt32Array("{}", nil)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	for _, s := range []string{"", "1,2", "{1,2", "{1,,2}", "{1,2,}", "{,1}", "{ , }", "{1,NULL}", "{2147483648}", "{{1,2}}"} {
+		_, err = ParseInt32Array(s, nil)
+		assert.Error(t, err, "%q should be invalid", s)
+	}
+}
@@ -78,6 +78,7 @@ func TestFileReadSeek(t *testing.T) {
 		}
 		if file, err := fs.OpenFile("foo"); assert.NoError(t, err) {
 			defer file.Close()
+			assert.Equal(t, int64(10), file.Size())
 			buf := make([]byte, 10)
 			if n, err := file.Read(buf[:2]); assert.NoError(t, err) {
 				assert.Equal(t, 2, n)